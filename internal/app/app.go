@@ -3,7 +3,10 @@ package app
 import (
 	"context"
 	"errors"
+	"expvar"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/atomic"
@@ -11,6 +14,7 @@ import (
 
 	"mascot/internal/config"
 	"mascot/internal/db"
+	"mascot/internal/domain"
 	"mascot/internal/handlers"
 	"mascot/internal/repositories"
 	"mascot/internal/services"
@@ -34,45 +38,272 @@ func (s *Service) Start(ctx context.Context, cfg config.Config) {
 		transport.RecoverMiddleware(s.logger),
 	)
 
-	conn, err := pgxpool.Connect(context.Background(), cfg.PostgresDSN)
+	switch cfg.Storage {
+	case config.StorageMemory:
+		s.startMemory(ctx, cfg, server)
+		return
+	case config.StoragePostgres:
+	default:
+		s.logger.Fatal("unknown storage", zap.String("storage", cfg.Storage))
+	}
+
+	if cfg.Postgres.DSN == "" {
+		s.logger.Fatal("postgres dsn is not set")
+	}
+
+	conn, err := connectPostgres(context.Background(), cfg.Postgres, cfg.Postgres.DSN)
 	if err != nil {
 		s.logger.Fatal("db connect", zap.Error(err))
 	}
 
-	transactor := db.NewTransactor(conn, s.logger)
+	if err := s.checkSchema(ctx, conn, cfg.Postgres.AutoMigrate); err != nil {
+		s.logger.Fatal("check schema", zap.Error(err))
+	}
+
+	replicas := make([]*pgxpool.Pool, 0, len(cfg.Postgres.ReplicaDSNs))
+	for i, dsn := range cfg.Postgres.ReplicaDSNs {
+		replica, err := connectPostgres(context.Background(), cfg.Postgres, dsn)
+		if err != nil {
+			s.logger.Fatal("db replica connect", zap.Int("replica", i), zap.Error(err))
+		}
+		replicas = append(replicas, replica)
+	}
+
+	transactor := db.NewTransactor(conn, s.logger,
+		db.WithRetry(cfg.Postgres.TxMaxAttempts, cfg.Postgres.TxRetryBaseDelay, cfg.Postgres.TxRetryMaxDelay),
+		db.WithReplicas(cfg.Postgres.ReplicaMaxLag, replicas...),
+	)
 
 	//repositories
 	walletRepo := repositories.NewWallet(transactor)
+	roundRepo := repositories.NewRound(transactor)
+	freeRoundsRepo := repositories.NewFreeRounds(transactor)
+	jackpotRepo := repositories.NewJackpot(transactor)
+	ledgerRepo := repositories.NewLedger(transactor)
+	reservationRepo := repositories.NewReservation(transactor)
+	adjustmentRepo := repositories.NewAdjustment(transactor)
+	outboxRepo := repositories.NewOutbox(transactor)
+	webhookRepo := repositories.NewWebhook(transactor)
 
 	//services
-	walletService := services.NewWallet(transactor, walletRepo)
+	walletOptions := s.walletOptions(cfg)
+	if cfg.BalanceCache.Size > 0 {
+		balanceCache := services.NewBalanceCache(cfg.BalanceCache.Size, cfg.BalanceCache.TTL)
+		go transactor.Listen(ctx, repositories.WalletChangedChannel, balanceCache)
+		walletOptions = append(walletOptions, services.WithBalanceCache(balanceCache))
+	}
+
+	walletService := services.NewWallet(
+		transactor, walletRepo, roundRepo, freeRoundsRepo, jackpotRepo, ledgerRepo, reservationRepo, outboxRepo,
+		walletOptions...,
+	)
+	freeRoundsService := services.NewFreeRounds(transactor, walletRepo, freeRoundsRepo)
+	jackpotService := services.NewJackpot(transactor, jackpotRepo, ledgerRepo)
+	ledgerService := services.NewLedger(transactor, ledgerRepo)
+	adjustmentService := services.NewAdjustment(transactor, adjustmentRepo, walletService, cfg.Adjustments.ApprovalThreshold)
+	reconciliationService := s.newReconciliation(cfg, walletRepo)
+	webhookService := services.NewWebhooks(transactor, webhookRepo, &http.Client{Timeout: cfg.Webhooks.Timeout}, domain.WebhookRetry{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BaseDelay:   cfg.Webhooks.RetryBaseDelay,
+		MaxDelay:    cfg.Webhooks.RetryMaxDelay,
+	})
 
 	//handlers
-	handler := handlers.NewHandler(walletService)
+	s.registerSeamless(server, walletService)
+	adminHandler := handlers.NewAdminHandler(
+		walletService, freeRoundsService, jackpotService, ledgerService, adjustmentService, webhookService,
+	)
 
-	err = server.RegisterServices(
-		"getBalance", handler.GetBalance,
-		"withdrawAndDeposit", handler.WithdrawAndDeposit,
-		"rollbackTransaction", handler.RollbackTransaction,
+	adminServer := transport.NewServer(transport.WithUseValidator()).UseMiddlewares(
+		transport.LoggingMiddleware(s.logger),
+		transport.RecoverMiddleware(s.logger),
+	)
+
+	err = adminServer.RegisterServices(
+		"createWallet", adminHandler.CreateWallet,
+		"setWalletStatus", adminHandler.SetWalletStatus,
+		"setWalletRollbackPolicy", adminHandler.SetWalletRollbackPolicy,
+		"getWalletStatusHistory", adminHandler.GetWalletStatusHistory,
+		"grantBonus", adminHandler.GrantBonus,
+		"transferFunds", adminHandler.TransferFunds,
+		"createAdjustment", adminHandler.CreateAdjustment,
+		"approveAdjustment", adminHandler.ApproveAdjustment,
+		"rejectAdjustment", adminHandler.RejectAdjustment,
+		"getPendingAdjustments", adminHandler.GetPendingAdjustments,
+		"getAdjustmentHistory", adminHandler.GetAdjustmentHistory,
+		"getAdjustmentReport", adminHandler.GetAdjustmentReport,
+		"createWebhookSubscription", adminHandler.CreateWebhookSubscription,
+		"setWebhookSubscriptionActive", adminHandler.SetWebhookSubscriptionActive,
+		"getWebhookSubscriptions", adminHandler.GetWebhookSubscriptions,
+		"getWebhookDeliveries", adminHandler.GetWebhookDeliveries,
+		"getWebhookDeadLetters", adminHandler.GetWebhookDeadLetters,
+		"replayWebhookDelivery", adminHandler.ReplayWebhookDelivery,
+		"getRound", adminHandler.GetRound,
+		"getTransactionHistory", adminHandler.GetTransactionHistory,
+		"grantFreeRounds", adminHandler.GrantFreeRounds,
+		"cancelFreeRounds", adminHandler.CancelFreeRounds,
+		"getPlayerFreeRounds", adminHandler.GetPlayerFreeRounds,
+		"getFreeRoundsCampaignReport", adminHandler.GetFreeRoundsCampaignReport,
+		"createJackpotPool", adminHandler.CreateJackpotPool,
+		"setJackpotContribution", adminHandler.SetJackpotContribution,
+		"getJackpotPools", adminHandler.GetJackpotPools,
+		"getJackpotPoolLedger", adminHandler.GetJackpotPoolLedger,
+		"checkLedger", adminHandler.CheckLedger,
+		"getDebtReport", adminHandler.GetDebtReport,
+		"getLedgerAccount", adminHandler.GetLedgerAccount,
 	)
 	if err != nil {
-		s.logger.Fatal("register services", zap.Error(err))
+		s.logger.Fatal("register admin services", zap.Error(err))
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle(cfg.SeamlessURI, server.HandleFunc())
-	httpServer := http.Server{Addr: cfg.Addr, Handler: mux}
+	mux.Handle(cfg.Seamless.URI, server.HandleFunc())
+	httpServer := http.Server{Addr: cfg.Seamless.Addr, Handler: mux}
+
+	adminMux := http.NewServeMux()
+	var adminAPI http.Handler = adminServer.HandleFunc()
+	if actors := cfg.AdminAuth.Actors(); len(actors) > 0 {
+		adminAPI = transport.ActorAuth(actors, adminAPI)
+	} else {
+		s.logger.Warn("admin api doesn't authenticate actors, adjustments are refused and status changes trust the named actor")
+	}
+	adminMux.Handle(cfg.Admin.URI, adminAPI)
+	adminMux.Handle("/debug/vars", expvar.Handler())
+	adminHTTPServer := http.Server{Addr: cfg.Admin.Addr, Handler: adminMux}
 
 	s.AddClose(httpServer.Shutdown)
+	s.AddClose(adminHTTPServer.Shutdown)
 
 	s.AddClose(func(ctx context.Context) error {
 		conn.Close()
+		for _, replica := range replicas {
+			replica.Close()
+		}
 		return nil
 	})
 
+	//jobs
+	if len(replicas) > 0 {
+		if err := transactor.CheckReplicas(ctx); err != nil {
+			s.logger.Error("check replicas", zap.Error(err))
+		}
+		go s.runPeriodic(ctx, "check replicas", cfg.Postgres.ReplicaCheckInterval, transactor.CheckReplicas)
+	}
+
+	s.runWalletJobs(ctx, cfg, walletService)
+
+	sinks := services.MultiSink{webhookService}
+	if cfg.Outbox.File != "" {
+		sink, err := services.NewFileSink(cfg.Outbox.File)
+		if err != nil {
+			s.logger.Fatal("open outbox file", zap.Error(err))
+		}
+		s.AddClose(func(ctx context.Context) error {
+			return sink.Close()
+		})
+		sinks = append(sinks, sink)
+	}
+
+	outboxService := services.NewOutbox(transactor, outboxRepo, sinks)
+	go s.runPeriodic(ctx, "dispatch outbox", cfg.Outbox.DispatchInterval, func(ctx context.Context) error {
+		_, err := outboxService.Dispatch(ctx)
+		return err
+	})
+
+	go s.runPeriodic(ctx, "deliver webhooks", cfg.Webhooks.DeliveryInterval, func(ctx context.Context) error {
+		_, err := webhookService.Deliver(ctx)
+		return err
+	})
+
+	if cfg.Reconcile.Interval > 0 {
+		go s.runPeriodic(ctx, "reconcile balances", cfg.Reconcile.Interval, func(ctx context.Context) error {
+			_, err := s.reconcile(ctx, reconciliationService)
+			return err
+		})
+	}
+
+	go s.listenAndServe(&adminHTTPServer)
+	s.listenAndServe(&httpServer)
+}
+
+func (s *Service) listenAndServe(httpServer *http.Server) {
 	if err := httpServer.ListenAndServe(); err != nil {
 		if (s.shutdown.Load() && !errors.Is(err, http.ErrServerClosed)) || !s.shutdown.Load() {
-			s.logger.Fatal("listen and serve", zap.Error(err))
+			s.logger.Fatal("listen and serve", zap.String("addr", httpServer.Addr), zap.Error(err))
+		}
+	}
+}
+
+// walletOptions returns the options of the wallet engine set by cfg, which config.Load validated.
+func (s *Service) walletOptions(cfg config.Config) []services.WalletOption {
+	limits := make(map[string]domain.Limits, len(cfg.Currencies))
+	rollbackPolicies := make(map[string]domain.RollbackPolicy, len(cfg.Currencies))
+	for _, c := range cfg.Currencies {
+		code := strings.ToUpper(c.Code)
+		limits[code] = domain.Limits{MaxBet: c.MaxBet, MaxWin: c.MaxWin, MaxBalance: c.MaxBalance}
+		if c.RollbackPolicy != "" {
+			rollbackPolicies[code] = domain.RollbackPolicy(c.RollbackPolicy)
+		}
+	}
+
+	options := []services.WalletOption{
+		services.WithSpendOrder(domain.SpendOrder(cfg.Bonus.SpendOrder)),
+		services.WithFreeRoundWinBalance(domain.SubBalance(cfg.Bonus.FreeRoundWinBalance)),
+		services.WithLimits(limits),
+		services.WithRollbackPolicies(domain.RollbackPolicy(cfg.RollbackPolicy), rollbackPolicies),
+		services.WithReservationTTL(cfg.Reservations.TTL),
+	}
+	// the commands of the CLI write wallets too, the servers must hear of it
+	if cfg.BalanceCache.Size > 0 {
+		options = append(options, services.WithChangeNotifications())
+	}
+
+	return options
+}
+
+// registerSeamless registers the methods of the seamless API.
+func (s *Service) registerSeamless(server *transport.Server, walletService handlers.WalletService) {
+	handler := handlers.NewHandler(walletService)
+	err := server.RegisterServices(
+		"getBalance", handler.GetBalance,
+		"withdrawAndDeposit", handler.WithdrawAndDeposit,
+		"rollbackTransaction", handler.RollbackTransaction,
+		"getTransactionHistory", handler.GetTransactionHistory,
+		"reserveFunds", handler.ReserveFunds,
+		"commitReservation", handler.CommitReservation,
+		"releaseReservation", handler.ReleaseReservation,
+	)
+	if err != nil {
+		s.logger.Fatal("register services", zap.Error(err))
+	}
+}
+
+// runWalletJobs starts the periodic jobs of the wallet engine.
+func (s *Service) runWalletJobs(ctx context.Context, cfg config.Config, walletService *services.Wallet) {
+	go s.runPeriodic(ctx, "expire bonuses", cfg.Bonus.ExpiryInterval, func(ctx context.Context) error {
+		_, err := walletService.ExpireBonuses(ctx)
+		return err
+	})
+
+	go s.runPeriodic(ctx, "expire reservations", cfg.Reservations.ExpiryInterval, func(ctx context.Context) error {
+		_, err := walletService.ExpireReservations(ctx)
+		return err
+	})
+}
+
+// runPeriodic calls job every interval until ctx is done. Errors are logged and don't stop the job.
+func (s *Service) runPeriodic(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				s.logger.Error("periodic job", zap.String("job", name), zap.Error(err))
+			}
 		}
 	}
 }
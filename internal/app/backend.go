@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"mascot/internal/config"
+	"mascot/internal/db"
+	"mascot/internal/repositories"
+	"mascot/internal/services"
+)
+
+// Backend is the wallet engine on the primary database, without the servers and the jobs of Start.
+// It backs the operational commands.
+type Backend struct {
+	Wallet     *services.Wallet
+	Adjustment *services.Adjustment
+
+	conn *pgxpool.Pool
+}
+
+// Connect connects the backend to the postgres storage, the memory storage holds nothing to operate on.
+// The backend must be closed.
+func (s *Service) Connect(ctx context.Context, cfg config.Config) (*Backend, error) {
+	if cfg.Storage != config.StoragePostgres {
+		return nil, errors.New("the command needs the postgres storage")
+	}
+
+	if cfg.Postgres.DSN == "" {
+		return nil, errors.New("postgres dsn is not set")
+	}
+
+	conn, err := connectPostgres(ctx, cfg.Postgres, cfg.Postgres.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSchema(ctx, conn, cfg.Postgres.AutoMigrate); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	transactor := db.NewTransactor(conn, s.logger,
+		db.WithRetry(cfg.Postgres.TxMaxAttempts, cfg.Postgres.TxRetryBaseDelay, cfg.Postgres.TxRetryMaxDelay),
+	)
+
+	walletService := services.NewWallet(
+		transactor,
+		repositories.NewWallet(transactor),
+		repositories.NewRound(transactor),
+		repositories.NewFreeRounds(transactor),
+		repositories.NewJackpot(transactor),
+		repositories.NewLedger(transactor),
+		repositories.NewReservation(transactor),
+		repositories.NewOutbox(transactor),
+		s.walletOptions(cfg)...,
+	)
+
+	return &Backend{
+		Wallet: walletService,
+		Adjustment: services.NewAdjustment(
+			transactor, repositories.NewAdjustment(transactor), walletService, cfg.Adjustments.ApprovalThreshold,
+		),
+		conn: conn,
+	}, nil
+}
+
+func (b *Backend) Close() {
+	b.conn.Close()
+}
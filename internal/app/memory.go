@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"mascot/internal/config"
+	"mascot/internal/domain"
+	"mascot/internal/memory"
+	"mascot/internal/services"
+	"mascot/internal/transport"
+)
+
+// demoWallets are the wallets of the memory storage, the same as the migrations add to Postgres.
+var demoWallets = []domain.Wallet{
+	{UserName: "user1", Currency: "USD", Balance: 1000},
+	{UserName: "user2", Currency: "USD", Balance: 200},
+	{UserName: "user3", Currency: "EUR", Balance: 999},
+	{UserName: "user4", Currency: "RUB", Balance: 12000},
+}
+
+// startMemory serves the seamless API from the memory storage. The admin API and the jobs delivering
+// events need Postgres and don't run.
+func (s *Service) startMemory(ctx context.Context, cfg config.Config, server *transport.Server) {
+	store := memory.NewStore()
+	walletRepo := memory.NewWallet(store)
+	for _, wallet := range demoWallets {
+		wallet := wallet
+		if err := walletRepo.InsertWallet(ctx, &wallet); err != nil {
+			s.logger.Fatal("create demo wallet", zap.String("player", wallet.UserName), zap.Error(err))
+		}
+	}
+
+	walletOptions := s.walletOptions(cfg)
+	if cfg.BalanceCache.Size > 0 {
+		balanceCache := services.NewBalanceCache(cfg.BalanceCache.Size, cfg.BalanceCache.TTL)
+		store.ListenWalletChanged(balanceCache)
+		walletOptions = append(walletOptions, services.WithBalanceCache(balanceCache))
+	}
+
+	walletService := services.NewWallet(
+		store,
+		walletRepo,
+		memory.NewRound(store),
+		memory.NewFreeRounds(store),
+		memory.NewJackpot(store),
+		memory.NewLedger(store),
+		memory.NewReservation(store),
+		memory.NewOutbox(store),
+		walletOptions...,
+	)
+
+	s.registerSeamless(server, walletService)
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Seamless.URI, server.HandleFunc())
+	httpServer := http.Server{Addr: cfg.Seamless.Addr, Handler: mux}
+	s.AddClose(httpServer.Shutdown)
+
+	s.runWalletJobs(ctx, cfg, walletService)
+
+	s.logger.Warn("serving from the memory storage, data is lost on exit")
+	s.listenAndServe(&httpServer)
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"mascot/internal/config"
+	"mascot/internal/migrate"
+	"mascot/migrations"
+)
+
+// Migrate runs a migration command, up, down, status or redo, with the embedded migrations.
+// It backs the migrate command, status is written to out.
+func (s *Service) Migrate(ctx context.Context, cfg config.Config, command string, out io.Writer) error {
+	if cfg.Postgres.DSN == "" {
+		return errors.New("postgres dsn is not set")
+	}
+
+	conn, err := connectPostgres(ctx, cfg.Postgres, cfg.Postgres.DSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := s.newMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		_, err := migrator.Up(ctx)
+		return err
+	case "down":
+		return migrator.Down(ctx)
+	case "redo":
+		return migrator.Redo(ctx)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return writeMigrationStatus(out, statuses)
+	default:
+		return fmt.Errorf("unknown migrate command %q, want up, down, status or redo", command)
+	}
+}
+
+func (s *Service) newMigrator(conn *pgxpool.Pool) (*migrate.Migrator, error) {
+	migrationList, err := migrate.Load(migrations.FS)
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+
+	return migrate.NewMigrator(conn, migrationList, s.logger), nil
+}
+
+// checkSchema applies the pending migrations if autoMigrate is set, else it fails if any are pending.
+func (s *Service) checkSchema(ctx context.Context, conn *pgxpool.Pool, autoMigrate bool) error {
+	migrator, err := s.newMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	if autoMigrate {
+		_, err := migrator.Up(ctx)
+		return err
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pending > 0 {
+		return fmt.Errorf("the schema is %d migrations behind, run the migrate up command or set postgres.auto_migrate", pending)
+	}
+
+	return nil
+}
+
+func writeMigrationStatus(out io.Writer, statuses []migrate.Status) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Applied At\tMigration")
+	for _, s := range statuses {
+		appliedAt := "Pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\n", appliedAt, s.Migration.Name)
+	}
+
+	return w.Flush()
+}
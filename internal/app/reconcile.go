@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"mascot/internal/config"
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+	"mascot/internal/services"
+)
+
+const alertTimeout = 10 * time.Second
+
+// Reconcile runs a single balance reconciliation. It backs the reconcile command.
+func (s *Service) Reconcile(ctx context.Context, cfg config.Config) (*domain.ReconciliationReport, error) {
+	if cfg.Postgres.DSN == "" {
+		return nil, errors.New("postgres dsn is not set")
+	}
+
+	conn, err := connectPostgres(ctx, cfg.Postgres, cfg.Postgres.DSN)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	walletRepo := repositories.NewWallet(db.NewTransactor(conn, s.logger))
+	return s.reconcile(ctx, s.newReconciliation(cfg, walletRepo))
+}
+
+func (s *Service) newReconciliation(cfg config.Config, walletRepo *repositories.Wallet) *services.Reconciliation {
+	var alerter services.Alerter
+	if cfg.Reconcile.AlertURL != "" {
+		alerter = services.NewWebhookAlerter(cfg.Reconcile.AlertURL, &http.Client{Timeout: alertTimeout})
+	}
+
+	return services.NewReconciliation(walletRepo, cfg.Reconcile.ReportDir, alerter)
+}
+
+func (s *Service) reconcile(ctx context.Context, reconciliation *services.Reconciliation) (*domain.ReconciliationReport, error) {
+	report, err := reconciliation.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []zap.Field{
+		zap.Int("wallets", len(report.Wallets)),
+		zap.Int("discrepancies", report.Discrepancies),
+	}
+	if report.Discrepancies > 0 {
+		s.logger.Warn("balance discrepancies found", fields...)
+	} else {
+		s.logger.Info("balances reconciled", fields...)
+	}
+
+	return report, nil
+}
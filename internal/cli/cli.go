@@ -0,0 +1,259 @@
+// Package cli implements the commands of the mascot binary: the server, and the operational commands
+// on-call engineers run instead of querying the database.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mascot/internal/app"
+	"mascot/internal/config"
+)
+
+const Usage = `usage: mascot [--config file] [--set path=value]... [--storage postgres|memory] [command] [arguments]
+
+commands:
+  serve                                  start the servers, the default command
+  wallet create <player> --currency C --operator O [--balance N]
+  wallet show <player> [--tx N]          the wallet and its last N transactions
+  wallet list [--after P] [--limit N]
+  wallet adjust <player> --amount N --reason R --comment C --token T [--actor A]
+  tx show <ref>
+  tx list [filters] [--limit N] [--cursor C]
+  tx rollback <ref>
+  export [filters]                       every transaction matching the filters
+  reconcile [--all]                      exits with 1 on discrepancies
+  migrate up|down|redo|status
+  config print                           the config with secrets redacted
+  help
+
+filters: --player P --currency C --game G --round R --status committed|rolled_back --from T --to T
+Times are RFC 3339. Commands printing data take -o table (the default) or -o json.
+`
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// shutdownTimeout bounds the shutdown of the servers after the serve command is interrupted.
+const shutdownTimeout = 10 * time.Second
+
+var (
+	// ErrUsage is returned for unknown commands and invalid arguments, the caller prints Usage.
+	ErrUsage = errors.New("invalid usage")
+	// ErrDiscrepancies is returned by the reconcile command when balances don't reconcile.
+	ErrDiscrepancies = errors.New("balance discrepancies found")
+	// ErrUnauthenticated is returned for adjustments while admin_auth has no tokens to authenticate the actor.
+	ErrUnauthenticated = errors.New("adjustments need actors authenticated by admin_auth tokens")
+)
+
+type CLI struct {
+	service *app.Service
+	cfg     config.Config
+	out     io.Writer
+}
+
+func New(service *app.Service, cfg config.Config, out io.Writer) *CLI {
+	return &CLI{service: service, cfg: cfg, out: out}
+}
+
+// Run runs the command of args, serve if args are empty. Data is printed to the output of the CLI.
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return c.serve(ctx, nil)
+	}
+
+	return dispatch(ctx, "", args, map[string]func(context.Context, []string) error{
+		"serve":     c.serve,
+		"wallet":    c.wallet,
+		"tx":        c.tx,
+		"export":    c.export,
+		"reconcile": c.reconcile,
+		"migrate":   c.migrate,
+		"config":    c.config,
+		"help":      c.help,
+	})
+}
+
+// dispatch runs the command named by the first of args with the rest of them.
+func dispatch(ctx context.Context, group string, args []string, commands map[string]func(context.Context, []string) error) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: %s needs a command", ErrUsage, group)
+	}
+
+	run, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, strings.TrimSpace(group+" "+args[0]))
+	}
+
+	return run(ctx, args[1:])
+}
+
+func (c *CLI) serve(ctx context.Context, args []string) error {
+	if _, err := newFlags("serve", false).parse(args); err != nil {
+		return err
+	}
+
+	go c.service.Start(ctx, c.cfg)
+
+	<-ctx.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return c.service.Shutdown(ctx)
+}
+
+func (c *CLI) help(_ context.Context, _ []string) error {
+	_, err := io.WriteString(c.out, Usage)
+	return err
+}
+
+func (c *CLI) migrate(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: migrate takes one of up, down, redo or status", ErrUsage)
+	}
+
+	return c.service.Migrate(ctx, c.cfg, args[0], c.out)
+}
+
+func (c *CLI) reconcile(ctx context.Context, args []string) error {
+	f := newFlags("reconcile", true)
+	all := f.Bool("all", false, "print every wallet, not only the discrepancies")
+	if _, err := f.parse(args); err != nil {
+		return err
+	}
+
+	report, err := c.service.Reconcile(ctx, c.cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := c.write(f, newReconciliationView(report, *all)); err != nil {
+		return err
+	}
+
+	if report.Discrepancies > 0 {
+		return ErrDiscrepancies
+	}
+	return nil
+}
+
+func (c *CLI) config(ctx context.Context, args []string) error {
+	return dispatch(ctx, "config", args, map[string]func(context.Context, []string) error{
+		"print": c.printConfig,
+	})
+}
+
+func (c *CLI) printConfig(_ context.Context, args []string) error {
+	f := newFlags("config print", true)
+	if _, err := f.parse(args); err != nil {
+		return err
+	}
+
+	return c.write(f, newConfigView(c.cfg.Redacted()))
+}
+
+// withBackend runs f with a backend connected for the command.
+func (c *CLI) withBackend(ctx context.Context, f func(backend *app.Backend) error) error {
+	backend, err := c.service.Connect(ctx, c.cfg)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	return f(backend)
+}
+
+// flags are the flags of a command, with the output format if the command prints data.
+type flags struct {
+	*flag.FlagSet
+	format string
+}
+
+func newFlags(name string, output bool) *flags {
+	f := &flags{FlagSet: flag.NewFlagSet(name, flag.ContinueOnError), format: formatTable}
+	f.SetOutput(io.Discard)
+	if output {
+		f.StringVar(&f.format, "o", formatTable, "output format, table or json")
+	}
+
+	return f
+}
+
+// parse parses the flags wherever they are among the arguments, unlike flag.Parse which stops at
+// the first argument which isn't a flag. It returns the other arguments, which must be as many as names.
+func (f *flags) parse(args []string, names ...string) ([]string, error) {
+	var positional []string
+	for {
+		if err := f.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUsage, f.Name(), err)
+		}
+
+		args = f.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) != len(names) {
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%w: %s takes no arguments", ErrUsage, f.Name())
+		}
+		return nil, fmt.Errorf("%w: %s takes %s", ErrUsage, f.Name(), strings.Join(names, " "))
+	}
+
+	if f.format != formatTable && f.format != formatJSON {
+		return nil, fmt.Errorf("%w: %s: unknown output format %q, want table or json", ErrUsage, f.Name(), f.format)
+	}
+
+	return positional, nil
+}
+
+// view is printed as indented JSON or as tables. JSON is the view itself.
+type view interface {
+	tables() []table
+}
+
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (c *CLI) write(f *flags, v view) error {
+	if f.format == formatJSON {
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	for i, t := range v.tables() {
+		if i > 0 {
+			fmt.Fprintln(c.out)
+		}
+		if err := t.write(c.out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t table) write(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"mascot/internal/config"
+)
+
+func TestFlags_Parse(t *testing.T) {
+	t.Parallel()
+
+	f := newFlags("wallet show", true)
+	limit := f.Int("tx", 10, "")
+	positional, err := f.parse([]string{"user1", "-o", "json", "--tx", "3"}, "<player>")
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	if len(positional) != 1 || positional[0] != "user1" || *limit != 3 || f.format != formatJSON {
+		t.Errorf("parse() = %v with tx %d and format %s, want [user1] with tx 3 and format json", positional, *limit, f.format)
+	}
+
+	invalid := [][]string{
+		{},
+		{"user1", "user2"},
+		{"user1", "--unknown"},
+		{"user1", "-o", "yaml"},
+	}
+	for _, args := range invalid {
+		f := newFlags("wallet show", true)
+		if _, err := f.parse(args, "<player>"); !errors.Is(err, ErrUsage) {
+			t.Errorf("parse(%v) error = %v, want %v", args, err, ErrUsage)
+		}
+	}
+}
+
+func TestCLI_ConfigPrint(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.Postgres.DSN = "postgres://mascot:s3cret@db/mascot"
+	var out bytes.Buffer
+	if err := New(nil, cfg, &out).Run(context.Background(), []string{"config", "print", "-o", "json"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if strings.Contains(out.String(), "s3cret") {
+		t.Errorf("config print leaks the password:\n%s", out.String())
+	}
+
+	var printed map[string]string
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("config print is not a JSON object: %v", err)
+	}
+	if printed["postgres.dsn"] != "postgres://mascot:xxxxx@db/mascot" {
+		t.Errorf("printed postgres.dsn = %q, want the redacted dsn", printed["postgres.dsn"])
+	}
+}
+
+func TestCLI_UnknownCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{{"wallets"}, {"wallet"}, {"tx", "delete"}} {
+		if err := New(nil, config.Config{}, &bytes.Buffer{}).Run(context.Background(), args); !errors.Is(err, ErrUsage) {
+			t.Errorf("Run(%v) error = %v, want %v", args, err, ErrUsage)
+		}
+	}
+}
+
+func TestCLI_AdjustNeedsAuthenticatedActor(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"wallet", "adjust", "user1", "--amount", "10", "--reason", "goodwill", "--comment", "c", "--actor", "alice"}
+	if err := New(nil, config.Config{}, &bytes.Buffer{}).Run(context.Background(), args); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Run(%v) without admin_auth tokens error = %v, want %v", args, err, ErrUnauthenticated)
+	}
+
+	cfg := config.Config{AdminAuth: config.AdminAuth{Tokens: "alice:t1,bob:t2"}}
+	args = append(args, "--token", "t2")
+	if err := New(nil, cfg, &bytes.Buffer{}).Run(context.Background(), args); !errors.Is(err, ErrUsage) {
+		t.Errorf("Run(%v) naming another actor than the owner of the token error = %v, want %v", args, err, ErrUsage)
+	}
+}
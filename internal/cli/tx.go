@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"mascot/internal/app"
+	"mascot/internal/domain"
+)
+
+const (
+	defaultTransactionListLimit = 10
+	// exportPageSize is the largest page of the transaction history.
+	exportPageSize = 1000
+)
+
+func (c *CLI) tx(ctx context.Context, args []string) error {
+	return dispatch(ctx, "tx", args, map[string]func(context.Context, []string) error{
+		"show":     c.showTransaction,
+		"list":     c.listTransactions,
+		"rollback": c.rollbackTransaction,
+	})
+}
+
+func (c *CLI) showTransaction(ctx context.Context, args []string) error {
+	f := newFlags("tx show", true)
+	positional, err := f.parse(args, "<ref>")
+	if err != nil {
+		return err
+	}
+
+	return c.withBackend(ctx, func(backend *app.Backend) error {
+		tx, err := backend.Wallet.GetTransaction(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+
+		return c.write(f, &transactionListView{Transactions: newTransactions([]*domain.Transaction{tx})})
+	})
+}
+
+func (c *CLI) listTransactions(ctx context.Context, args []string) error {
+	f := newFlags("tx list", true)
+	filter := filterFlags(f)
+	limit := f.Int("limit", defaultTransactionListLimit, "number of transactions to list")
+	cursor := f.String("cursor", "", "cursor of the page to list, printed with the previous page")
+	if _, err := f.parse(args); err != nil {
+		return err
+	}
+
+	return c.withBackend(ctx, func(backend *app.Backend) error {
+		txFilter, err := filter.transactionFilter()
+		if err != nil {
+			return err
+		}
+
+		txFilter.Limit = *limit
+		if *cursor != "" {
+			if txFilter.After, err = domain.DecodeTransactionCursor(*cursor); err != nil {
+				return err
+			}
+		}
+
+		txs, next, err := backend.Wallet.GetTransactionHistory(ctx, txFilter)
+		if err != nil {
+			return err
+		}
+
+		return c.write(f, &transactionListView{Transactions: newTransactions(txs), NextCursor: next})
+	})
+}
+
+// rollbackTransaction rolls back the transaction like the rollbackTransaction method of the seamless API.
+func (c *CLI) rollbackTransaction(ctx context.Context, args []string) error {
+	f := newFlags("tx rollback", true)
+	positional, err := f.parse(args, "<ref>")
+	if err != nil {
+		return err
+	}
+
+	return c.withBackend(ctx, func(backend *app.Backend) error {
+		tx, err := backend.Wallet.GetTransaction(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+
+		rollback := &domain.Transaction{PlayerName: tx.PlayerName, ExternalID: tx.ExternalID}
+		if err := backend.Wallet.RollbackTransaction(ctx, rollback); err != nil {
+			return err
+		}
+
+		if tx, err = backend.Wallet.GetTransaction(ctx, tx.ExternalID); err != nil {
+			return err
+		}
+
+		return c.write(f, &transactionListView{Transactions: newTransactions([]*domain.Transaction{tx})})
+	})
+}
+
+// export prints every transaction matching the filters, newest first. With -o json it prints JSON lines,
+// one transaction each, so the output can be streamed.
+func (c *CLI) export(ctx context.Context, args []string) error {
+	f := newFlags("export", true)
+	filter := filterFlags(f)
+	if _, err := f.parse(args); err != nil {
+		return err
+	}
+
+	return c.withBackend(ctx, func(backend *app.Backend) error {
+		txFilter, err := filter.transactionFilter()
+		if err != nil {
+			return err
+		}
+		txFilter.Limit = exportPageSize
+
+		encoder := json.NewEncoder(c.out)
+		var v transactionListView
+		for {
+			txs, next, err := backend.Wallet.GetTransactionHistory(ctx, txFilter)
+			if err != nil {
+				return err
+			}
+
+			for _, tx := range newTransactions(txs) {
+				if f.format == formatJSON {
+					if err := encoder.Encode(tx); err != nil {
+						return err
+					}
+					continue
+				}
+				v.Transactions = append(v.Transactions, tx)
+			}
+
+			if next == "" {
+				break
+			}
+			if txFilter.After, err = domain.DecodeTransactionCursor(next); err != nil {
+				return err
+			}
+		}
+
+		if f.format == formatJSON {
+			return nil
+		}
+		return c.write(f, &v)
+	})
+}
+
+// transactionFilterFlags are the flags filtering the transaction history.
+type transactionFilterFlags struct {
+	player, currency, game, round, status, from, to *string
+}
+
+func filterFlags(f *flags) *transactionFilterFlags {
+	return &transactionFilterFlags{
+		player:   f.String("player", "", "player name"),
+		currency: f.String("currency", "", "currency"),
+		game:     f.String("game", "", "game id"),
+		round:    f.String("round", "", "game round ref"),
+		status:   f.String("status", "", "committed or rolled_back"),
+		from:     f.String("from", "", "first time, RFC 3339"),
+		to:       f.String("to", "", "time to list up to, excluded, RFC 3339"),
+	}
+}
+
+func (t *transactionFilterFlags) transactionFilter() (domain.TransactionFilter, error) {
+	filter := domain.TransactionFilter{
+		PlayerName: *t.player,
+		Currency:   *t.currency,
+		GameID:     *t.game,
+		RoundRef:   *t.round,
+		Status:     domain.TransactionStatus(*t.status),
+	}
+
+	var err error
+	if filter.From, err = parseTime("from", *t.from); err != nil {
+		return filter, err
+	}
+	filter.To, err = parseTime("to", *t.to)
+
+	return filter, err
+}
+
+func parseTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: --%s: %v", ErrUsage, name, err)
+	}
+
+	return &t, nil
+}
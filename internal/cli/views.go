@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"mascot/internal/config"
+	"mascot/internal/domain"
+	"mascot/internal/handlers"
+)
+
+type wallet struct {
+	ID             int64      `json:"id"`
+	PlayerName     string     `json:"playerName"`
+	Currency       string     `json:"currency"`
+	Balance        int64      `json:"balance"`
+	Bonus          int64      `json:"bonus"`
+	BonusExpiresAt *time.Time `json:"bonusExpiresAt,omitempty"`
+	Held           int64      `json:"held"`
+	Debt           int64      `json:"debt"`
+	Status         string     `json:"status"`
+	RollbackPolicy string     `json:"rollbackPolicy,omitempty"`
+	Operator       string     `json:"operator,omitempty"`
+}
+
+func newWallet(w *domain.Wallet) *wallet {
+	return &wallet{
+		ID:             w.ID,
+		PlayerName:     w.UserName,
+		Currency:       w.Currency,
+		Balance:        w.Balance,
+		Bonus:          w.Bonus.Balance,
+		BonusExpiresAt: w.Bonus.ExpiresAt,
+		Held:           w.Held,
+		Debt:           w.Debt,
+		Status:         string(w.Status),
+		RollbackPolicy: string(w.RollbackPolicy),
+		Operator:       w.Operator,
+	}
+}
+
+func walletTable(wallets []*wallet) table {
+	t := table{header: []string{"Player", "Currency", "Balance", "Bonus", "Held", "Debt", "Status"}}
+	for _, w := range wallets {
+		t.rows = append(t.rows, []string{
+			w.PlayerName, w.Currency, amount(w.Balance), amount(w.Bonus), amount(w.Held), amount(w.Debt), w.Status,
+		})
+	}
+
+	return t
+}
+
+func (w *wallet) tables() []table {
+	return []table{walletTable([]*wallet{w})}
+}
+
+type walletDetailsView struct {
+	Wallet       *wallet                 `json:"wallet"`
+	Transactions []*handlers.Transaction `json:"transactions"`
+}
+
+func (v *walletDetailsView) tables() []table {
+	return []table{walletTable([]*wallet{v.Wallet}), transactionTable(v.Transactions)}
+}
+
+type walletListView struct {
+	Wallets []*wallet `json:"wallets"`
+}
+
+func newWalletListView(wallets []*domain.Wallet) *walletListView {
+	v := &walletListView{Wallets: make([]*wallet, 0, len(wallets))}
+	for _, w := range wallets {
+		v.Wallets = append(v.Wallets, newWallet(w))
+	}
+
+	return v
+}
+
+func (v *walletListView) tables() []table {
+	return []table{walletTable(v.Wallets)}
+}
+
+func newTransactions(txs []*domain.Transaction) []*handlers.Transaction {
+	res := make([]*handlers.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		res = append(res, handlers.NewTransaction(tx))
+	}
+
+	return res
+}
+
+func transactionTable(txs []*handlers.Transaction) table {
+	t := table{header: []string{
+		"Created At", "Ref", "Kind", "Player", "Currency", "Withdraw", "Deposit", "Balance After", "Game", "Round", "Status",
+	}}
+	for _, tx := range txs {
+		balanceAfter := ""
+		if tx.BalanceAfterCommit != nil {
+			balanceAfter = amount(*tx.BalanceAfterCommit)
+		}
+
+		t.rows = append(t.rows, []string{
+			tx.CreatedAt.Format(time.RFC3339),
+			tx.TransactionRef,
+			tx.Kind,
+			tx.PlayerName,
+			tx.Currency,
+			amount(tx.Withdraw),
+			amount(tx.Deposit),
+			balanceAfter,
+			tx.GameID,
+			tx.GameRoundRef,
+			tx.Status,
+		})
+	}
+
+	return t
+}
+
+type transactionListView struct {
+	Transactions []*handlers.Transaction `json:"transactions"`
+	NextCursor   string                  `json:"nextCursor,omitempty"`
+}
+
+func (v *transactionListView) tables() []table {
+	tables := []table{transactionTable(v.Transactions)}
+	if v.NextCursor != "" {
+		tables = append(tables, table{header: []string{"Next Cursor"}, rows: [][]string{{v.NextCursor}}})
+	}
+
+	return tables
+}
+
+type adjustmentView struct {
+	ID            int64  `json:"id"`
+	PlayerName    string `json:"playerName"`
+	Currency      string `json:"currency"`
+	Amount        int64  `json:"amount"`
+	Reason        string `json:"reason"`
+	Status        string `json:"status"`
+	CreatedBy     string `json:"createdBy"`
+	TransactionID string `json:"transactionId,omitempty"`
+}
+
+func newAdjustmentView(a *domain.Adjustment) *adjustmentView {
+	return &adjustmentView{
+		ID:            a.ID,
+		PlayerName:    a.PlayerName,
+		Currency:      a.Currency,
+		Amount:        a.Amount,
+		Reason:        string(a.Reason),
+		Status:        string(a.Status),
+		CreatedBy:     a.CreatedBy,
+		TransactionID: a.TransactionID,
+	}
+}
+
+func (v *adjustmentView) tables() []table {
+	return []table{{
+		header: []string{"ID", "Player", "Currency", "Amount", "Reason", "Status", "Created By", "Transaction"},
+		rows: [][]string{{
+			strconv.FormatInt(v.ID, 10), v.PlayerName, v.Currency, amount(v.Amount), v.Reason, v.Status, v.CreatedBy,
+			v.TransactionID,
+		}},
+	}}
+}
+
+type balance struct {
+	Real  int64 `json:"real"`
+	Bonus int64 `json:"bonus"`
+}
+
+func (b balance) String() string {
+	return amount(b.Real) + " / " + amount(b.Bonus)
+}
+
+type walletReconciliation struct {
+	PlayerName   string  `json:"playerName"`
+	Currency     string  `json:"currency"`
+	Transactions int64   `json:"transactions"`
+	Actual       balance `json:"actual"`
+	Expected     balance `json:"expected"`
+	Difference   balance `json:"difference"`
+}
+
+type reconciliationView struct {
+	StartedAt     time.Time               `json:"startedAt"`
+	FinishedAt    time.Time               `json:"finishedAt"`
+	Reconciled    int                     `json:"reconciled"`
+	Discrepancies int                     `json:"discrepancies"`
+	Wallets       []*walletReconciliation `json:"wallets"`
+}
+
+// newReconciliationView returns the view of the report with the mismatched wallets, or with all of them.
+func newReconciliationView(report *domain.ReconciliationReport, all bool) *reconciliationView {
+	v := &reconciliationView{
+		StartedAt:     report.StartedAt,
+		FinishedAt:    report.FinishedAt,
+		Reconciled:    len(report.Wallets),
+		Discrepancies: report.Discrepancies,
+		Wallets:       []*walletReconciliation{},
+	}
+
+	for _, w := range report.Wallets {
+		if !all && w.Matches() {
+			continue
+		}
+
+		v.Wallets = append(v.Wallets, &walletReconciliation{
+			PlayerName:   w.PlayerName,
+			Currency:     w.Currency,
+			Transactions: w.Transactions,
+			Actual:       balance{Real: w.Actual.Real, Bonus: w.Actual.Bonus},
+			Expected:     balance{Real: w.Expected.Real, Bonus: w.Expected.Bonus},
+			Difference:   balance{Real: w.Difference.Real, Bonus: w.Difference.Bonus},
+		})
+	}
+
+	return v
+}
+
+func (v *reconciliationView) tables() []table {
+	summary := table{
+		header: []string{"Started At", "Took", "Reconciled", "Discrepancies"},
+		rows: [][]string{{
+			v.StartedAt.Format(time.RFC3339),
+			v.FinishedAt.Sub(v.StartedAt).String(),
+			strconv.Itoa(v.Reconciled),
+			strconv.Itoa(v.Discrepancies),
+		}},
+	}
+
+	wallets := table{header: []string{
+		"Player", "Currency", "Transactions", "Actual Real / Bonus", "Expected Real / Bonus", "Difference Real / Bonus",
+	}}
+	for _, w := range v.Wallets {
+		wallets.rows = append(wallets.rows, []string{
+			w.PlayerName, w.Currency, strconv.FormatInt(w.Transactions, 10),
+			w.Actual.String(), w.Expected.String(), w.Difference.String(),
+		})
+	}
+
+	return []table{summary, wallets}
+}
+
+// configView lists the settings of the config by path in declaration order, as a JSON object too.
+type configView struct {
+	names  []string
+	values []string
+}
+
+func newConfigView(cfg config.Config) *configView {
+	v := &configView{}
+	paths, values := cfg.Settings()
+	for i, path := range paths {
+		v.names = append(v.names, path)
+		v.values = append(v.values, fmt.Sprintf("%+v", values[i]))
+	}
+
+	return v
+}
+
+func (v *configView) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range v.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		// strings always marshal
+		key, _ := json.Marshal(name)
+		value, _ := json.Marshal(v.values[i])
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (v *configView) tables() []table {
+	t := table{header: []string{"Setting", "Value"}}
+	for i, name := range v.names {
+		t.rows = append(t.rows, []string{name, v.values[i]})
+	}
+
+	return []table{t}
+}
+
+func amount(a int64) string {
+	return strconv.FormatInt(a, 10)
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"mascot/internal/app"
+	"mascot/internal/domain"
+	"mascot/internal/handlers"
+)
+
+// AdminTokenEnv holds the admin API token of the operator running the CLI.
+const AdminTokenEnv = "MASCOT_ADMIN_TOKEN"
+
+const (
+	defaultWalletTransactions = 10
+	defaultWalletListLimit    = 100
+)
+
+// authenticate returns the actor owning the token. Like the admin API, the CLI doesn't trust named actors
+// with adjustments, so it refuses them while admin_auth has no tokens.
+func (c *CLI) authenticate(named, token string) (string, error) {
+	actors := c.cfg.AdminAuth.Actors()
+	if len(actors) == 0 {
+		return "", ErrUnauthenticated
+	}
+
+	authenticated, ok := actors[token]
+	if !ok {
+		return "", fmt.Errorf("%w: --token or %s must be the admin API token of the actor", ErrUsage, AdminTokenEnv)
+	}
+
+	if named != "" && named != authenticated {
+		return "", fmt.Errorf("%w: --actor names another actor than the owner of the token", ErrUsage)
+	}
+
+	return authenticated, nil
+}
+
+func (c *CLI) wallet(ctx context.Context, args []string) error {
+	return dispatch(ctx, "wallet", args, map[string]func(context.Context, []string) error{
+		"create": c.createWallet,
+		"show":   c.showWallet,
+		"list":   c.listWallets,
+		"adjust": c.adjustWallet,
+	})
+}
+
+func (c *CLI) createWallet(ctx context.Context, args []string) error {
+	f := newFlags("wallet create", true)
+	currency := f.String("currency", "", "currency of the wallet")
+	operator := f.String("operator", "", "operator the player belongs to, its webhooks receive the wallet events")
+	balance := f.Int64("balance", 0, "opening balance in minor units")
+	positional, err := f.parse(args, "<player>")
+	if err != nil {
+		return err
+	}
+
+	return c.withBackend(ctx, func(backend *app.Backend) error {
+		wallet, err := backend.Wallet.CreateWallet(ctx, positional[0], *currency, *operator, *balance)
+		if err != nil {
+			return err
+		}
+
+		return c.write(f, newWallet(wallet))
+	})
+}
+
+func (c *CLI) showWallet(ctx context.Context, args []string) error {
+	f := newFlags("wallet show", true)
+	transactions := f.Int("tx", defaultWalletTransactions, "number of last transactions to show")
+	positional, err := f.parse(args, "<player>")
+	if err != nil {
+		return err
+	}
+
+	return c.withBackend(ctx, func(backend *app.Backend) error {
+		wallet, err := backend.Wallet.GetWallet(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+
+		v := &walletDetailsView{Wallet: newWallet(wallet), Transactions: []*handlers.Transaction{}}
+		if *transactions > 0 {
+			txs, _, err := backend.Wallet.GetTransactionHistory(ctx, domain.TransactionFilter{
+				PlayerName: wallet.UserName,
+				Limit:      *transactions,
+			})
+			if err != nil {
+				return err
+			}
+			v.Transactions = newTransactions(txs)
+		}
+
+		return c.write(f, v)
+	})
+}
+
+func (c *CLI) listWallets(ctx context.Context, args []string) error {
+	f := newFlags("wallet list", true)
+	after := f.String("after", "", "list the wallets of players after this one")
+	limit := f.Int("limit", defaultWalletListLimit, "number of wallets to list")
+	if _, err := f.parse(args); err != nil {
+		return err
+	}
+
+	return c.withBackend(ctx, func(backend *app.Backend) error {
+		wallets, err := backend.Wallet.ListWallets(ctx, *after, *limit)
+		if err != nil {
+			return err
+		}
+
+		return c.write(f, newWalletListView(wallets))
+	})
+}
+
+// adjustWallet creates a manual adjustment. It is applied at once up to the approval threshold, else it
+// waits for the approval of another operator on the admin API. The actor is the owner of the token,
+// like on the admin API.
+func (c *CLI) adjustWallet(ctx context.Context, args []string) error {
+	f := newFlags("wallet adjust", true)
+	amount := f.Int64("amount", 0, "amount in minor units, negative to debit the wallet")
+	reason := f.String("reason", "", "goodwill, correction, compensation or chargeback")
+	comment := f.String("comment", "", "why the wallet is adjusted")
+	actor := f.String("actor", "", "operator making the adjustment, the owner of the token if omitted")
+	token := f.String("token", os.Getenv(AdminTokenEnv), "admin API token of the operator")
+	positional, err := f.parse(args, "<player>")
+	if err != nil {
+		return err
+	}
+
+	if *actor, err = c.authenticate(*actor, *token); err != nil {
+		return err
+	}
+
+	return c.withBackend(ctx, func(backend *app.Backend) error {
+		adjustment := &domain.Adjustment{
+			PlayerName: positional[0],
+			Amount:     *amount,
+			Reason:     domain.AdjustmentReason(*reason),
+			Comment:    *comment,
+			CreatedBy:  *actor,
+		}
+		if err := backend.Adjustment.CreateAdjustment(ctx, adjustment); err != nil {
+			return err
+		}
+
+		return c.write(f, newAdjustmentView(adjustment))
+	})
+}
@@ -1,22 +1,198 @@
 package config
 
 import (
-	"fmt"
+	"strings"
+	"time"
+)
 
-	"github.com/vrischmann/envconfig"
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
 )
 
+// Config is the configuration of the service. It is read by Load from the defaults, a YAML file, the
+// environment and the command line, see Sources. The yaml tags are the paths of the settings in all of them.
 type Config struct {
-	PostgresDSN string
-	Addr        string
-	SeamlessURI string
+	// Storage is postgres or memory. The memory storage serves only the seamless API from demo wallets,
+	// everything is lost on exit.
+	Storage string `yaml:"storage"`
+
+	Seamless  Listener  `yaml:"seamless"`
+	Admin     Listener  `yaml:"admin"`
+	AdminAuth AdminAuth `yaml:"admin_auth"`
+
+	Postgres Postgres `yaml:"postgres"`
+
+	BalanceCache BalanceCache `yaml:"balance_cache"`
+	Bonus        Bonus        `yaml:"bonus"`
+	Reservations Reservations `yaml:"reservations"`
+	Adjustments  Adjustments  `yaml:"adjustments"`
+	Outbox       Outbox       `yaml:"outbox"`
+	Webhooks     Webhooks     `yaml:"webhooks"`
+	Reconcile    Reconcile    `yaml:"reconcile"`
+
+	// RollbackPolicy applies to wallets and currencies without their own policy: reject, negative_balance or debt.
+	RollbackPolicy string `yaml:"rollback_policy"`
+	// Currencies set the limits and the rollback policy of a currency.
+	Currencies []Currency `yaml:"currencies"`
 }
 
-func Init(prefix string) (Config, error) {
-	config := Config{}
-	if err := envconfig.InitWithPrefix(&config, prefix); err != nil {
-		return config, fmt.Errorf("failed to init config: %w", err)
+// Listener is an HTTP server serving one API.
+type Listener struct {
+	Addr string `yaml:"addr"`
+	URI  string `yaml:"uri"`
+}
+
+// AdminAuth authenticates the actors of the admin API. With tokens set, requests must send
+// Authorization: Bearer <token> and wallets are changed and adjustments reviewed in the name of the actor
+// owning the token. Without tokens the actor named by a request is trusted with wallet statuses, and
+// adjustments are refused.
+type AdminAuth struct {
+	// Tokens are name:token pairs separated by commas or new lines.
+	Tokens     string `yaml:"tokens"`
+	TokensFile string `yaml:"tokens_file"`
+}
+
+// Actors returns the actor names by token. Malformed pairs are skipped, Validate reports them.
+func (a AdminAuth) Actors() map[string]string {
+	actors := make(map[string]string)
+	for _, pair := range adminTokenPairs(a.Tokens) {
+		if i := strings.Index(pair, ":"); i > 0 && i < len(pair)-1 {
+			actors[pair[i+1:]] = pair[:i]
+		}
 	}
 
-	return config, nil
+	return actors
+}
+
+func adminTokenPairs(tokens string) []string {
+	var pairs []string
+	for _, pair := range strings.FieldsFunc(tokens, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if pair = strings.TrimSpace(pair); pair != "" {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	return pairs
+}
+
+type Postgres struct {
+	// DSN is required by the postgres storage.
+	DSN     string `yaml:"dsn"`
+	DSNFile string `yaml:"dsn_file"`
+	// Password replaces the password of the DSNs, so they can be kept with the rest of the config.
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+	// AutoMigrate applies pending migrations on start, else the service refuses to start with pending migrations.
+	AutoMigrate bool `yaml:"auto_migrate"`
+
+	// ReplicaDSNs serve history, reports and balance reads. ReplicaMaxLag is the lag above which reads
+	// fall back to the primary, checked every ReplicaCheckInterval.
+	ReplicaDSNs          []string      `yaml:"replica_dsns"`
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval"`
+
+	// The pool settings of every database, 0 keeps the pgx default.
+	MaxConns        int32         `yaml:"max_conns"`
+	MinConns        int32         `yaml:"min_conns"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+
+	// TxMaxAttempts bounds the runs of a transaction failing on serialization failures and deadlocks,
+	// retries wait a random delay growing from TxRetryBaseDelay up to TxRetryMaxDelay.
+	TxMaxAttempts    int           `yaml:"tx_max_attempts"`
+	TxRetryBaseDelay time.Duration `yaml:"tx_retry_base_delay"`
+	TxRetryMaxDelay  time.Duration `yaml:"tx_retry_max_delay"`
+}
+
+// BalanceCache caches the balances of Size wallets for at most TTL, a Size of 0 disables the cache.
+// Wallet writes notify the caches of every instance only while it is enabled, all instances must share it.
+type BalanceCache struct {
+	Size int           `yaml:"size"`
+	TTL  time.Duration `yaml:"ttl"`
+}
+
+type Bonus struct {
+	SpendOrder          string        `yaml:"spend_order"`
+	ExpiryInterval      time.Duration `yaml:"expiry_interval"`
+	FreeRoundWinBalance string        `yaml:"free_round_win_balance"`
+}
+
+type Reservations struct {
+	TTL            time.Duration `yaml:"ttl"`
+	ExpiryInterval time.Duration `yaml:"expiry_interval"`
+}
+
+type Adjustments struct {
+	// ApprovalThreshold is the largest manual adjustment, in minor units, applied without the approval
+	// of a second operator.
+	ApprovalThreshold int64 `yaml:"approval_threshold"`
+}
+
+type Outbox struct {
+	// File receives the wallet events of the outbox as JSON lines when set.
+	File             string        `yaml:"file"`
+	DispatchInterval time.Duration `yaml:"dispatch_interval"`
+}
+
+// Webhooks deliveries are retried with a delay doubling from RetryBaseDelay up to RetryMaxDelay,
+// after MaxAttempts they are dead-lettered.
+type Webhooks struct {
+	DeliveryInterval time.Duration `yaml:"delivery_interval"`
+	Timeout          time.Duration `yaml:"timeout"`
+	MaxAttempts      int           `yaml:"max_attempts"`
+	RetryBaseDelay   time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay    time.Duration `yaml:"retry_max_delay"`
+}
+
+type Reconcile struct {
+	// Interval is the period of the reconciliation job, 0 disables the job.
+	Interval  time.Duration `yaml:"interval"`
+	ReportDir string        `yaml:"report_dir"`
+	// AlertURL receives mismatched wallets as JSON when set.
+	AlertURL     string `yaml:"alert_url"`
+	AlertURLFile string `yaml:"alert_url_file"`
+}
+
+// Currency sets the limits of a currency, 0 meaning no cap, and its rollback policy if not empty.
+type Currency struct {
+	Code           string `yaml:"code"`
+	MaxBet         int64  `yaml:"max_bet"`
+	MaxWin         int64  `yaml:"max_win"`
+	MaxBalance     int64  `yaml:"max_balance"`
+	RollbackPolicy string `yaml:"rollback_policy"`
+}
+
+// Default returns the settings used where no source sets them.
+func Default() Config {
+	return Config{
+		Storage:  StoragePostgres,
+		Seamless: Listener{Addr: ":8080", URI: "/mascot/seamless"},
+		Admin:    Listener{Addr: ":8081", URI: "/mascot/admin"},
+		Postgres: Postgres{
+			ReplicaMaxLag:        time.Second,
+			ReplicaCheckInterval: 5 * time.Second,
+			TxMaxAttempts:        3,
+			TxRetryBaseDelay:     5 * time.Millisecond,
+			TxRetryMaxDelay:      100 * time.Millisecond,
+		},
+		BalanceCache: BalanceCache{TTL: 5 * time.Second},
+		Bonus: Bonus{
+			SpendOrder:          "real_first",
+			ExpiryInterval:      time.Minute,
+			FreeRoundWinBalance: "real",
+		},
+		Reservations: Reservations{TTL: 5 * time.Minute, ExpiryInterval: 30 * time.Second},
+		Outbox:       Outbox{DispatchInterval: time.Second},
+		Webhooks: Webhooks{
+			DeliveryInterval: time.Second,
+			Timeout:          10 * time.Second,
+			MaxAttempts:      8,
+			RetryBaseDelay:   10 * time.Second,
+			RetryMaxDelay:    time.Hour,
+		},
+		Reconcile:      Reconcile{Interval: time.Hour, ReportDir: "reports"},
+		RollbackPolicy: "reject",
+	}
 }
@@ -0,0 +1,288 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources are where Load reads the config from. Each one overrides the settings of the previous ones:
+// the defaults, the YAML file, the environment variables, then the overrides of the command line.
+type Sources struct {
+	// File is the path of the YAML file, none is read if it is empty.
+	File string
+	// EnvPrefix is the prefix of the environment variables, named after the paths of the settings:
+	// postgres.max_conns is set by PREFIX_POSTGRES_MAX_CONNS. Other variables of the prefix are errors
+	// unless they are listed in OtherEnv, like the variable of the config file.
+	EnvPrefix string
+	OtherEnv  []string
+	// Overrides are path=value pairs, like postgres.max_conns=20.
+	Overrides []string
+}
+
+// Overrides collects path=value flags.
+type Overrides []string
+
+func (o *Overrides) String() string {
+	return strings.Join(*o, " ")
+}
+
+func (o *Overrides) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+// Load reads the config from the sources, reads the secrets kept in files and validates the result.
+// Every invalid setting is reported in the returned Errors.
+func Load(sources Sources) (Config, error) {
+	cfg := Default()
+	var errs Errors
+
+	if sources.File != "" {
+		if err := cfg.loadFile(sources.File); err != nil {
+			var typeErr *yaml.TypeError
+			if !errors.As(err, &typeErr) {
+				return cfg, fmt.Errorf("read config file: %w", err)
+			}
+			for _, e := range typeErr.Errors {
+				errs = append(errs, fmt.Errorf("%s: %s", sources.File, e))
+			}
+		}
+	}
+
+	settings := cfg.settings()
+	if sources.EnvPrefix != "" {
+		errs = append(errs, loadEnv(settings, sources.EnvPrefix, sources.OtherEnv)...)
+	}
+
+	for _, override := range sources.Overrides {
+		errs = append(errs, cfg.override(settings, override)...)
+	}
+
+	errs = append(errs, cfg.readSecrets()...)
+	errs = append(errs, cfg.Validate()...)
+	if len(errs) > 0 {
+		return cfg, errs
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// envAliases are the former names of the environment variables, without the prefix, by setting path.
+// They are read when the current variable is not set.
+var envAliases = map[string]string{
+	"seamless.addr":                   "ADDR",
+	"postgres.auto_migrate":           "AUTO_MIGRATE",
+	"postgres.replica_max_lag":        "REPLICA_MAX_LAG",
+	"postgres.replica_check_interval": "REPLICA_CHECK_INTERVAL",
+	"postgres.tx_max_attempts":        "TX_MAX_ATTEMPTS",
+	"postgres.tx_retry_base_delay":    "TX_RETRY_BASE_DELAY",
+	"postgres.tx_retry_max_delay":     "TX_RETRY_MAX_DELAY",
+	"bonus.free_round_win_balance":    "FREE_ROUND_WIN_BALANCE",
+	"reservations.ttl":                "RESERVATION_TTL",
+	"reservations.expiry_interval":    "RESERVATION_EXPIRY_INTERVAL",
+	"adjustments.approval_threshold":  "ADJUSTMENT_APPROVAL_THRESHOLD",
+	"webhooks.delivery_interval":      "WEBHOOK_DELIVERY_INTERVAL",
+	"webhooks.timeout":                "WEBHOOK_TIMEOUT",
+	"webhooks.max_attempts":           "WEBHOOK_MAX_ATTEMPTS",
+	"webhooks.retry_base_delay":       "WEBHOOK_RETRY_BASE_DELAY",
+	"webhooks.retry_max_delay":        "WEBHOOK_RETRY_MAX_DELAY",
+}
+
+// removedEnv are former environment variables, without the prefix, whose values the settings replacing
+// them can't read.
+var removedEnv = map[string]string{
+	"LIMITS":            "currencies",
+	"ROLLBACK_POLICIES": "currencies",
+}
+
+// loadEnv sets the settings from the environment variables of the prefix, or from their former names.
+// It reports the variables of the prefix which are not settings nor listed in other.
+func loadEnv(settings []setting, prefix string, other []string) Errors {
+	var errs Errors
+	known := make(map[string]bool, len(settings)+len(other))
+	for _, key := range other {
+		known[key] = true
+	}
+
+	for _, s := range settings {
+		key := envKey(prefix, s.path)
+		known[key] = true
+		value := os.Getenv(key)
+		if alias, ok := envAliases[s.path]; ok {
+			aliasKey := envKey(prefix, alias)
+			known[aliasKey] = true
+			if aliasValue := os.Getenv(aliasKey); aliasValue != "" {
+				if value != "" {
+					errs = append(errs, fmt.Errorf("%s: set along with %s", aliasKey, key))
+					continue
+				}
+				key, value = aliasKey, aliasValue
+			}
+		}
+
+		if value != "" {
+			if err := s.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+		}
+	}
+
+	var unknown []string
+	for _, env := range os.Environ() {
+		key := strings.SplitN(env, "=", 2)[0]
+		if strings.HasPrefix(key, envKey(prefix, "")) && !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		if path, ok := removedEnv[strings.TrimPrefix(key, envKey(prefix, ""))]; ok {
+			errs = append(errs, fmt.Errorf("%s: no longer read, set %s instead", key, envKey(prefix, path)))
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: unknown setting", key))
+	}
+
+	return errs
+}
+
+func (c *Config) override(settings []setting, override string) Errors {
+	i := strings.Index(override, "=")
+	if i < 0 {
+		return Errors{fmt.Errorf("override %q: want path=value", override)}
+	}
+
+	path, value := override[:i], override[i+1:]
+	for _, s := range settings {
+		if s.path == path {
+			if err := s.set(value); err != nil {
+				return Errors{fmt.Errorf("override %s: %w", path, err)}
+			}
+			return nil
+		}
+	}
+
+	return Errors{fmt.Errorf("override %s: unknown setting", path)}
+}
+
+// readSecrets reads the settings kept in files, a setting can't be set both ways.
+func (c *Config) readSecrets() Errors {
+	var errs Errors
+	for _, secret := range []struct {
+		path  string
+		value *string
+		file  string
+	}{
+		{"admin_auth.tokens", &c.AdminAuth.Tokens, c.AdminAuth.TokensFile},
+		{"postgres.dsn", &c.Postgres.DSN, c.Postgres.DSNFile},
+		{"postgres.password", &c.Postgres.Password, c.Postgres.PasswordFile},
+		{"reconcile.alert_url", &c.Reconcile.AlertURL, c.Reconcile.AlertURLFile},
+	} {
+		if secret.file == "" {
+			continue
+		}
+
+		if *secret.value != "" {
+			errs = append(errs, fmt.Errorf("%s: set along with %s_file", secret.path, secret.path))
+			continue
+		}
+
+		b, err := os.ReadFile(secret.file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s_file: %w", secret.path, err))
+			continue
+		}
+		*secret.value = strings.TrimRight(string(b), "\r\n")
+	}
+
+	return errs
+}
+
+var stringsType = reflect.TypeOf([]string(nil))
+
+// setting is a field of the config, the sections are not settings themselves.
+type setting struct {
+	// path is the yaml tags of the sections and of the field joined by dots.
+	path  string
+	value reflect.Value
+}
+
+func (c *Config) settings() []setting {
+	return appendSettings(nil, "", reflect.ValueOf(c).Elem())
+}
+
+// Settings returns the paths of the settings with their values, in declaration order.
+func (c Config) Settings() (paths []string, values []interface{}) {
+	for _, s := range c.settings() {
+		paths = append(paths, s.path)
+		values = append(values, s.value.Interface())
+	}
+
+	return paths, values
+}
+
+func appendSettings(settings []setting, prefix string, section reflect.Value) []setting {
+	for i := 0; i < section.NumField(); i++ {
+		path := prefix + strings.Split(section.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		field := section.Field(i)
+		if field.Kind() == reflect.Struct {
+			settings = appendSettings(settings, path+".", field)
+			continue
+		}
+		settings = append(settings, setting{path: path, value: field})
+	}
+
+	return settings
+}
+
+// set parses the value of an environment variable or override. Strings are taken as they are, lists
+// of strings are comma separated and the rest is parsed as YAML, like [{code: USD, max_bet: 1000}].
+func (s setting) set(value string) error {
+	switch {
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(value)
+	case s.value.Type() == stringsType:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(list))
+	default:
+		parsed := reflect.New(s.value.Type())
+		if err := yaml.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+			return fmt.Errorf("invalid value %q: %w", value, err)
+		}
+		s.value.Set(parsed.Elem())
+	}
+
+	return nil
+}
+
+func envKey(prefix, path string) string {
+	return strings.ToUpper(prefix + "_" + strings.ReplaceAll(path, ".", "_"))
+}
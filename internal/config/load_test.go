@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	passwordFile := writeFile(t, "password", "s3cret\n")
+	file := writeFile(t, "mascot.yaml", `
+postgres:
+  dsn: postgres://mascot@db/mascot
+  password_file: `+passwordFile+`
+  max_conns: 10
+  min_conns: 2
+webhooks:
+  timeout: 3s
+currencies:
+  - code: USD
+    max_bet: 500
+`)
+	t.Setenv("MASCOTTEST_POSTGRES_MAX_CONNS", "20")
+	t.Setenv("MASCOTTEST_POSTGRES_REPLICA_DSNS", "postgres://replica1/mascot, postgres://replica2/mascot")
+	t.Setenv("MASCOTTEST_WEBHOOKS_TIMEOUT", "4s")
+
+	cfg, err := Load(Sources{
+		File:      file,
+		EnvPrefix: "mascottest",
+		Overrides: []string{"webhooks.timeout=5s", "currencies=[{code: EUR, rollback_policy: debt}]"},
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Postgres.MaxConns != 20 || cfg.Postgres.MinConns != 2 {
+		t.Errorf("pool = %d..%d conns, want 2..20 from the file and the environment", cfg.Postgres.MinConns, cfg.Postgres.MaxConns)
+	}
+	if len(cfg.Postgres.ReplicaDSNs) != 2 || cfg.Postgres.ReplicaDSNs[1] != "postgres://replica2/mascot" {
+		t.Errorf("replica dsns = %q, want the two of the environment", cfg.Postgres.ReplicaDSNs)
+	}
+	if cfg.Webhooks.Timeout != 5*time.Second {
+		t.Errorf("webhooks timeout = %s, want 5s of the override", cfg.Webhooks.Timeout)
+	}
+	if len(cfg.Currencies) != 1 || cfg.Currencies[0].Code != "EUR" || cfg.Currencies[0].MaxBet != 0 {
+		t.Errorf("currencies = %+v, want EUR of the override", cfg.Currencies)
+	}
+	if cfg.Postgres.Password != "s3cret" {
+		t.Errorf("password = %q, want the content of the password file", cfg.Postgres.Password)
+	}
+	if cfg.Webhooks.MaxAttempts != Default().Webhooks.MaxAttempts {
+		t.Errorf("webhooks max attempts = %d, want the default %d", cfg.Webhooks.MaxAttempts, Default().Webhooks.MaxAttempts)
+	}
+}
+
+func TestLoad_Env(t *testing.T) {
+	t.Setenv("MASCOTENV_RESERVATION_TTL", "2m")
+	t.Setenv("MASCOTENV_ADDR", ":9090")
+	t.Setenv("MASCOTENV_CONFIG", "mascot.yaml")
+
+	sources := Sources{EnvPrefix: "mascotenv", OtherEnv: []string{"MASCOTENV_CONFIG"}, Overrides: []string{"storage=memory"}}
+	cfg, err := Load(sources)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Reservations.TTL != 2*time.Minute || cfg.Seamless.Addr != ":9090" {
+		t.Errorf("reservations ttl = %s, seamless addr = %q, want the former variables", cfg.Reservations.TTL, cfg.Seamless.Addr)
+	}
+
+	t.Setenv("MASCOTENV_RESERVATIONS_TTL", "3m")
+	t.Setenv("MASCOTENV_LIMITS", "{USD,100,0,0}")
+	t.Setenv("MASCOTENV_WEBHOOK_TIMOUT", "1s")
+	_, err = Load(sources)
+	for _, want := range []string{
+		"MASCOTENV_RESERVATION_TTL: set along with MASCOTENV_RESERVATIONS_TTL",
+		"MASCOTENV_LIMITS: no longer read, set MASCOTENV_CURRENCIES instead",
+		"MASCOTENV_WEBHOOK_TIMOUT: unknown setting",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error doesn't report %q:\n%v", want, err)
+		}
+	}
+	if err != nil && strings.Contains(err.Error(), "MASCOTENV_CONFIG") {
+		t.Errorf("Load() error reports a variable of OtherEnv:\n%v", err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	file := writeFile(t, "mascot.yaml", `
+storage: sqlite
+postgres:
+  max_conns: two
+  tx_max_attempts: 0
+webhooks:
+  retry_base_delay: 1m
+  retry_max_delay: 1s
+unknown: true
+currencies:
+  - code: USD
+  - code: usd
+    rollback_policy: forgive
+`)
+
+	_, err := Load(Sources{File: file, Overrides: []string{"bonus.spend_order=random", "nothing=1", "admin_auth.tokens=alice"}})
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Load() error = %v, want Errors", err)
+	}
+
+	for _, want := range []string{
+		"field unknown not found",
+		"cannot unmarshal !!str `two`",
+		"override nothing: unknown setting",
+		"storage: unknown storage",
+		"postgres.tx_max_attempts",
+		"webhooks.retry_max_delay",
+		"bonus.spend_order",
+		"admin_auth.tokens: pair 1 must be name:token",
+		"currencies[1].code: USD is set more than once",
+		"currencies[1].rollback_policy",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error doesn't report %q:\n%v", want, err)
+		}
+	}
+}
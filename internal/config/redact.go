@@ -0,0 +1,71 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "xxxxx"
+
+// secretParams are the substrings of URL query parameters and DSN keys whose values are secrets.
+var secretParams = []string{"pass", "secret", "token", "key", "sig"}
+
+// dsnSecret matches a secret of a key=value postgres DSN, quoted or not.
+var dsnSecret = regexp.MustCompile(`(?i)\b(password|sslkey|sslpassword)\s*=\s*('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Redacted returns the config with the passwords and tokens of its DSNs and URLs masked, safe to print.
+func (c Config) Redacted() Config {
+	if c.AdminAuth.Tokens != "" {
+		c.AdminAuth.Tokens = redacted
+	}
+	c.Postgres.DSN = redact(c.Postgres.DSN)
+	if c.Postgres.Password != "" {
+		c.Postgres.Password = redacted
+	}
+	replicas := make([]string, 0, len(c.Postgres.ReplicaDSNs))
+	for _, dsn := range c.Postgres.ReplicaDSNs {
+		replicas = append(replicas, redact(dsn))
+	}
+	c.Postgres.ReplicaDSNs = replicas
+	c.Reconcile.AlertURL = redact(c.Reconcile.AlertURL)
+
+	return c
+}
+
+// redact masks the password and the secret query parameters of a URL, or the password of a key=value DSN.
+func redact(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		return dsnSecret.ReplaceAllString(dsn, "$1="+redacted)
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+
+	query := u.Query()
+	masked := false
+	for name := range query {
+		if isSecret(name) {
+			query.Set(name, redacted)
+			masked = true
+		}
+	}
+	if masked {
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretParams {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,25 @@
+package config
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"", ""},
+		{"postgres://mascot:s3cret@db:5432/mascot?sslmode=disable", "postgres://mascot:xxxxx@db:5432/mascot?sslmode=disable"},
+		{"postgres://mascot@db/mascot?password=s3cret", "postgres://mascot@db/mascot?password=xxxxx"},
+		{"host=db user=mascot password=s3cret dbname=mascot", "host=db user=mascot password=xxxxx dbname=mascot"},
+		{"host=db password = 'it\\'s secret' dbname=mascot", "host=db password=xxxxx dbname=mascot"},
+		{"https://hooks.example.com/alert?channel=ops&token=abc", "https://hooks.example.com/alert?channel=ops&token=xxxxx"},
+	}
+
+	for _, tt := range tests {
+		if got := redact(tt.dsn); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
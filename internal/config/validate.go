@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+// Errors are the problems found in the config, all of them are reported at once.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return "invalid config:\n  " + strings.Join(msgs, "\n  ")
+}
+
+// validator collects the problems of the settings, named by their paths.
+type validator struct {
+	errs Errors
+}
+
+func (v *validator) check(ok bool, path, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *validator) positive(path string, d time.Duration) {
+	v.check(d > 0, path, "must be positive, got %s", d)
+}
+
+func (v *validator) notNegative(path string, n int64) {
+	v.check(n >= 0, path, "must not be negative, got %d", n)
+}
+
+func (v *validator) listener(path string, l Listener) {
+	v.check(l.Addr != "", path+".addr", "must be set")
+	v.check(strings.HasPrefix(l.URI, "/"), path+".uri", "must start with /, got %q", l.URI)
+}
+
+func (v *validator) rollbackPolicy(path, policy string) {
+	v.check(domain.RollbackPolicy(policy).Valid(), path, "unknown rollback policy %q, want reject, negative_balance or debt", policy)
+}
+
+// Validate returns every problem of the config, none if it is valid.
+func (c Config) Validate() Errors {
+	var v validator
+
+	v.check(c.Storage == StoragePostgres || c.Storage == StorageMemory, "storage",
+		"unknown storage %q, want postgres or memory", c.Storage)
+	v.listener("seamless", c.Seamless)
+	v.listener("admin", c.Admin)
+	v.check(c.Seamless.Addr != c.Admin.Addr, "admin.addr", "must differ from seamless.addr")
+	tokens := make(map[string]bool)
+	for i, pair := range adminTokenPairs(c.AdminAuth.Tokens) {
+		// the pair holds a secret, only its position is reported
+		j := strings.Index(pair, ":")
+		v.check(j > 0 && j < len(pair)-1, "admin_auth.tokens", "pair %d must be name:token", i+1)
+		v.check(j < 0 || !tokens[pair[j+1:]], "admin_auth.tokens", "token of pair %d is used more than once", i+1)
+		if j >= 0 {
+			tokens[pair[j+1:]] = true
+		}
+	}
+
+	p := c.Postgres
+	v.check(c.Storage != StoragePostgres || p.DSN != "", "postgres.dsn", "must be set for the postgres storage")
+	v.positive("postgres.replica_max_lag", p.ReplicaMaxLag)
+	v.positive("postgres.replica_check_interval", p.ReplicaCheckInterval)
+	v.notNegative("postgres.max_conns", int64(p.MaxConns))
+	v.notNegative("postgres.min_conns", int64(p.MinConns))
+	v.check(p.MaxConns == 0 || p.MinConns <= p.MaxConns, "postgres.min_conns",
+		"must not exceed postgres.max_conns %d, got %d", p.MaxConns, p.MinConns)
+	v.notNegative("postgres.max_conn_lifetime", int64(p.MaxConnLifetime))
+	v.notNegative("postgres.max_conn_idle_time", int64(p.MaxConnIdleTime))
+	v.notNegative("postgres.connect_timeout", int64(p.ConnectTimeout))
+	v.check(p.TxMaxAttempts >= 1, "postgres.tx_max_attempts", "must be at least 1, got %d", p.TxMaxAttempts)
+	v.positive("postgres.tx_retry_base_delay", p.TxRetryBaseDelay)
+	v.check(p.TxRetryBaseDelay <= p.TxRetryMaxDelay, "postgres.tx_retry_max_delay",
+		"must not be below postgres.tx_retry_base_delay %s, got %s", p.TxRetryBaseDelay, p.TxRetryMaxDelay)
+
+	v.notNegative("balance_cache.size", int64(c.BalanceCache.Size))
+	if c.BalanceCache.Size > 0 {
+		v.positive("balance_cache.ttl", c.BalanceCache.TTL)
+	}
+
+	v.check(domain.SpendOrder(c.Bonus.SpendOrder).Valid(), "bonus.spend_order",
+		"unknown spend order %q, want real_first or bonus_first", c.Bonus.SpendOrder)
+	v.positive("bonus.expiry_interval", c.Bonus.ExpiryInterval)
+	v.check(domain.SubBalance(c.Bonus.FreeRoundWinBalance).Valid(), "bonus.free_round_win_balance",
+		"unknown balance %q, want real or bonus", c.Bonus.FreeRoundWinBalance)
+
+	v.positive("reservations.ttl", c.Reservations.TTL)
+	v.positive("reservations.expiry_interval", c.Reservations.ExpiryInterval)
+	v.notNegative("adjustments.approval_threshold", c.Adjustments.ApprovalThreshold)
+	v.positive("outbox.dispatch_interval", c.Outbox.DispatchInterval)
+
+	w := c.Webhooks
+	v.positive("webhooks.delivery_interval", w.DeliveryInterval)
+	v.positive("webhooks.timeout", w.Timeout)
+	v.check(w.MaxAttempts >= 1, "webhooks.max_attempts", "must be at least 1, got %d", w.MaxAttempts)
+	v.positive("webhooks.retry_base_delay", w.RetryBaseDelay)
+	v.check(w.RetryBaseDelay <= w.RetryMaxDelay, "webhooks.retry_max_delay",
+		"must not be below webhooks.retry_base_delay %s, got %s", w.RetryBaseDelay, w.RetryMaxDelay)
+
+	v.notNegative("reconcile.interval", int64(c.Reconcile.Interval))
+	v.check(c.Reconcile.ReportDir != "", "reconcile.report_dir", "must be set")
+	if c.Reconcile.AlertURL != "" {
+		u, err := url.Parse(c.Reconcile.AlertURL)
+		v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "reconcile.alert_url",
+			"must be an http or https URL")
+	}
+
+	v.rollbackPolicy("rollback_policy", c.RollbackPolicy)
+	codes := make(map[string]bool, len(c.Currencies))
+	for i, currency := range c.Currencies {
+		path := fmt.Sprintf("currencies[%d]", i)
+		code := strings.ToUpper(currency.Code)
+		v.check(len(code) == 3, path+".code", "must be a 3 letter currency code, got %q", currency.Code)
+		v.check(!codes[code], path+".code", "%s is set more than once", code)
+		codes[code] = true
+
+		v.notNegative(path+".max_bet", currency.MaxBet)
+		v.notNegative(path+".max_win", currency.MaxWin)
+		v.notNegative(path+".max_balance", currency.MaxBalance)
+		if currency.RollbackPolicy != "" {
+			v.rollbackPolicy(path+".rollback_policy", currency.RollbackPolicy)
+		}
+	}
+
+	return v.errs
+}
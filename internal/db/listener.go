@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+const listenRetryDelay = time.Second
+
+// NotificationHandler receives the notifications of a channel. Notifications sent while the handler
+// is not listening are lost.
+type NotificationHandler interface {
+	// Listening is called with true once notifications are received and with false when they stop.
+	Listening(on bool)
+	Notify(payload string)
+}
+
+// Listen delivers the notifications of channel to handler until ctx is done. It listens on a connection
+// of its own taken from the primary pool and reconnects after errors.
+func (t *Transactor) Listen(ctx context.Context, channel string, handler NotificationHandler) {
+	for {
+		err := t.listen(ctx, channel, handler)
+		handler.Listening(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		t.logger.Warn("listen", zap.String("channel", channel), zap.Error(err))
+		timer := time.NewTimer(listenRetryDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *Transactor) listen(ctx context.Context, channel string, handler NotificationHandler) error {
+	pooled, err := t.conn.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// a listening connection must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	handler.Listening(true)
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handler.Notify(notification.Payload)
+	}
+}
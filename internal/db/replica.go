@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+const defaultMaxReplicaLag = time.Second
+
+// replicaLagQuery measures how far the replica is behind. An idle primary makes the replica look
+// behind too, reads then fall back to the primary, which is safe.
+const replicaLagQuery = "SELECT CASE WHEN pg_is_in_recovery() " +
+	"THEN coalesce(extract(epoch FROM now() - pg_last_xact_replay_timestamp()), 0)::float8 ELSE 0 END"
+
+type replicaKey struct{}
+
+// PreferReplica marks reads made with the returned context as tolerating replication lag,
+// they are served by a replica when one is close enough to the primary.
+func PreferReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaKey{}, true)
+}
+
+func prefersReplica(ctx context.Context) bool {
+	prefer, _ := ctx.Value(replicaKey{}).(bool)
+	return prefer
+}
+
+type replica struct {
+	pool *pgxpool.Pool
+	// usable is false until the first lag check and whenever the replica lags more than allowed.
+	usable atomic.Bool
+}
+
+// WithReplicas adds read replicas for reads made with PreferReplica. A replica is used once CheckReplicas
+// found its lag to be at most maxLag.
+func WithReplicas(maxLag time.Duration, pools ...*pgxpool.Pool) TransactorOption {
+	return func(t *Transactor) {
+		t.maxReplicaLag = maxLag
+		for _, pool := range pools {
+			t.replicas = append(t.replicas, &replica{pool: pool})
+		}
+	}
+}
+
+// CheckReplicas measures the lag of every replica and takes lagging or unreachable ones out of rotation.
+func (t *Transactor) CheckReplicas(ctx context.Context) error {
+	for i, r := range t.replicas {
+		var seconds float64
+		if err := r.pool.QueryRow(ctx, replicaLagQuery).Scan(&seconds); err != nil {
+			if r.usable.Swap(false) {
+				t.logger.Warn("replica is unreachable", zap.Int("replica", i), zap.Error(err))
+			}
+			continue
+		}
+
+		lag := time.Duration(seconds * float64(time.Second))
+		usable := lag <= t.maxReplicaLag
+		if r.usable.Swap(usable) != usable {
+			t.logger.Info("replica usage changed", zap.Int("replica", i), zap.Bool("usable", usable), zap.Duration("lag", lag))
+		}
+	}
+
+	return nil
+}
+
+// replica returns the next usable replica in turn, nil if there is none.
+func (t *Transactor) replica() *pgxpool.Pool {
+	n := len(t.replicas)
+	if n == 0 {
+		return nil
+	}
+
+	start := int(t.nextReplica.Inc() % uint64(n))
+	for i := 0; i < n; i++ {
+		if r := t.replicas[(start+i)%n]; r.usable.Load() {
+			return r.pool
+		}
+	}
+
+	return nil
+}
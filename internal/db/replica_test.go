@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+func lazyPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	cfg, err := pgxpool.ParseConfig("postgresql://localhost/mascot")
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	cfg.LazyConnect = true
+
+	pool, err := pgxpool.ConnectConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestTransactor_Conn_Replicas(t *testing.T) {
+	t.Parallel()
+	primary, lagging, current := lazyPool(t), lazyPool(t), lazyPool(t)
+	tr := NewTransactor(primary, zap.NewNop(), WithReplicas(time.Second, lagging, current))
+	tr.replicas[1].usable.Store(true)
+
+	ctx := context.Background()
+	if got := tr.Conn(ctx); got != primary {
+		t.Errorf("Conn() without PreferReplica is not the primary")
+	}
+
+	for i := 0; i < 4; i++ {
+		if got := tr.Conn(PreferReplica(ctx)); got != current {
+			t.Errorf("Conn() with PreferReplica is not the usable replica")
+		}
+	}
+
+	tr.replicas[1].usable.Store(false)
+	if got := tr.Conn(PreferReplica(ctx)); got != primary {
+		t.Errorf("Conn() without usable replicas is not the primary")
+	}
+}
@@ -2,14 +2,35 @@ package db
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
+	"math/rand"
+	"sync"
+	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype/pgxtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 )
 
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 5 * time.Millisecond
+	defaultMaxDelay    = 100 * time.Millisecond
+)
+
+// txMetrics counts retried transactions by SQLSTATE, served as expvar "db_transactions".
+var txMetrics = expvar.NewMap("db_transactions")
+
 type txKey struct{}
 
 // injectTx injects transaction to context
@@ -25,40 +46,227 @@ func extractTx(ctx context.Context) pgx.Tx {
 	return nil
 }
 
+type afterCommitKey struct{}
+
+// afterCommit collects the functions to run once the transaction commits.
+type afterCommit struct {
+	funcs []func()
+}
+
+// AfterCommit runs f once the transaction carried by ctx has committed, it is not run if the transaction
+// rolls back. Functions registered in a savepoint run with the outer transaction even if the savepoint
+// was rolled back. Outside of a transaction f runs at once.
+func AfterCommit(ctx context.Context, f func()) {
+	hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommit)
+	if !ok {
+		f()
+		return
+	}
+	hooks.funcs = append(hooks.funcs, f)
+}
+
+type TransactorOption func(t *Transactor)
+
+// TxOption sets the characteristics of a transaction started by WithTx.
+type TxOption func(options *pgx.TxOptions)
+
 type Transactor struct {
 	conn   *pgxpool.Pool
 	logger *zap.Logger
+
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	replicas      []*replica
+	maxReplicaLag time.Duration
+	nextReplica   atomic.Uint64
+}
+
+func NewTransactor(conn *pgxpool.Pool, logger *zap.Logger, options ...TransactorOption) *Transactor {
+	t := &Transactor{
+		conn:          conn,
+		logger:        logger,
+		maxAttempts:   defaultMaxAttempts,
+		baseDelay:     defaultBaseDelay,
+		maxDelay:      defaultMaxDelay,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		maxReplicaLag: defaultMaxReplicaLag,
+	}
+	for _, option := range options {
+		option(t)
+	}
+	return t
+}
+
+// WithRetry sets how many times a transaction is run on serialization failures and deadlocks,
+// and the bounds of the exponential backoff between the attempts. One attempt disables retries.
+func WithRetry(maxAttempts int, baseDelay, maxDelay time.Duration) TransactorOption {
+	return func(t *Transactor) {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+		t.maxAttempts, t.baseDelay, t.maxDelay = maxAttempts, baseDelay, maxDelay
+	}
 }
 
-func NewTransactor(conn *pgxpool.Pool, logger *zap.Logger) *Transactor {
-	return &Transactor{conn: conn, logger: logger}
+func WithIsoLevel(level pgx.TxIsoLevel) TxOption {
+	return func(options *pgx.TxOptions) {
+		options.IsoLevel = level
+	}
 }
 
-func (t *Transactor) WithTx(ctx context.Context, txFunc func(ctx context.Context) error) error {
-	tx, err := t.conn.Begin(ctx)
+func WithAccessMode(mode pgx.TxAccessMode) TxOption {
+	return func(options *pgx.TxOptions) {
+		options.AccessMode = mode
+	}
+}
+
+// WithDeferrable makes a serializable read only transaction wait for a snapshot it can't fail on.
+func WithDeferrable() TxOption {
+	return func(options *pgx.TxOptions) {
+		options.DeferrableMode = pgx.Deferrable
+	}
+}
+
+// WithTx runs txFunc in a transaction. The whole txFunc is run again after a serialization failure
+// or a deadlock, so it must not have side effects outside the db.
+//
+// Called with a context which already carries a transaction, WithTx runs txFunc in a savepoint of it
+// instead: an error of txFunc rolls back to the savepoint and the outer transaction can go on. Options
+// and retries only apply to the outermost transaction.
+func (t *Transactor) WithTx(ctx context.Context, txFunc func(ctx context.Context) error, options ...TxOption) error {
+	if outer := extractTx(ctx); outer != nil {
+		return t.withSavepoint(ctx, outer, txFunc)
+	}
+
+	txOptions := pgx.TxOptions{}
+	for _, option := range options {
+		option(&txOptions)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := t.withTx(ctx, txOptions, txFunc)
+
+		code, ok := retryableCode(err)
+		if !ok {
+			return err
+		}
+
+		if attempt >= t.maxAttempts {
+			txMetrics.Add("exhausted", 1)
+			return err
+		}
+
+		txMetrics.Add("retries", 1)
+		txMetrics.Add("retries."+code, 1)
+		t.logger.Warn("retry transaction", zap.Int("attempt", attempt), zap.Error(err))
+
+		timer := time.NewTimer(t.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *Transactor) withTx(ctx context.Context, options pgx.TxOptions, txFunc func(ctx context.Context) error) error {
+	tx, err := t.conn.BeginTx(ctx, options)
 	if err != nil {
 		return fmt.Errorf("create transaction: %w", err)
 	}
 
 	defer func(tx pgx.Tx, ctx context.Context) {
-		if err := tx.Rollback(ctx); err != nil {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
 			t.logger.Error("transaction rollback", zap.Error(err))
 		}
 	}(tx, ctx)
 
-	err = txFunc(injectTx(ctx, tx))
+	hooks := &afterCommit{}
+	err = txFunc(injectTx(context.WithValue(ctx, afterCommitKey{}, hooks), tx))
 	if err != nil {
 		return err
 	}
 
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	for _, f := range hooks.funcs {
+		f()
+	}
+	return nil
 }
 
+func (t *Transactor) withSavepoint(ctx context.Context, outer pgx.Tx, txFunc func(ctx context.Context) error) error {
+	savepoint, err := outer.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create savepoint: %w", err)
+	}
+
+	defer func(savepoint pgx.Tx, ctx context.Context) {
+		if err := savepoint.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			t.logger.Error("savepoint rollback", zap.Error(err))
+		}
+	}(savepoint, ctx)
+
+	err = txFunc(injectTx(ctx, savepoint))
+	if err != nil {
+		return err
+	}
+
+	return savepoint.Commit(ctx)
+}
+
+// backoff returns a random delay up to the exponential backoff of the attempt.
+func (t *Transactor) backoff(attempt int) time.Duration {
+	limit := t.maxDelay
+	if shift := uint(attempt - 1); shift < 32 && t.baseDelay<<shift < limit {
+		limit = t.baseDelay << shift
+	}
+
+	if limit <= 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Duration(t.rand.Int63n(int64(limit) + 1))
+}
+
+// retryableCode returns the SQLSTATE of err if the transaction which failed with it may succeed when run again.
+func retryableCode(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return "", false
+	}
+
+	switch pgErr.Code {
+	case serializationFailure, deadlockDetected:
+		return pgErr.Code, true
+	default:
+		return "", false
+	}
+}
+
+// Conn returns the transaction carried by ctx. Outside of a transaction reads marked by PreferReplica
+// go to a usable replica, everything else goes to the primary.
 func (t *Transactor) Conn(ctx context.Context) pgxtype.Querier {
 	tx := extractTx(ctx)
 	if tx != nil {
 		return tx
 	}
 
+	if prefersReplica(ctx) {
+		if replica := t.replica(); replica != nil {
+			return replica
+		}
+	}
+
 	return t.conn
 }
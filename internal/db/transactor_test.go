@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+func TestRetryableCode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+		wantOk   bool
+	}{
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, wantCode: "40001", wantOk: true},
+		{name: "wrapped deadlock", err: fmt.Errorf("insert: %w", &pgconn.PgError{Code: "40P01"}), wantCode: "40P01", wantOk: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{name: "other error", err: errors.New("not enough money")},
+		{name: "no error"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			code, ok := retryableCode(tt.err)
+			if code != tt.wantCode || ok != tt.wantOk {
+				t.Errorf("retryableCode() = %q, %v, want %q, %v", code, ok, tt.wantCode, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestTransactor_backoff(t *testing.T) {
+	t.Parallel()
+	tr := NewTransactor(nil, zap.NewNop(), WithRetry(10, 5*time.Millisecond, 30*time.Millisecond))
+
+	for attempt, limit := range map[int]time.Duration{
+		1: 5 * time.Millisecond,
+		2: 10 * time.Millisecond,
+		3: 20 * time.Millisecond,
+		4: 30 * time.Millisecond,
+		9: 30 * time.Millisecond,
+	} {
+		for i := 0; i < 100; i++ {
+			if d := tr.backoff(attempt); d < 0 || d > limit {
+				t.Fatalf("backoff(%d) = %s, want up to %s", attempt, d, limit)
+			}
+		}
+	}
+}
+
+func TestTransactor_WithTx_Savepoint(t *testing.T) {
+	dsn := os.Getenv("MASCOT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("MASCOT_TEST_POSTGRES_DSN is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+
+	tr := NewTransactor(conn, zap.NewNop())
+	errInner := errors.New("inner failed")
+	var values []int
+	err = tr.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := tr.Conn(ctx).Exec(ctx, "CREATE TEMPORARY TABLE savepoints (v INT) ON COMMIT DROP"); err != nil {
+			return err
+		}
+
+		for v, innerErr := range []error{nil, errInner, nil} {
+			v, innerErr := v, innerErr
+			err := tr.WithTx(ctx, func(ctx context.Context) error {
+				if _, err := tr.Conn(ctx).Exec(ctx, "INSERT INTO savepoints VALUES ($1)", v); err != nil {
+					return err
+				}
+				return innerErr
+			})
+			if !errors.Is(err, innerErr) {
+				return fmt.Errorf("inner WithTx() error = %v, want %v", err, innerErr)
+			}
+		}
+
+		rows, err := tr.Conn(ctx).Query(ctx, "SELECT v FROM savepoints ORDER BY v")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var v int
+			if err := rows.Scan(&v); err != nil {
+				return err
+			}
+			values = append(values, v)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	if len(values) != 2 || values[0] != 0 || values[1] != 2 {
+		t.Errorf("values = %v, want [0 2]", values)
+	}
+}
+
+type testNotificationHandler struct {
+	listening     chan bool
+	notifications chan string
+}
+
+func (h *testNotificationHandler) Listening(on bool) {
+	h.listening <- on
+}
+
+func (h *testNotificationHandler) Notify(payload string) {
+	h.notifications <- payload
+}
+
+func TestTransactor_AfterCommit_Listen(t *testing.T) {
+	dsn := os.Getenv("MASCOT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("MASCOT_TEST_POSTGRES_DSN is not set")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+
+	tr := NewTransactor(conn, zap.NewNop())
+	handler := &testNotificationHandler{listening: make(chan bool, 2), notifications: make(chan string, 2)}
+	go tr.Listen(ctx, "after_commit_test", handler)
+	if on := <-handler.listening; !on {
+		t.Fatalf("Listening(false) before listening")
+	}
+
+	var committed bool
+	err = tr.WithTx(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func() { committed = true })
+		if _, err := tr.Conn(ctx).Exec(ctx, "SELECT pg_notify('after_commit_test', 'changed')"); err != nil {
+			return err
+		}
+
+		select {
+		case payload := <-handler.notifications:
+			return fmt.Errorf("notification %q before commit", payload)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		if committed {
+			return errors.New("AfterCommit func run before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	if !committed {
+		t.Errorf("AfterCommit func not run after commit")
+	}
+
+	select {
+	case payload := <-handler.notifications:
+		if payload != "changed" {
+			t.Errorf("payload = %q, want changed", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("no notification after commit")
+	}
+}
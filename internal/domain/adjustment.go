@@ -0,0 +1,134 @@
+package domain
+
+import "time"
+
+type AdjustmentReason string
+
+const (
+	AdjustmentReasonGoodwill     AdjustmentReason = "goodwill"
+	AdjustmentReasonCorrection   AdjustmentReason = "correction"
+	AdjustmentReasonCompensation AdjustmentReason = "compensation"
+	AdjustmentReasonChargeback   AdjustmentReason = "chargeback"
+)
+
+func (r AdjustmentReason) Valid() bool {
+	switch r {
+	case AdjustmentReasonGoodwill, AdjustmentReasonCorrection, AdjustmentReasonCompensation, AdjustmentReasonChargeback:
+		return true
+	default:
+		return false
+	}
+}
+
+type AdjustmentStatus string
+
+const (
+	AdjustmentStatusPending  AdjustmentStatus = "pending"
+	AdjustmentStatusApplied  AdjustmentStatus = "applied"
+	AdjustmentStatusRejected AdjustmentStatus = "rejected"
+)
+
+// Adjustment is a manual change of the real balance made by an operator. A positive amount credits
+// the wallet, a negative one debits it. Adjustments above the approval threshold stay pending until
+// another operator reviews them.
+type Adjustment struct {
+	ID            int64
+	PlayerName    string
+	Currency      string
+	Amount        int64
+	Reason        AdjustmentReason
+	Comment       string
+	CreatedBy     string
+	ReviewedBy    string
+	ReviewComment string
+	Status        AdjustmentStatus
+	TransactionID string
+	CreatedAt     time.Time
+	ReviewedAt    *time.Time
+}
+
+func (a *Adjustment) Validate() error {
+	if a.PlayerName == "" || a.Amount == 0 || a.Comment == "" || a.CreatedBy == "" {
+		return ErrInvalidAdjustment
+	}
+
+	if !a.Reason.Valid() {
+		return ErrUnknownAdjustmentReason
+	}
+
+	return nil
+}
+
+// NeedsApproval reports whether the adjustment is above threshold and must be approved by a second operator.
+func (a *Adjustment) NeedsApproval(threshold int64) bool {
+	return a.Amount > threshold || a.Amount < -threshold
+}
+
+// Review records the decision of reviewer on the pending adjustment. The maker can't check their own adjustment.
+func (a *Adjustment) Review(reviewer, comment string, approve bool, now time.Time) error {
+	if a.Status != AdjustmentStatusPending {
+		return ErrAdjustmentNotPending
+	}
+
+	if reviewer == "" {
+		return ErrInvalidAdjustment
+	}
+
+	if reviewer == a.CreatedBy {
+		return ErrAdjustmentSelfReview
+	}
+
+	a.ReviewedBy, a.ReviewComment, a.ReviewedAt = reviewer, comment, &now
+	if approve {
+		a.Status = AdjustmentStatusApplied
+	} else {
+		a.Status = AdjustmentStatusRejected
+	}
+
+	return nil
+}
+
+// Adjust applies amount to the real balance of w. A debit can't take the balance below what is spendable.
+func (w *Wallet) Adjust(amount int64) error {
+	if err := w.checkMovementAllowed(amount < 0); err != nil {
+		return err
+	}
+
+	if amount < 0 && (w.Balance < -amount || w.spendable() < -amount) {
+		return ErrNotEnoughMoney
+	}
+
+	var c checked
+	balance := c.add(w.Balance, amount)
+	c.add(balance, w.Bonus.Balance)
+	if c.err != nil {
+		return c.err
+	}
+
+	w.Balance = balance
+	return nil
+}
+
+// AdjustmentEvent is an entry of the audit trail of an adjustment.
+type AdjustmentEvent struct {
+	ID           int64
+	AdjustmentID int64
+	Status       AdjustmentStatus
+	Actor        string
+	Comment      string
+	CreatedAt    time.Time
+}
+
+// OperatorAdjustments sums up the adjustments an operator made in one currency. Created and Pending count
+// the adjustments the operator created, Credited and Debited sum the applied ones. Approved and Rejected
+// count the reviews of the operator.
+type OperatorAdjustments struct {
+	Operator string
+	Currency string
+	Created  int64
+	Pending  int64
+	Credited int64
+	Debited  int64
+	Approved int64
+	Rejected int64
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdjustment_Review(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		status     AdjustmentStatus
+		reviewer   string
+		approve    bool
+		wantErr    error
+		wantStatus AdjustmentStatus
+	}{
+		{name: "approve", status: AdjustmentStatusPending, reviewer: "checker", approve: true, wantStatus: AdjustmentStatusApplied},
+		{name: "reject", status: AdjustmentStatusPending, reviewer: "checker", wantStatus: AdjustmentStatusRejected},
+		{name: "self review", status: AdjustmentStatusPending, reviewer: "maker", approve: true,
+			wantErr: ErrAdjustmentSelfReview, wantStatus: AdjustmentStatusPending},
+		{name: "already applied", status: AdjustmentStatusApplied, reviewer: "checker",
+			wantErr: ErrAdjustmentNotPending, wantStatus: AdjustmentStatusApplied},
+		{name: "no reviewer", status: AdjustmentStatusPending, approve: true,
+			wantErr: ErrInvalidAdjustment, wantStatus: AdjustmentStatusPending},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := &Adjustment{CreatedBy: "maker", Status: tt.status}
+
+			if err := a.Review(tt.reviewer, "", tt.approve, time.Now()); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Review() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if a.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", a.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAdjustment_NeedsApproval(t *testing.T) {
+	t.Parallel()
+	for _, amount := range []int64{100, -100} {
+		a := &Adjustment{Amount: amount}
+		if a.NeedsApproval(100) {
+			t.Errorf("NeedsApproval(100) of %d = true, want false", amount)
+		}
+		if !a.NeedsApproval(99) {
+			t.Errorf("NeedsApproval(99) of %d = false, want true", amount)
+		}
+	}
+}
+
+func TestWallet_Adjust(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		wallet      Wallet
+		amount      int64
+		wantErr     error
+		wantBalance int64
+	}{
+		{name: "credit", wallet: Wallet{Balance: 10}, amount: 5, wantBalance: 15},
+		{name: "debit", wallet: Wallet{Balance: 10}, amount: -10, wantBalance: 0},
+		{name: "debit over real balance", wallet: Wallet{Balance: 10, Bonus: Bonus{Balance: 50}}, amount: -11,
+			wantErr: ErrNotEnoughMoney, wantBalance: 10},
+		{name: "debit of held money", wallet: Wallet{Balance: 10, Held: 5}, amount: -6,
+			wantErr: ErrNotEnoughMoney, wantBalance: 10},
+		{name: "credit of closed wallet", wallet: Wallet{Balance: 10, Status: WalletStatusClosed}, amount: 5,
+			wantErr: ErrWalletClosed, wantBalance: 10},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := tt.wallet
+			if w.Status == "" {
+				w.Status = WalletStatusActive
+			}
+
+			if err := w.Adjust(tt.amount); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Adjust() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if w.Balance != tt.wantBalance {
+				t.Errorf("Balance = %d, want %d", w.Balance, tt.wantBalance)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package domain
+
+import "math"
+
+// checked performs int64 arithmetic remembering the first overflow.
+// Once an overflow happened the results must be discarded.
+type checked struct {
+	err error
+}
+
+func (c *checked) add(a, b int64) int64 {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		c.fail()
+		return 0
+	}
+	return a + b
+}
+
+func (c *checked) sub(a, b int64) int64 {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		c.fail()
+		return 0
+	}
+	return a - b
+}
+
+func (c *checked) fail() {
+	if c.err == nil {
+		c.err = ErrAmountOverflow
+	}
+}
+
+// AddAmounts adds two amounts returning ErrAmountOverflow instead of wrapping.
+func AddAmounts(a, b int64) (int64, error) {
+	var c checked
+	sum := c.add(a, b)
+	return sum, c.err
+}
+
+// saturatingAdd adds non-negative b to a, stopping at math.MaxInt64.
+func saturatingAdd(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// saturatingMul multiplies non-negative a and b, stopping at math.MaxInt64.
+func saturatingMul(a, b int64) int64 {
+	if a != 0 && b > math.MaxInt64/a {
+		return math.MaxInt64
+	}
+	return a * b
+}
+
+// Limits are per currency caps enforced on bets, wins and balances. Zero means no cap.
+type Limits struct {
+	MaxBet     int64
+	MaxWin     int64
+	MaxBalance int64
+}
+
+// CheckTransaction checks the amounts of a transaction before it is applied.
+func (l Limits) CheckTransaction(tx *Transaction) error {
+	if l.MaxBet > 0 && tx.WithdrawAmount() > l.MaxBet {
+		return ErrBetLimitExceeded
+	}
+
+	if l.MaxWin > 0 && tx.DepositAmount() > l.MaxWin {
+		return ErrWinLimitExceeded
+	}
+
+	return nil
+}
+
+// CheckBalance checks the wallet balance after a credit. Debits are always allowed
+// so that a wallet above a lowered cap can still play its balance down.
+func (l Limits) CheckBalance(wallet *Wallet, credited bool) error {
+	if credited && l.MaxBalance > 0 && wallet.TotalBalance() > l.MaxBalance {
+		return ErrBalanceLimitExceeded
+	}
+
+	return nil
+}
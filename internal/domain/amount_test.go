@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// amount is an int64 generator biased towards values close to the int64 bounds.
+type amount int64
+
+func (amount) Generate(r *rand.Rand, _ int) reflect.Value {
+	var v int64
+	switch r.Intn(4) {
+	case 0:
+		v = r.Int63n(1000)
+	case 1:
+		v = math.MaxInt64 - r.Int63n(1000)
+	case 2:
+		v = math.MaxInt64/2 + r.Int63n(1000) - 500
+	default:
+		v = r.Int63()
+	}
+	if r.Intn(2) == 0 {
+		v = -v
+	}
+	return reflect.ValueOf(amount(v))
+}
+
+// nonNegative is an amount generator producing values a provider could send.
+type nonNegative int64
+
+func (nonNegative) Generate(r *rand.Rand, size int) reflect.Value {
+	v := int64(amount(0).Generate(r, size).Interface().(amount))
+	if v < 0 {
+		v = -(v + 1)
+	}
+	return reflect.ValueOf(nonNegative(v))
+}
+
+func fitsInt64(v *big.Int) bool {
+	return v.IsInt64()
+}
+
+func TestChecked_Property(t *testing.T) {
+	t.Parallel()
+	add := func(a, b amount) bool {
+		var c checked
+		got := c.add(int64(a), int64(b))
+		want := new(big.Int).Add(big.NewInt(int64(a)), big.NewInt(int64(b)))
+		if !fitsInt64(want) {
+			return errors.Is(c.err, ErrAmountOverflow)
+		}
+		return c.err == nil && got == want.Int64()
+	}
+	if err := quick.Check(add, nil); err != nil {
+		t.Error(err)
+	}
+
+	sub := func(a, b amount) bool {
+		var c checked
+		got := c.sub(int64(a), int64(b))
+		want := new(big.Int).Sub(big.NewInt(int64(a)), big.NewInt(int64(b)))
+		if !fitsInt64(want) {
+			return errors.Is(c.err, ErrAmountOverflow)
+		}
+		return c.err == nil && got == want.Int64()
+	}
+	if err := quick.Check(sub, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+type walletOp struct {
+	Rollback bool
+	Bonus    bool
+	Deposit  nonNegative
+	Withdraw nonNegative
+}
+
+// TestWallet_NeverWrapsProperty applies random operations with huge amounts and checks that
+// a failed operation leaves the wallet untouched and a successful one changes the total balance
+// exactly by its amounts without wrapping.
+func TestWallet_NeverWrapsProperty(t *testing.T) {
+	t.Parallel()
+	property := func(real, bonus nonNegative, ops []walletOp) bool {
+		w := &Wallet{Balance: int64(real), Status: WalletStatusActive}
+		if bonus > 0 && w.GrantBonus(int64(bonus), 0, nil) != nil {
+			w.Bonus = Bonus{}
+		}
+
+		var applied []*Transaction
+		for _, op := range ops {
+			before := *w
+			total := new(big.Int).Add(big.NewInt(w.Balance), big.NewInt(w.Bonus.Balance))
+			deposit, withdraw := int64(op.Deposit), int64(op.Withdraw)
+
+			var (
+				err   error
+				delta *big.Int
+			)
+			switch {
+			case op.Rollback && len(applied) > 0:
+				tx := applied[len(applied)-1]
+				applied = applied[:len(applied)-1]
+				_, err = w.Rollback(tx, RollbackPolicyReject)
+				delta = new(big.Int).Sub(big.NewInt(tx.WithdrawAmount()), big.NewInt(tx.DepositAmount()))
+			case op.Bonus && deposit > 0:
+				err = w.GrantBonus(deposit, 0, nil)
+				delta = big.NewInt(deposit)
+			default:
+				tx := &Transaction{Deposit: &deposit, Withdraw: &withdraw}
+				err = w.WithdrawAndDeposit(tx, SpendRealFirst)
+				if err == nil {
+					applied = append(applied, tx)
+				}
+				delta = new(big.Int).Sub(big.NewInt(deposit), big.NewInt(withdraw))
+			}
+
+			if err != nil {
+				if w.Balance != before.Balance || w.Bonus != before.Bonus {
+					return false
+				}
+				continue
+			}
+
+			want := new(big.Int).Add(total, delta)
+			got := new(big.Int).Add(big.NewInt(w.Balance), big.NewInt(w.Bonus.Balance))
+			if w.Balance < 0 || w.Bonus.Balance < 0 || !fitsInt64(got) || got.Cmp(want) != 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+	limits := Limits{MaxBet: 100, MaxWin: 1000, MaxBalance: 5000}
+	tests := []struct {
+		name     string
+		balance  int64
+		deposit  int64
+		withdraw int64
+		wantErr  error
+	}{
+		{name: "within limits", balance: 100, deposit: 1000, withdraw: 100},
+		{name: "bet limit", balance: 1000, withdraw: 101, wantErr: ErrBetLimitExceeded},
+		{name: "win limit", balance: 100, deposit: 1001, wantErr: ErrWinLimitExceeded},
+		{name: "balance limit", balance: 4500, deposit: 600, wantErr: ErrBalanceLimitExceeded},
+		{name: "debit above balance limit", balance: 6000, withdraw: 100},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := &Wallet{Balance: tt.balance, Status: WalletStatusActive}
+			tx := newTx(tt.deposit, tt.withdraw)
+			err := limits.CheckTransaction(tx)
+			if err == nil {
+				if err = w.WithdrawAndDeposit(tx, SpendRealFirst); err != nil {
+					t.Fatalf("WithdrawAndDeposit() error = %v", err)
+				}
+				err = limits.CheckBalance(w, tt.deposit > tt.withdraw)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
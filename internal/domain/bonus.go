@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+type SpendOrder string
+
+const (
+	// SpendRealFirst takes bets from the real balance and uses bonus funds only for the remainder.
+	SpendRealFirst SpendOrder = "real_first"
+	// SpendBonusFirst takes bets from the bonus balance and uses real money only for the remainder.
+	SpendBonusFirst SpendOrder = "bonus_first"
+)
+
+func (o SpendOrder) Valid() bool {
+	return o == SpendRealFirst || o == SpendBonusFirst
+}
+
+// Bonus is the bonus sub-balance of a wallet together with its wagering state.
+type Bonus struct {
+	Balance             int64
+	WageringRequirement int64
+	Wagered             int64
+	ExpiresAt           *time.Time
+}
+
+// Active reports whether the wallet has a bonus which is not converted, lost or expired yet.
+func (b *Bonus) Active() bool {
+	return b.Balance > 0 || b.WageringRequirement > 0
+}
+
+func (b *Bonus) expired(now time.Time) bool {
+	return b.ExpiresAt != nil && !now.Before(*b.ExpiresAt)
+}
+
+func (b *Bonus) reset() {
+	*b = Bonus{}
+}
+
+// Balance is a wallet balance split by sub-balances. Held is the part of the total reserved by unfinished games.
+type Balance struct {
+	Real  int64
+	Bonus int64
+	Held  int64
+}
+
+func (b Balance) Total() int64 {
+	return b.Real + b.Bonus
+}
+
+// Available is the part of the total which may be bet.
+func (b Balance) Available() int64 {
+	return b.Total() - b.Held
+}
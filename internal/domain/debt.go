@@ -0,0 +1,68 @@
+package domain
+
+// RollbackPolicy decides what happens when a rollback takes back money the player already spent.
+type RollbackPolicy string
+
+const (
+	// RollbackPolicyReject fails the rollback with ErrNotEnoughMoney.
+	RollbackPolicyReject RollbackPolicy = "reject"
+	// RollbackPolicyNegativeBalance lets the real balance go below zero.
+	RollbackPolicyNegativeBalance RollbackPolicy = "negative_balance"
+	// RollbackPolicyDebt keeps the balance at zero and records the shortfall as a debt recovered from later wins.
+	RollbackPolicyDebt RollbackPolicy = "debt"
+)
+
+func (p RollbackPolicy) Valid() bool {
+	switch p {
+	case RollbackPolicyReject, RollbackPolicyNegativeBalance, RollbackPolicyDebt:
+		return true
+	default:
+		return false
+	}
+}
+
+// MoveBonusShortfall takes a negative bonus sub-balance left by a rollback from the real balance.
+// It returns the moved amount.
+func (w *Wallet) MoveBonusShortfall() int64 {
+	if w.Bonus.Balance >= 0 {
+		return 0
+	}
+
+	moved := -w.Bonus.Balance
+	w.Balance -= moved
+	w.Bonus.reset()
+	return moved
+}
+
+// TakeDebt brings a negative real balance to zero and adds it to the debt. It returns the amount taken.
+func (w *Wallet) TakeDebt() int64 {
+	if w.Balance >= 0 {
+		return 0
+	}
+
+	taken := -w.Balance
+	w.Balance = 0
+	w.Debt = saturatingAdd(w.Debt, taken)
+	return taken
+}
+
+// RecoverDebt pays the debt off the real money just credited to the wallet, never more than the credited
+// amount or the real balance. It returns the recovered amount.
+func (w *Wallet) RecoverDebt(credited int64) int64 {
+	recovered := min64(min64(w.Debt, max64(credited, 0)), max64(w.Balance, 0))
+	w.Balance -= recovered
+	w.Debt -= recovered
+	return recovered
+}
+
+// DebtReportEntry is the money a player owes: a recorded debt or a negative balance.
+type DebtReportEntry struct {
+	PlayerName      string
+	Currency        string
+	Debt            int64
+	NegativeBalance int64
+}
+
+func (e *DebtReportEntry) Outstanding() int64 {
+	return saturatingAdd(e.Debt, e.NegativeBalance)
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWallet_RollbackPolicies(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		policy        RollbackPolicy
+		wantErr       error
+		wantShortfall int64
+		wantBalance   int64
+		wantDebt      int64
+	}{
+		{
+			name:        "reject keeps the wallet untouched",
+			policy:      RollbackPolicyReject,
+			wantErr:     ErrNotEnoughMoney,
+			wantBalance: 30,
+		},
+		{
+			name:          "negative balance",
+			policy:        RollbackPolicyNegativeBalance,
+			wantShortfall: 60,
+			wantBalance:   -60,
+		},
+		{
+			name:          "debt",
+			policy:        RollbackPolicyDebt,
+			wantShortfall: 60,
+			wantDebt:      60,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// the win of 100 on a bet of 10 was mostly spent already
+			w := &Wallet{Balance: 30, Status: WalletStatusActive}
+			shortfall, err := w.Rollback(newTx(100, 10), tt.policy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Rollback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.policy == RollbackPolicyDebt {
+				w.TakeDebt()
+			}
+
+			if shortfall != tt.wantShortfall || w.Balance != tt.wantBalance || w.Debt != tt.wantDebt {
+				t.Errorf("shortfall, balance, debt = %d, %d, %d, want %d, %d, %d",
+					shortfall, w.Balance, w.Debt, tt.wantShortfall, tt.wantBalance, tt.wantDebt)
+			}
+		})
+	}
+}
+
+func TestWallet_DebtRecovery(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		balance       int64
+		debt          int64
+		credited      int64
+		wantRecovered int64
+	}{
+		{name: "part of the debt", balance: 40, debt: 60, credited: 40, wantRecovered: 40},
+		{name: "whole debt", balance: 180, debt: 20, credited: 100, wantRecovered: 20},
+		{name: "only the credit, not the balance", balance: 500, debt: 60, credited: 1, wantRecovered: 1},
+		{name: "nothing credited", balance: 500, debt: 60, credited: 0},
+		{name: "net loss", balance: 500, debt: 60, credited: -10},
+		{name: "balance below the credit", balance: 5, debt: 60, credited: 10, wantRecovered: 5},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := &Wallet{Balance: tt.balance, Debt: tt.debt}
+			if recovered := w.RecoverDebt(tt.credited); recovered != tt.wantRecovered {
+				t.Errorf("RecoverDebt(%d) = %d, want %d", tt.credited, recovered, tt.wantRecovered)
+			}
+			if w.Balance != tt.balance-tt.wantRecovered || w.Debt != tt.debt-tt.wantRecovered {
+				t.Errorf("balance %d, debt %d, want %d, %d", w.Balance, w.Debt, tt.balance-tt.wantRecovered, tt.debt-tt.wantRecovered)
+			}
+		})
+	}
+}
+
+func TestWallet_NegativeBalanceBets(t *testing.T) {
+	t.Parallel()
+	w := &Wallet{Balance: -30, Bonus: Bonus{Balance: 50}, Status: WalletStatusActive}
+
+	// the bonus can be played but must not pay off the negative balance
+	if err := w.WithdrawAndDeposit(newTx(0, 60), SpendRealFirst); !errors.Is(err, ErrNotEnoughMoney) {
+		t.Errorf("WithdrawAndDeposit() error = %v, want %v", err, ErrNotEnoughMoney)
+	}
+
+	tx := newTx(0, 20)
+	if err := w.WithdrawAndDeposit(tx, SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if w.Balance != -30 || w.Bonus.Balance != 30 || tx.WithdrawBonus != 20 {
+		t.Errorf("balance %d, bonus %d, withdraw bonus %d, want -30, 30, 20", w.Balance, w.Bonus.Balance, tx.WithdrawBonus)
+	}
+}
@@ -9,4 +9,63 @@ var (
 	ErrNegativeWithdrawal      = errors.New("negative withdrawal")
 	ErrNegativeDeposit         = errors.New("negative deposit")
 	ErrTransactionIsRolledBack = errors.New("transaction is rolled back")
+	ErrWalletSuspended         = errors.New("wallet is suspended")
+	ErrWalletFrozen            = errors.New("wallet is frozen")
+	ErrWalletClosed            = errors.New("wallet is closed")
+	ErrUnknownWalletStatus     = errors.New("unknown wallet status")
+	ErrIllegalStatusTransition = errors.New("illegal wallet status transition")
+	ErrInvalidBonus            = errors.New("invalid bonus")
+	ErrInvalidBonusDeposit     = errors.New("bonus deposit exceeds deposit")
+	ErrRoundNotFound           = errors.New("round not found")
+	ErrRoundIsFinished         = errors.New("round is finished")
+	ErrInvalidFreeRounds       = errors.New("invalid free rounds")
+	ErrFreeRoundsExists        = errors.New("free rounds already exist")
+	ErrFreeRoundsNotFound      = errors.New("free rounds not found")
+	ErrFreeRoundsCancelled     = errors.New("free rounds are cancelled")
+	ErrFreeRoundsGameMismatch  = errors.New("free rounds are granted for another game")
+	ErrFreeRoundsExpired       = errors.New("free rounds are expired")
+	ErrFreeRoundsExhausted     = errors.New("free rounds are exhausted")
+	ErrFreeRoundWithdrawal     = errors.New("free round bet must not withdraw money")
+	ErrFreeRoundsRoundMismatch = errors.New("round was not started with the free rounds")
+	ErrJackpotPoolNotFound     = errors.New("jackpot pool not found")
+	ErrJackpotPoolExists       = errors.New("jackpot pool already exists")
+	ErrJackpotPoolInsufficient = errors.New("jackpot pool balance is insufficient")
+	ErrInvalidJackpotPayout    = errors.New("invalid jackpot payout")
+	ErrInvalidJackpotPool      = errors.New("invalid jackpot pool")
+	ErrAmountOverflow          = errors.New("amount overflow")
+	ErrBetLimitExceeded        = errors.New("bet limit exceeded")
+	ErrWinLimitExceeded        = errors.New("win limit exceeded")
+	ErrBalanceLimitExceeded    = errors.New("balance limit exceeded")
+	ErrInvalidCursor           = errors.New("invalid cursor")
+	ErrInvalidFilter           = errors.New("invalid filter")
+	ErrUnbalancedEntrySet      = errors.New("unbalanced ledger entry set")
+	ErrIdempotencyConflict     = errors.New("idempotency conflict")
+	ErrTransactionExists       = errors.New("transaction already exists")
+	ErrRollbackNotAllowed      = errors.New("only bets and wins can be rolled back")
+	ErrReplayUnavailable       = errors.New("result of the handled transaction is not recorded")
+	ErrRollbackPlayerMismatch  = errors.New("transaction belongs to another player")
+	ErrUnknownRollbackPolicy   = errors.New("unknown rollback policy")
+	ErrInvalidReservation      = errors.New("invalid reservation")
+	ErrReservationNotFound     = errors.New("reservation not found")
+	ErrReservationCommitted    = errors.New("reservation is committed")
+	ErrReservationReleased     = errors.New("reservation is released")
+	ErrReservationExpired      = errors.New("reservation is expired")
+	ErrInvalidTransfer         = errors.New("invalid transfer")
+	ErrInvalidAdjustment       = errors.New("invalid adjustment")
+	ErrUnknownAdjustmentReason = errors.New("unknown adjustment reason")
+	ErrAdjustmentNotFound      = errors.New("adjustment not found")
+	ErrAdjustmentNotPending    = errors.New("adjustment is not pending")
+	ErrAdjustmentSelfReview    = errors.New("adjustment must be reviewed by another operator")
+)
+
+var (
+	ErrInvalidWebhookSubscription  = errors.New("invalid webhook subscription")
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound     = errors.New("webhook delivery not found")
+)
+
+var (
+	ErrInvalidWallet       = errors.New("invalid wallet")
+	ErrWalletExists        = errors.New("wallet already exists")
+	ErrTransactionNotFound = errors.New("transaction not found")
 )
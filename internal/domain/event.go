@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+type EventType string
+
+const (
+	EventTypeBalanceChanged        EventType = "balance_changed"
+	EventTypeTransactionCommitted  EventType = "transaction_committed"
+	EventTypeTransactionRolledBack EventType = "transaction_rolled_back"
+)
+
+// Event is a change of a wallet written to the outbox in the transaction which made it. Payload is
+// the JSON encoded change. Events of a wallet are delivered in ID order, at least once.
+type Event struct {
+	ID         int64
+	Type       EventType
+	PlayerName string
+	Payload    []byte
+	Attempts   int
+	CreatedAt  time.Time
+}
+
+func (t EventType) Valid() bool {
+	switch t {
+	case EventTypeBalanceChanged, EventTypeTransactionCommitted, EventTypeTransactionRolledBack:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package domain
+
+import "time"
+
+type FreeRoundsStatus string
+
+const (
+	FreeRoundsStatusActive    FreeRoundsStatus = "active"
+	FreeRoundsStatusCancelled FreeRoundsStatus = "cancelled"
+)
+
+// SubBalance names a part of the wallet balance.
+type SubBalance string
+
+const (
+	SubBalanceReal  SubBalance = "real"
+	SubBalanceBonus SubBalance = "bonus"
+)
+
+func (b SubBalance) Valid() bool {
+	return b == SubBalanceReal || b == SubBalanceBonus
+}
+
+// FreeRounds is a grant of free-round bets for a player in a game. Ref is the reference
+// the game provider sends with every free-round bet.
+type FreeRounds struct {
+	ID                 int64
+	Ref                string
+	PlayerName         string
+	GameID             string
+	Campaign           string
+	Currency           string
+	Count              int64
+	Remaining          int64
+	BetValue           int64
+	WageringMultiplier int64
+	TotalWin           int64
+	Status             FreeRoundsStatus
+	ExpiresAt          time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+func (f *FreeRounds) Validate() error {
+	if f.Ref == "" || f.Count <= 0 || f.BetValue <= 0 || f.WageringMultiplier < 0 {
+		return ErrInvalidFreeRounds
+	}
+
+	return nil
+}
+
+// Check reports whether the free rounds may still be played in the given game. Every bet of a free round
+// is checked, the round which spent the last free round goes on with none remaining.
+func (f *FreeRounds) Check(gameID string, now time.Time) error {
+	switch {
+	case f.Status == FreeRoundsStatusCancelled:
+		return ErrFreeRoundsCancelled
+	case f.GameID != gameID:
+		return ErrFreeRoundsGameMismatch
+	case !now.Before(f.ExpiresAt):
+		return ErrFreeRoundsExpired
+	}
+
+	return nil
+}
+
+// Spend uses up one free round in the given game.
+func (f *FreeRounds) Spend(gameID string, now time.Time) error {
+	if err := f.Check(gameID, now); err != nil {
+		return err
+	}
+
+	if f.Remaining <= 0 {
+		return ErrFreeRoundsExhausted
+	}
+
+	f.Remaining--
+	return nil
+}
+
+// Restore gives back a free round spent by a rolled back bet.
+func (f *FreeRounds) Restore() {
+	if f.Remaining < f.Count {
+		f.Remaining++
+	}
+}
+
+func (f *FreeRounds) Cancel() error {
+	if f.Status == FreeRoundsStatusCancelled {
+		return ErrFreeRoundsCancelled
+	}
+
+	f.Status = FreeRoundsStatusCancelled
+	return nil
+}
+
+type FreeRoundsCampaignReport struct {
+	Campaign        string
+	Grants          int64
+	Players         int64
+	RoundsGranted   int64
+	RoundsUsed      int64
+	RoundsRemaining int64
+	TotalBetValue   int64
+	TotalWin        int64
+}
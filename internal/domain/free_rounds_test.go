@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFreeRounds_Spend(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	tests := []struct {
+		name       string
+		freeRounds FreeRounds
+		gameID     string
+		wantErr    error
+	}{
+		{
+			name:       "spends a round",
+			freeRounds: FreeRounds{GameID: "g1", Remaining: 1, Status: FreeRoundsStatusActive, ExpiresAt: now.Add(time.Hour)},
+			gameID:     "g1",
+		},
+		{
+			name:       "another game",
+			freeRounds: FreeRounds{GameID: "g1", Remaining: 1, Status: FreeRoundsStatusActive, ExpiresAt: now.Add(time.Hour)},
+			gameID:     "g2",
+			wantErr:    ErrFreeRoundsGameMismatch,
+		},
+		{
+			name:       "expired",
+			freeRounds: FreeRounds{GameID: "g1", Remaining: 1, Status: FreeRoundsStatusActive, ExpiresAt: now},
+			gameID:     "g1",
+			wantErr:    ErrFreeRoundsExpired,
+		},
+		{
+			name:       "exhausted",
+			freeRounds: FreeRounds{GameID: "g1", Status: FreeRoundsStatusActive, ExpiresAt: now.Add(time.Hour)},
+			gameID:     "g1",
+			wantErr:    ErrFreeRoundsExhausted,
+		},
+		{
+			name:       "cancelled",
+			freeRounds: FreeRounds{GameID: "g1", Remaining: 1, Status: FreeRoundsStatusCancelled, ExpiresAt: now.Add(time.Hour)},
+			gameID:     "g1",
+			wantErr:    ErrFreeRoundsCancelled,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tt.freeRounds.Spend(tt.gameID, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Spend() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFreeRounds_Check(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	f := FreeRounds{GameID: "g1", Status: FreeRoundsStatusActive, ExpiresAt: now.Add(time.Hour)}
+	if err := f.Check("g1", now); err != nil {
+		t.Errorf("Check() of the round spending the last free round error = %v", err)
+	}
+	if err := f.Check("g1", now.Add(time.Hour)); !errors.Is(err, ErrFreeRoundsExpired) {
+		t.Errorf("Check() after expiry error = %v, want %v", err, ErrFreeRoundsExpired)
+	}
+}
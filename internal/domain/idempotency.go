@@ -0,0 +1,60 @@
+package domain
+
+import "strings"
+
+// IdempotencyConflict is returned when a transaction ref is reused with different parameters.
+type IdempotencyConflict struct {
+	// Fields are the request parameters which differ from the handled transaction.
+	Fields []string
+}
+
+func (e *IdempotencyConflict) Error() string {
+	return ErrIdempotencyConflict.Error() + ": " + strings.Join(e.Fields, ", ")
+}
+
+func (e *IdempotencyConflict) Is(target error) bool {
+	return target == ErrIdempotencyConflict
+}
+
+// CheckReplay checks that tx repeats the handled transaction with the same external id.
+func (t *Transaction) CheckReplay(handled *Transaction) error {
+	var fields []string
+	diff := func(field string, differs bool) {
+		if differs {
+			fields = append(fields, field)
+		}
+	}
+
+	diff("playerName", t.PlayerName != handled.PlayerName)
+	diff("withdraw", t.WithdrawAmount() != handled.WithdrawAmount())
+	diff("deposit", t.DepositAmount() != handled.DepositAmount())
+	diff("currency", !strings.EqualFold(t.Currency, handled.Currency))
+	diff("gameId", t.GameID != handled.GameID)
+	diff("gameRoundRef", t.RoundRef != handled.RoundRef)
+	diff("finished", t.Finished != handled.Finished)
+	diff("freeRoundsRef", t.FreeRoundsRef != handled.FreeRoundsRef)
+	diff("jackpotPayouts", !samePayouts(t.JackpotPayouts, handled.JackpotPayouts))
+
+	if len(fields) > 0 {
+		return &IdempotencyConflict{Fields: fields}
+	}
+
+	return nil
+}
+
+func samePayouts(a, b []JackpotPayout) bool {
+	sums := make(map[string]int64, len(a))
+	for _, payout := range a {
+		sums[payout.PoolName] += payout.Amount
+	}
+	for _, payout := range b {
+		sums[payout.PoolName] -= payout.Amount
+	}
+
+	for _, sum := range sums {
+		if sum != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package domain
+
+import "time"
+
+// basisPointsDenominator converts contribution rates in basis points to fractions.
+const basisPointsDenominator = 10000
+
+type JackpotEntryKind string
+
+const (
+	JackpotEntrySeed                 JackpotEntryKind = "seed"
+	JackpotEntryContribution         JackpotEntryKind = "contribution"
+	JackpotEntryPayout               JackpotEntryKind = "payout"
+	JackpotEntryContributionReversal JackpotEntryKind = "contribution_reversal"
+	JackpotEntryPayoutReversal       JackpotEntryKind = "payout_reversal"
+)
+
+type JackpotPool struct {
+	ID        int64
+	Name      string
+	Currency  string
+	Balance   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// JackpotContribution sends RateBasisPoints of every bet in Currency to the pool.
+// Empty GameID applies to every game without a game specific rule for the same pool.
+type JackpotContribution struct {
+	ID              int64
+	PoolID          int64
+	PoolName        string
+	GameID          string
+	Currency        string
+	RateBasisPoints int64
+}
+
+// Amount returns the contribution from bet rounded down. It is computed in parts so that
+// bet * rate never overflows.
+func (c *JackpotContribution) Amount(bet int64) int64 {
+	return bet/basisPointsDenominator*c.RateBasisPoints +
+		bet%basisPointsDenominator*c.RateBasisPoints/basisPointsDenominator
+}
+
+// JackpotPayout is a part of a transaction deposit paid out from a jackpot pool.
+type JackpotPayout struct {
+	PoolName string
+	Amount   int64
+}
+
+// JackpotLedgerEntry is an immutable change of a pool balance.
+type JackpotLedgerEntry struct {
+	ID            int64
+	PoolID        int64
+	PoolName      string
+	TransactionID string
+	Kind          JackpotEntryKind
+	Amount        int64
+	BalanceAfter  int64
+	CreatedAt     time.Time
+}
+
+// Apply changes the pool balance by amount and returns the ledger entry describing it.
+func (p *JackpotPool) Apply(kind JackpotEntryKind, transactionID string, amount int64) (*JackpotLedgerEntry, error) {
+	if kind == JackpotEntryPayout && p.Balance < -amount {
+		return nil, ErrJackpotPoolInsufficient
+	}
+
+	balance, err := AddAmounts(p.Balance, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Balance = balance
+	return &JackpotLedgerEntry{
+		PoolID:        p.ID,
+		PoolName:      p.Name,
+		TransactionID: transactionID,
+		Kind:          kind,
+		Amount:        amount,
+		BalanceAfter:  p.Balance,
+	}, nil
+}
+
+// ReversalKind returns the kind of the entry cancelling an entry of kind k.
+func (k JackpotEntryKind) ReversalKind() JackpotEntryKind {
+	switch k {
+	case JackpotEntryContribution:
+		return JackpotEntryContributionReversal
+	case JackpotEntryPayout:
+		return JackpotEntryPayoutReversal
+	default:
+		return k
+	}
+}
+
+func ValidateJackpotPayouts(tx *Transaction) error {
+	var (
+		total int64
+		c     checked
+	)
+	for _, payout := range tx.JackpotPayouts {
+		if payout.PoolName == "" || payout.Amount <= 0 {
+			return ErrInvalidJackpotPayout
+		}
+		total = c.add(total, payout.Amount)
+	}
+
+	if c.err != nil {
+		return c.err
+	}
+
+	if total > tx.DepositAmount() {
+		return ErrInvalidJackpotPayout
+	}
+
+	return nil
+}
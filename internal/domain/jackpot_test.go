@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestJackpotContribution_Amount(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		rate int64
+		bet  int64
+		want int64
+	}{
+		{name: "whole basis points", rate: 100, bet: 10000, want: 100},
+		{name: "rounds down", rate: 150, bet: 1001, want: 15},
+		{name: "below one unit", rate: 1, bet: 9999, want: 0},
+		{name: "full rate", rate: 10000, bet: 12345, want: 12345},
+		{name: "no rate", rate: 0, bet: 12345, want: 0},
+		{name: "large bet doesn't overflow", rate: 10000, bet: math.MaxInt64, want: math.MaxInt64},
+		{name: "large bet rounds down", rate: 3, bet: math.MaxInt64, want: 2767011611056432},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := &JackpotContribution{RateBasisPoints: tt.rate}
+			if got := c.Amount(tt.bet); got != tt.want {
+				t.Errorf("Amount(%d) = %d, want %d", tt.bet, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJackpotPool_Apply(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		balance     int64
+		kind        JackpotEntryKind
+		amount      int64
+		wantErr     error
+		wantBalance int64
+	}{
+		{name: "contribution", balance: 100, kind: JackpotEntryContribution, amount: 15, wantBalance: 115},
+		{name: "payout", balance: 100, kind: JackpotEntryPayout, amount: -100, wantBalance: 0},
+		{name: "payout above the pool", balance: 100, kind: JackpotEntryPayout, amount: -101, wantErr: ErrJackpotPoolInsufficient, wantBalance: 100},
+		{name: "contribution reversal may empty the pool", balance: 10, kind: JackpotEntryContributionReversal, amount: -15, wantBalance: -5},
+		{name: "overflow", balance: math.MaxInt64, kind: JackpotEntryContribution, amount: 1, wantErr: ErrAmountOverflow, wantBalance: math.MaxInt64},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := &JackpotPool{ID: 1, Name: "mega", Balance: tt.balance}
+			entry, err := p.Apply(tt.kind, "tx", tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if p.Balance != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", p.Balance, tt.wantBalance)
+			}
+			if err == nil && (entry.Kind != tt.kind || entry.Amount != tt.amount || entry.BalanceAfter != tt.wantBalance) {
+				t.Errorf("entry = %+v, want %s of %d leaving %d", entry, tt.kind, tt.amount, tt.wantBalance)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EntrySetKindRollback = "rollback"
+	// EntrySetKindOpening books the balance a wallet is created with against the house.
+	EntrySetKindOpening = "opening"
+)
+
+// OpeningEntrySetID identifies the opening entry set of the wallet, the same the ledger migration books.
+func OpeningEntrySetID(walletID int64) string {
+	return "opening:wallet:" + strconv.FormatInt(walletID, 10)
+}
+
+func PlayerAccount(playerName string) string {
+	return "player:" + playerName + ":real"
+}
+
+func PlayerBonusAccount(playerName string) string {
+	return "player:" + playerName + ":bonus"
+}
+
+func HouseAccount(currency string) string {
+	return "house:" + strings.ToUpper(currency)
+}
+
+// DebtAccount holds what the player owes, its balance is the negated debt.
+func DebtAccount(playerName string) string {
+	return "player:" + playerName + ":debt"
+}
+
+// PromotionsAccount funds bonuses and takes forfeited ones back.
+func PromotionsAccount(currency string) string {
+	return "promotions:" + strings.ToUpper(currency)
+}
+
+func JackpotAccount(poolName string) string {
+	return "jackpot:" + poolName
+}
+
+// LedgerEntry is an immutable change of an account balance. Positive amounts credit the account,
+// negative ones debit it.
+type LedgerEntry struct {
+	ID            int64
+	SetID         string
+	TransactionID string
+	Kind          string
+	Account       string
+	Currency      string
+	Amount        int64
+	CreatedAt     time.Time
+}
+
+// EntrySet is a group of entries booked together. Entries of a set always sum to zero.
+type EntrySet struct {
+	ID            string
+	TransactionID string
+	Kind          string
+	Currency      string
+	Entries       []*LedgerEntry
+}
+
+func NewEntrySet(id, transactionID, kind, currency string) *EntrySet {
+	return &EntrySet{ID: id, TransactionID: transactionID, Kind: kind, Currency: strings.ToUpper(currency)}
+}
+
+// Post adds amount to the account. Zero amounts are skipped and amounts for the same account are merged.
+func (s *EntrySet) Post(account string, amount int64) error {
+	if amount == 0 {
+		return nil
+	}
+
+	for _, entry := range s.Entries {
+		if entry.Account == account {
+			sum, err := AddAmounts(entry.Amount, amount)
+			if err != nil {
+				return err
+			}
+			entry.Amount = sum
+			return nil
+		}
+	}
+
+	s.Entries = append(s.Entries, &LedgerEntry{
+		SetID:         s.ID,
+		TransactionID: s.TransactionID,
+		Kind:          s.Kind,
+		Account:       account,
+		Currency:      s.Currency,
+		Amount:        amount,
+	})
+	return nil
+}
+
+// Settle posts to the account whatever makes the set sum to zero.
+func (s *EntrySet) Settle(account string) error {
+	sum, err := s.Sum()
+	if err != nil {
+		return err
+	}
+
+	var c checked
+	amount := c.sub(0, sum)
+	if c.err != nil {
+		return c.err
+	}
+
+	return s.Post(account, amount)
+}
+
+func (s *EntrySet) Sum() (int64, error) {
+	var c checked
+	var sum int64
+	for _, entry := range s.Entries {
+		sum = c.add(sum, entry.Amount)
+	}
+
+	return sum, c.err
+}
+
+// Validate checks that the set is balanced.
+func (s *EntrySet) Validate() error {
+	sum, err := s.Sum()
+	if err != nil {
+		return err
+	}
+
+	if sum != 0 {
+		return ErrUnbalancedEntrySet
+	}
+
+	return nil
+}
+
+// NewTransactionEntrySet books the sub-balance changes of tx and the pool movements caused by it.
+// The rest is settled against the house, against promotions for bonus grants and expiries
+// and against the player debt for debts and their recoveries.
+func NewTransactionEntrySet(tx *Transaction, jackpotEntries []*JackpotLedgerEntry) (*EntrySet, error) {
+	set := NewEntrySet(tx.ID, tx.ID, string(tx.Kind), tx.Currency)
+	if err := set.postTransaction(tx, 1, jackpotEntries); err != nil {
+		return nil, err
+	}
+
+	counter := HouseAccount(tx.Currency)
+	switch tx.Kind {
+	case TransactionKindBonusGrant, TransactionKindBonusExpiry:
+		counter = PromotionsAccount(tx.Currency)
+	case TransactionKindDebt, TransactionKindDebtRecovery:
+		counter = DebtAccount(tx.PlayerName)
+	}
+
+	return set, set.Settle(counter)
+}
+
+// NewRollbackEntrySet books the reversal of tx. jackpotEntries are the pool reversals, not the original movements.
+func NewRollbackEntrySet(tx *Transaction, jackpotEntries []*JackpotLedgerEntry) (*EntrySet, error) {
+	set := NewEntrySet(EntrySetKindRollback+":"+tx.ID, tx.ID, EntrySetKindRollback, tx.Currency)
+	if err := set.postTransaction(tx, -1, jackpotEntries); err != nil {
+		return nil, err
+	}
+
+	return set, set.Settle(HouseAccount(tx.Currency))
+}
+
+func (s *EntrySet) postTransaction(tx *Transaction, sign int64, jackpotEntries []*JackpotLedgerEntry) error {
+	if err := s.Post(PlayerAccount(tx.PlayerName), sign*tx.RealDelta()); err != nil {
+		return err
+	}
+
+	if err := s.Post(PlayerBonusAccount(tx.PlayerName), sign*tx.BonusDelta()); err != nil {
+		return err
+	}
+
+	for _, entry := range jackpotEntries {
+		if err := s.Post(JackpotAccount(entry.PoolName), entry.Amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnbalancedEntrySet is an entry set whose entries don't sum to zero.
+type UnbalancedEntrySet struct {
+	SetID string
+	Sum   int64
+}
+
+// ProjectionMismatch is a stored balance which differs from the sum of its ledger account.
+type ProjectionMismatch struct {
+	Account    string
+	Projection int64
+	Ledger     int64
+}
+
+type LedgerCheck struct {
+	CheckedAt  time.Time
+	EntrySets  int64
+	Unbalanced []UnbalancedEntrySet
+	Mismatches []ProjectionMismatch
+}
+
+func (c *LedgerCheck) OK() bool {
+	return len(c.Unbalanced) == 0 && len(c.Mismatches) == 0
+}
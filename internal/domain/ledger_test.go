@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewTransactionEntrySet(t *testing.T) {
+	t.Parallel()
+	withTx := func(tx *Transaction, kind TransactionKind, withdrawBonus, depositBonus int64) *Transaction {
+		tx.ID, tx.Kind, tx.PlayerName, tx.Currency = "tx", kind, "user1", "usd"
+		tx.WithdrawBonus, tx.DepositBonus = withdrawBonus, depositBonus
+		return tx
+	}
+
+	tests := []struct {
+		name    string
+		tx      *Transaction
+		jackpot []*JackpotLedgerEntry
+		want    map[string]int64
+	}{
+		{
+			name: "bet split between sub-balances with jackpot contribution",
+			tx:   withTx(newTx(30, 100), TransactionKindWithdrawAndDeposit, 40, 0),
+			jackpot: []*JackpotLedgerEntry{
+				{PoolName: "mega", Kind: JackpotEntryContribution, Amount: 2},
+			},
+			want: map[string]int64{
+				"player:user1:real": -30, "player:user1:bonus": -40, "jackpot:mega": 2, "house:USD": 68,
+			},
+		},
+		{
+			name: "jackpot payout",
+			tx:   withTx(newTx(500, 0), TransactionKindWithdrawAndDeposit, 0, 0),
+			jackpot: []*JackpotLedgerEntry{
+				{PoolName: "mega", Kind: JackpotEntryPayout, Amount: -400},
+			},
+			want: map[string]int64{"player:user1:real": 500, "jackpot:mega": -400, "house:USD": -100},
+		},
+		{
+			name: "bonus grant is funded by promotions",
+			tx:   withTx(newTx(50, 0), TransactionKindBonusGrant, 0, 50),
+			want: map[string]int64{"player:user1:bonus": 50, "promotions:USD": -50},
+		},
+		{
+			name: "bonus conversion moves money between player accounts",
+			tx:   withTx(newTx(50, 50), TransactionKindBonusConversion, 50, 0),
+			want: map[string]int64{"player:user1:real": 50, "player:user1:bonus": -50},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			set, err := NewTransactionEntrySet(tt.tx, tt.jackpot)
+			if err != nil {
+				t.Fatalf("NewTransactionEntrySet() error = %v", err)
+			}
+			if err := set.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+
+			got := make(map[string]int64, len(set.Entries))
+			for _, entry := range set.Entries {
+				got[entry.Account] = entry.Amount
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+			for account, amount := range tt.want {
+				if got[account] != amount {
+					t.Errorf("entries = %v, want %v", got, tt.want)
+					break
+				}
+			}
+
+			var reversals []*JackpotLedgerEntry
+			for _, entry := range tt.jackpot {
+				reversals = append(reversals, &JackpotLedgerEntry{PoolName: entry.PoolName, Amount: -entry.Amount})
+			}
+			rollback, err := NewRollbackEntrySet(tt.tx, reversals)
+			if err != nil {
+				t.Fatalf("NewRollbackEntrySet() error = %v", err)
+			}
+			for _, entry := range rollback.Entries {
+				if entry.Account != HouseAccount("usd") && got[entry.Account] != -entry.Amount {
+					t.Errorf("rollback entry %s = %d, want %d", entry.Account, entry.Amount, -got[entry.Account])
+				}
+			}
+		})
+	}
+}
+
+func TestEntrySet_Validate(t *testing.T) {
+	t.Parallel()
+	set := NewEntrySet("set", "", "test", "usd")
+	_ = set.Post(PlayerAccount("user1"), 10)
+	if err := set.Validate(); !errors.Is(err, ErrUnbalancedEntrySet) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrUnbalancedEntrySet)
+	}
+
+	_ = set.Post(HouseAccount("usd"), -10)
+	if err := set.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// WalletReconciliation compares a stored wallet balance with the balance recomputed
+// from its opening balance and committed transactions.
+type WalletReconciliation struct {
+	WalletID   int64
+	PlayerName string
+	Currency   string
+	Opening    Balance
+	// Movements is the net of committed, not rolled back transactions.
+	Movements    Balance
+	Transactions int64
+	Actual       Balance
+	Expected     Balance
+	Difference   Balance
+}
+
+// Reconcile computes the expected balance and the difference of the actual balance from it.
+func (r *WalletReconciliation) Reconcile() error {
+	var c checked
+	r.Expected = Balance{
+		Real:  c.add(r.Opening.Real, r.Movements.Real),
+		Bonus: c.add(r.Opening.Bonus, r.Movements.Bonus),
+	}
+	r.Difference = Balance{
+		Real:  c.sub(r.Actual.Real, r.Expected.Real),
+		Bonus: c.sub(r.Actual.Bonus, r.Expected.Bonus),
+	}
+
+	return c.err
+}
+
+func (r *WalletReconciliation) Matches() bool {
+	return r.Difference == Balance{}
+}
+
+type ReconciliationReport struct {
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	Wallets       []*WalletReconciliation
+	Discrepancies int
+}
+
+func NewReconciliationReport(startedAt, finishedAt time.Time, wallets []*WalletReconciliation) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{StartedAt: startedAt, FinishedAt: finishedAt, Wallets: wallets}
+	for _, wallet := range wallets {
+		if err := wallet.Reconcile(); err != nil {
+			return nil, err
+		}
+
+		if !wallet.Matches() {
+			report.Discrepancies++
+		}
+	}
+
+	return report, nil
+}
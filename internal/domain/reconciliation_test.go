@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewReconciliationReport(t *testing.T) {
+	t.Parallel()
+	matching := &WalletReconciliation{
+		PlayerName: "user1",
+		Opening:    Balance{Real: 1000},
+		Movements:  Balance{Real: -200, Bonus: 50},
+		Actual:     Balance{Real: 800, Bonus: 50},
+	}
+	drifted := &WalletReconciliation{
+		PlayerName: "user2",
+		Opening:    Balance{Real: 200},
+		Movements:  Balance{Real: 100},
+		Actual:     Balance{Real: 250, Bonus: 10},
+	}
+
+	now := time.Now()
+	report, err := NewReconciliationReport(now, now, []*WalletReconciliation{matching, drifted})
+	if err != nil {
+		t.Fatalf("NewReconciliationReport() error = %v", err)
+	}
+
+	if report.Discrepancies != 1 {
+		t.Errorf("Discrepancies = %d, want 1", report.Discrepancies)
+	}
+	if !matching.Matches() {
+		t.Errorf("Difference = %+v, want none", matching.Difference)
+	}
+	if want := (Balance{Real: -50, Bonus: 10}); drifted.Difference != want {
+		t.Errorf("Difference = %+v, want %+v", drifted.Difference, want)
+	}
+
+	overflowing := &WalletReconciliation{Opening: Balance{Real: math.MaxInt64}, Movements: Balance{Real: 1}}
+	if _, err := NewReconciliationReport(now, now, []*WalletReconciliation{overflowing}); !errors.Is(err, ErrAmountOverflow) {
+		t.Errorf("NewReconciliationReport() error = %v, want %v", err, ErrAmountOverflow)
+	}
+}
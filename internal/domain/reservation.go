@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationStatusHeld      ReservationStatus = "held"
+	ReservationStatusCommitted ReservationStatus = "committed"
+	ReservationStatusReleased  ReservationStatus = "released"
+	ReservationStatusExpired   ReservationStatus = "expired"
+)
+
+// Reservation holds money of a player until the outcome of a game is known. Held money is still
+// part of the booked balance but can't be bet. Ref is the reference the game provider sends.
+type Reservation struct {
+	ID              int64
+	Ref             string
+	PlayerName      string
+	Currency        string
+	Amount          int64
+	CommittedAmount int64
+	GameID          string
+	RoundRef        string
+	Status          ReservationStatus
+	TransactionID   string
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// TransactionRef is the external id of the transaction booked by the commit of the reservation.
+func (r *Reservation) TransactionRef() string {
+	return "reservation:" + r.Ref
+}
+
+// CheckReplay checks that req repeats the reservation with the same ref.
+func (r *Reservation) CheckReplay(req *Reservation) error {
+	var fields []string
+	if req.PlayerName != r.PlayerName {
+		fields = append(fields, "playerName")
+	}
+	if req.Amount != r.Amount {
+		fields = append(fields, "amount")
+	}
+	if req.Currency != "" && !strings.EqualFold(req.Currency, r.Currency) {
+		fields = append(fields, "currency")
+	}
+
+	if len(fields) > 0 {
+		return &IdempotencyConflict{Fields: fields}
+	}
+
+	return nil
+}
+
+// Expired reports whether a held reservation ran out of time.
+func (r *Reservation) Expired(now time.Time) bool {
+	return r.Status == ReservationStatusHeld && !now.Before(r.ExpiresAt)
+}
+
+// checkHeld reports why the reservation can't be committed or released any more.
+func (r *Reservation) checkHeld(now time.Time) error {
+	switch {
+	case r.Status == ReservationStatusCommitted:
+		return ErrReservationCommitted
+	case r.Status == ReservationStatusReleased:
+		return ErrReservationReleased
+	case r.Status == ReservationStatusExpired, r.Expired(now):
+		return ErrReservationExpired
+	default:
+		return nil
+	}
+}
+
+// Reserve holds amount of the wallet money for r.
+func (w *Wallet) Reserve(r *Reservation) error {
+	if err := w.checkMovementAllowed(true); err != nil {
+		return err
+	}
+
+	if r.Amount <= 0 {
+		return ErrInvalidReservation
+	}
+
+	if w.spendable() < r.Amount {
+		return ErrNotEnoughMoney
+	}
+
+	w.Held += r.Amount
+	r.Status = ReservationStatusHeld
+	return nil
+}
+
+// Commit releases the hold of r so that amount of it can be withdrawn. Amount must be positive and
+// not above the reservation.
+func (w *Wallet) Commit(r *Reservation, amount int64, now time.Time) error {
+	if err := r.checkHeld(now); err != nil {
+		return err
+	}
+
+	if amount <= 0 || amount > r.Amount {
+		return ErrInvalidReservation
+	}
+
+	w.release(r)
+	r.Status = ReservationStatusCommitted
+	r.CommittedAmount = amount
+	return nil
+}
+
+// Release gives the held money of r back to the player.
+func (w *Wallet) Release(r *Reservation, now time.Time) error {
+	if err := r.checkHeld(now); err != nil {
+		return err
+	}
+
+	w.release(r)
+	r.Status = ReservationStatusReleased
+	return nil
+}
+
+// Expire releases r if it ran out of time. It reports whether r expired.
+func (w *Wallet) Expire(r *Reservation, now time.Time) bool {
+	if !r.Expired(now) {
+		return false
+	}
+
+	w.release(r)
+	r.Status = ReservationStatusExpired
+	return true
+}
+
+func (w *Wallet) release(r *Reservation) {
+	w.Held = max64(w.Held-r.Amount, 0)
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWallet_Reservations(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	w := &Wallet{Balance: 100, Status: WalletStatusActive}
+
+	live := &Reservation{Amount: 70, ExpiresAt: now.Add(time.Minute)}
+	if err := w.Reserve(live); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := w.Reserve(&Reservation{Amount: 40}); !errors.Is(err, ErrNotEnoughMoney) {
+		t.Errorf("Reserve() error = %v, want %v", err, ErrNotEnoughMoney)
+	}
+	if err := w.WithdrawAndDeposit(newTx(0, 40), SpendRealFirst); !errors.Is(err, ErrNotEnoughMoney) {
+		t.Errorf("WithdrawAndDeposit() error = %v, want %v", err, ErrNotEnoughMoney)
+	}
+	if b := w.Balances(); b.Total() != 100 || b.Available() != 30 {
+		t.Errorf("Balances() total %d, available %d, want 100, 30", b.Total(), b.Available())
+	}
+
+	for _, amount := range []int64{0, -1, 71} {
+		if err := w.Commit(live, amount, now); !errors.Is(err, ErrInvalidReservation) {
+			t.Errorf("Commit(%d) error = %v, want %v", amount, err, ErrInvalidReservation)
+		}
+	}
+
+	// a partial commit releases the whole hold, the committed part is then withdrawn as a bet
+	if err := w.Commit(live, 50, now); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if w.Held != 0 || live.CommittedAmount != 50 {
+		t.Errorf("held %d, committed %d, want 0, 50", w.Held, live.CommittedAmount)
+	}
+	if err := w.Release(live, now); !errors.Is(err, ErrReservationCommitted) {
+		t.Errorf("Release() error = %v, want %v", err, ErrReservationCommitted)
+	}
+
+	stale := &Reservation{Amount: 20, ExpiresAt: now.Add(-time.Second)}
+	if err := w.Reserve(stale); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := w.Commit(stale, 10, now); !errors.Is(err, ErrReservationExpired) {
+		t.Errorf("Commit() error = %v, want %v", err, ErrReservationExpired)
+	}
+	if !w.Expire(stale, now) || w.Held != 0 || stale.Status != ReservationStatusExpired {
+		t.Errorf("Expire() held %d, status %s, want 0, %s", w.Held, stale.Status, ReservationStatusExpired)
+	}
+}
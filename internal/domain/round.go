@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+type RoundStatus string
+
+const (
+	RoundStatusOpen     RoundStatus = "open"
+	RoundStatusFinished RoundStatus = "finished"
+)
+
+// Round aggregates all transactions made by a player within one game round.
+type Round struct {
+	ID         int64
+	PlayerName string
+	GameID     string
+	RoundRef   string
+	Currency   string
+	TotalBet   int64
+	TotalWin   int64
+	Status     RoundStatus
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+func NewRound(tx *Transaction) *Round {
+	return &Round{
+		PlayerName: tx.PlayerName,
+		GameID:     tx.GameID,
+		RoundRef:   tx.RoundRef,
+		Currency:   tx.Currency,
+		Status:     RoundStatusOpen,
+	}
+}
+
+// Apply adds tx to the round totals and finishes the round if tx is the last one.
+func (r *Round) Apply(tx *Transaction, now time.Time) error {
+	if r.Status == RoundStatusFinished {
+		return ErrRoundIsFinished
+	}
+
+	var c checked
+	totalBet := c.add(r.TotalBet, tx.WithdrawAmount())
+	totalWin := c.add(r.TotalWin, tx.DepositAmount())
+	if c.err != nil {
+		return c.err
+	}
+
+	r.TotalBet, r.TotalWin = totalBet, totalWin
+	if tx.Finished {
+		r.Status = RoundStatusFinished
+		r.FinishedAt = &now
+	}
+
+	return nil
+}
+
+// Revert removes rolled back tx from the round totals. Finished rounds stay finished.
+func (r *Round) Revert(tx *Transaction) {
+	r.TotalBet -= tx.WithdrawAmount()
+	r.TotalWin -= tx.DepositAmount()
+}
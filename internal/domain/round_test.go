@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRound_Apply(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	tests := []struct {
+		name         string
+		round        Round
+		tx           *Transaction
+		finished     bool
+		wantErr      error
+		wantBet      int64
+		wantWin      int64
+		wantStatus   RoundStatus
+		wantFinished bool
+	}{
+		{
+			name:       "bet and win add up",
+			round:      Round{TotalBet: 10, TotalWin: 5, Status: RoundStatusOpen},
+			tx:         newTx(20, 30),
+			wantBet:    40,
+			wantWin:    25,
+			wantStatus: RoundStatusOpen,
+		},
+		{
+			name:         "last transaction finishes the round",
+			round:        Round{TotalBet: 10, Status: RoundStatusOpen},
+			tx:           newTx(15, 0),
+			finished:     true,
+			wantBet:      10,
+			wantWin:      15,
+			wantStatus:   RoundStatusFinished,
+			wantFinished: true,
+		},
+		{
+			name:       "finished round rejects bets",
+			round:      Round{TotalBet: 10, TotalWin: 15, Status: RoundStatusFinished},
+			tx:         newTx(0, 5),
+			wantErr:    ErrRoundIsFinished,
+			wantBet:    10,
+			wantWin:    15,
+			wantStatus: RoundStatusFinished,
+		},
+		{
+			name:       "overflowing totals are rejected",
+			round:      Round{TotalBet: math.MaxInt64, Status: RoundStatusOpen},
+			tx:         newTx(0, 1),
+			wantErr:    ErrAmountOverflow,
+			wantBet:    math.MaxInt64,
+			wantStatus: RoundStatusOpen,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := tt.round
+			tt.tx.Finished = tt.finished
+			if err := r.Apply(tt.tx, now); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if r.TotalBet != tt.wantBet || r.TotalWin != tt.wantWin || r.Status != tt.wantStatus {
+				t.Errorf("got bet %d, win %d, status %s, want %d, %d, %s",
+					r.TotalBet, r.TotalWin, r.Status, tt.wantBet, tt.wantWin, tt.wantStatus)
+			}
+			if (r.FinishedAt != nil) != tt.wantFinished {
+				t.Errorf("got finished at %v, want set %v", r.FinishedAt, tt.wantFinished)
+			}
+		})
+	}
+}
+
+func TestRound_Revert(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	r := &Round{Status: RoundStatusOpen}
+	bet, win := newTx(0, 30), newTx(50, 0)
+	win.Finished = true
+	for _, tx := range []*Transaction{bet, win} {
+		if err := r.Apply(tx, now); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	r.Revert(win)
+	if r.TotalBet != 30 || r.TotalWin != 0 {
+		t.Errorf("after reverting the win got bet %d, win %d, want 30, 0", r.TotalBet, r.TotalWin)
+	}
+	if r.Status != RoundStatusFinished {
+		t.Errorf("got status %s, a finished round stays finished", r.Status)
+	}
+
+	r.Revert(bet)
+	if r.TotalBet != 0 || r.TotalWin != 0 {
+		t.Errorf("after reverting the bet got bet %d, win %d, want 0, 0", r.TotalBet, r.TotalWin)
+	}
+}
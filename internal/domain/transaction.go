@@ -1,12 +1,167 @@
 package domain
 
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TransactionKind string
+
+const (
+	TransactionKindWithdrawAndDeposit TransactionKind = "withdraw_and_deposit"
+	TransactionKindBonusGrant         TransactionKind = "bonus_grant"
+	TransactionKindBonusConversion    TransactionKind = "bonus_conversion"
+	TransactionKindBonusExpiry        TransactionKind = "bonus_expiry"
+	// TransactionKindBonusConversionReversal takes the bonus converted after a rolled back transaction
+	// back to the bonus sub-balance.
+	TransactionKindBonusConversionReversal TransactionKind = "bonus_conversion_reversal"
+	// TransactionKindBonusShortfall takes a bonus sub-balance emptied by a rollback below zero from the real balance.
+	TransactionKindBonusShortfall TransactionKind = "bonus_shortfall"
+	TransactionKindDebt           TransactionKind = "debt"
+	TransactionKindDebtRecovery   TransactionKind = "debt_recovery"
+	TransactionKindTransferOut    TransactionKind = "transfer_out"
+	TransactionKindTransferIn     TransactionKind = "transfer_in"
+	TransactionKindAdjustment     TransactionKind = "adjustment"
+)
+
+// Transaction is a single money movement on a wallet. WithdrawBonus and DepositBonus
+// are the parts of Withdraw and Deposit which went from and to the bonus sub-balance.
+// RollbackShortfall is the part of a rolled back transaction the player had already spent.
+// LostBonus is the wagering state of the bonus the transaction spent to the last of, its rollback restores it.
+// WageringAdded is the wagering requirement the bonus deposit of a free round added, its rollback removes it.
+// Wagered is what the withdrawal counted towards the wagering of the bonus, its rollback takes it back.
+// ConvertedBonus is the bonus whose wagering the transaction met, converted after it, its rollback converts it back.
+// TransferRef links the two transactions of a transfer.
 type Transaction struct {
 	ID                 string
+	Kind               TransactionKind
 	PlayerName         string
 	Withdraw           *int64
 	Deposit            *int64
+	WithdrawBonus      int64
+	DepositBonus       int64
+	LostBonus          *Bonus
+	WageringAdded      int64
+	Wagered            int64
+	ConvertedBonus     *Bonus
 	Currency           string
 	ExternalID         string
+	GameID             string
+	RoundRef           string
+	Finished           bool
+	FreeRoundsRef      string
+	FreeRoundSpent     bool
+	JackpotPayouts     []JackpotPayout
 	BalanceAfterCommit *int64
 	RolledBack         bool
+	RollbackShortfall  int64
+	TransferRef        string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+type TransactionStatus string
+
+const (
+	TransactionStatusCommitted  TransactionStatus = "committed"
+	TransactionStatusRolledBack TransactionStatus = "rolled_back"
+)
+
+func (s TransactionStatus) Valid() bool {
+	return s == TransactionStatusCommitted || s == TransactionStatusRolledBack
+}
+
+func (t *Transaction) Status() TransactionStatus {
+	if t.RolledBack {
+		return TransactionStatusRolledBack
+	}
+	return TransactionStatusCommitted
+}
+
+// CheckRollback reports whether a rollback requested for playerName may reverse t. Game providers roll back
+// only bets and wins, the other kinds are undone by the operations which booked them.
+func (t *Transaction) CheckRollback(playerName string) error {
+	if t.Kind != TransactionKindWithdrawAndDeposit {
+		return ErrRollbackNotAllowed
+	}
+
+	if t.PlayerName != playerName {
+		return ErrRollbackPlayerMismatch
+	}
+
+	return nil
+}
+
+// RealDelta is the change of the real sub-balance made by the transaction.
+func (t *Transaction) RealDelta() int64 {
+	return (t.DepositAmount() - t.DepositBonus) - (t.WithdrawAmount() - t.WithdrawBonus)
+}
+
+// BonusDelta is the change of the bonus sub-balance made by the transaction.
+func (t *Transaction) BonusDelta() int64 {
+	return t.DepositBonus - t.WithdrawBonus
+}
+
+func (t *Transaction) WithdrawAmount() int64 {
+	if t.Withdraw == nil {
+		return 0
+	}
+	return *t.Withdraw
+}
+
+func (t *Transaction) DepositAmount() int64 {
+	if t.Deposit == nil {
+		return 0
+	}
+	return *t.Deposit
+}
+
+// TransactionCursor points at the last transaction of a history page. Pages are ordered
+// by creation time and id descending, so the cursor stays stable while new transactions arrive.
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+func NewTransactionCursor(tx *Transaction) *TransactionCursor {
+	return &TransactionCursor{CreatedAt: tx.CreatedAt, ID: tx.ID}
+}
+
+func (c *TransactionCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeTransactionCursor(encoded string) (*TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &TransactionCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: parts[1]}, nil
+}
+
+// TransactionFilter selects a page of the transaction history. Empty fields don't filter.
+type TransactionFilter struct {
+	PlayerName string
+	Currency   string
+	From       *time.Time
+	To         *time.Time
+	GameID     string
+	RoundRef   string
+	Status     TransactionStatus
+	After      *TransactionCursor
+	Limit      int
 }
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransactionCursor(t *testing.T) {
+	t.Parallel()
+	cursor := &TransactionCursor{CreatedAt: time.Date(2022, 10, 17, 10, 0, 0, 123456000, time.UTC), ID: "abc:def"}
+
+	decoded, err := DecodeTransactionCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeTransactionCursor() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("DecodeTransactionCursor() = %+v, want %+v", decoded, cursor)
+	}
+
+	for _, encoded := range []string{"", "!!!", "MTIz", "eDph"} {
+		if _, err := DecodeTransactionCursor(encoded); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeTransactionCursor(%q) error = %v, want %v", encoded, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestTransaction_CheckReplay(t *testing.T) {
+	t.Parallel()
+	handled := newTx(100, 10)
+	handled.PlayerName, handled.Currency, handled.GameID = "user1", "USD", "game"
+	handled.JackpotPayouts = []JackpotPayout{{PoolName: "mega", Amount: 60}, {PoolName: "mini", Amount: 5}}
+
+	same := newTx(100, 10)
+	same.PlayerName, same.Currency, same.GameID = "user1", "usd", "game"
+	same.JackpotPayouts = []JackpotPayout{{PoolName: "mini", Amount: 5}, {PoolName: "mega", Amount: 60}}
+	if err := same.CheckReplay(handled); err != nil {
+		t.Errorf("CheckReplay() error = %v", err)
+	}
+
+	changed := newTx(100, 20)
+	changed.PlayerName, changed.Currency = "user2", "USD"
+	changed.JackpotPayouts = handled.JackpotPayouts
+	err := changed.CheckReplay(handled)
+
+	var conflict *IdempotencyConflict
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrIdempotencyConflict) {
+		t.Fatalf("CheckReplay() error = %v, want %v", err, ErrIdempotencyConflict)
+	}
+	if want := "playerName,withdraw,gameId"; strings.Join(conflict.Fields, ",") != want {
+		t.Errorf("CheckReplay() fields = %v, want %s", conflict.Fields, want)
+	}
+}
+
+func TestTransaction_CheckRollback(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		kind    TransactionKind
+		player  string
+		wantErr error
+	}{
+		{name: "bet of the player", kind: TransactionKindWithdrawAndDeposit, player: "player"},
+		{name: "bet of another player", kind: TransactionKindWithdrawAndDeposit, player: "other", wantErr: ErrRollbackPlayerMismatch},
+		{name: "transfer leg", kind: TransactionKindTransferOut, player: "player", wantErr: ErrRollbackNotAllowed},
+		{name: "adjustment", kind: TransactionKindAdjustment, player: "player", wantErr: ErrRollbackNotAllowed},
+		{name: "debt recovery", kind: TransactionKindDebtRecovery, player: "player", wantErr: ErrRollbackNotAllowed},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tx := &Transaction{Kind: tt.kind, PlayerName: "player"}
+			if err := tx.CheckRollback(tt.player); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckRollback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
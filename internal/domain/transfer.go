@@ -0,0 +1,90 @@
+package domain
+
+import "strings"
+
+// Transfer moves real money between two wallets of the same currency. It is booked as a pair
+// of transactions linked by the transfer ref.
+type Transfer struct {
+	Ref        string
+	FromPlayer string
+	ToPlayer   string
+	Currency   string
+	Amount     int64
+	Out        *Transaction
+	In         *Transaction
+}
+
+func (t *Transfer) Validate() error {
+	if t.Ref == "" || t.Amount <= 0 || t.FromPlayer == "" || t.FromPlayer == t.ToPlayer {
+		return ErrInvalidTransfer
+	}
+
+	return nil
+}
+
+func (t *Transfer) OutRef() string {
+	return "transfer:" + t.Ref + ":out"
+}
+
+func (t *Transfer) InRef() string {
+	return "transfer:" + t.Ref + ":in"
+}
+
+// CheckReplay checks that t repeats the transfer booked as the out and in transactions.
+func (t *Transfer) CheckReplay(out, in *Transaction) error {
+	var fields []string
+	if t.FromPlayer != out.PlayerName {
+		fields = append(fields, "fromPlayer")
+	}
+	if t.ToPlayer != in.PlayerName {
+		fields = append(fields, "toPlayer")
+	}
+	if !strings.EqualFold(t.Currency, out.Currency) {
+		fields = append(fields, "currency")
+	}
+	if t.Amount != out.WithdrawAmount() {
+		fields = append(fields, "amount")
+	}
+
+	if len(fields) > 0 {
+		return &IdempotencyConflict{Fields: fields}
+	}
+
+	return nil
+}
+
+// Transfer moves amount of the real balance of w to the real balance of to. Bonus and held money can't be
+// transferred. The wallets are left untouched on error.
+func (w *Wallet) Transfer(to *Wallet, amount int64) error {
+	if err := w.checkMovementAllowed(true); err != nil {
+		return err
+	}
+
+	if err := to.checkMovementAllowed(false); err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(w.Currency, to.Currency) {
+		return ErrIllegalCurrency
+	}
+
+	if amount <= 0 {
+		return ErrInvalidTransfer
+	}
+
+	// held money counts against the real balance only, the bonus can't free it for a transfer
+	if w.Balance-w.Held < amount {
+		return ErrNotEnoughMoney
+	}
+
+	var c checked
+	balance := c.add(to.Balance, amount)
+	c.add(balance, to.Bonus.Balance)
+	if c.err != nil {
+		return c.err
+	}
+
+	w.Balance -= amount
+	to.Balance = balance
+	return nil
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWallet_Transfer(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		from     Wallet
+		to       Wallet
+		amount   int64
+		wantErr  error
+		wantFrom int64
+		wantTo   int64
+	}{
+		{
+			name:     "real money moves",
+			from:     Wallet{Balance: 100, Currency: "USD"},
+			to:       Wallet{Balance: 5, Currency: "usd"},
+			amount:   60,
+			wantFrom: 40,
+			wantTo:   65,
+		},
+		{
+			name:     "bonus money stays",
+			from:     Wallet{Balance: 50, Bonus: Bonus{Balance: 100}, Currency: "USD"},
+			to:       Wallet{Currency: "USD"},
+			amount:   60,
+			wantErr:  ErrNotEnoughMoney,
+			wantFrom: 50,
+		},
+		{
+			name:     "held money stays",
+			from:     Wallet{Balance: 100, Held: 50, Currency: "USD"},
+			to:       Wallet{Currency: "USD"},
+			amount:   60,
+			wantErr:  ErrNotEnoughMoney,
+			wantFrom: 100,
+		},
+		{
+			name:     "bonus money doesn't free held money",
+			from:     Wallet{Balance: 100, Bonus: Bonus{Balance: 50}, Held: 50, Currency: "USD"},
+			to:       Wallet{Currency: "USD"},
+			amount:   60,
+			wantErr:  ErrNotEnoughMoney,
+			wantFrom: 100,
+		},
+		{
+			name:     "currencies must match",
+			from:     Wallet{Balance: 100, Currency: "USD"},
+			to:       Wallet{Currency: "EUR"},
+			amount:   10,
+			wantErr:  ErrIllegalCurrency,
+			wantFrom: 100,
+		},
+		{
+			name:     "frozen receiver",
+			from:     Wallet{Balance: 100, Currency: "USD"},
+			to:       Wallet{Currency: "USD", Status: WalletStatusFrozen},
+			amount:   10,
+			wantErr:  ErrWalletFrozen,
+			wantFrom: 100,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, to := tt.from, tt.to
+			if from.Status == "" {
+				from.Status = WalletStatusActive
+			}
+			if to.Status == "" {
+				to.Status = WalletStatusActive
+			}
+
+			if err := from.Transfer(&to, tt.amount); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transfer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if from.Balance != tt.wantFrom || to.Balance != tt.wantTo {
+				t.Errorf("balances = %d, %d, want %d, %d", from.Balance, to.Balance, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
@@ -1,28 +1,274 @@
 package domain
 
+import "time"
+
 type Wallet struct {
 	ID       int64
 	UserName string
 	Currency string
 	Balance  int64
+	Bonus    Bonus
+	Status   WalletStatus
+	// Held is the money reserved for unfinished games, it is part of the balance but can't be bet.
+	Held int64
+	// Debt is the rollback shortfall the player owes, it is recovered from later wins.
+	Debt int64
+	// RollbackPolicy overrides the currency policy when set.
+	RollbackPolicy RollbackPolicy
+	// Operator is the casino the player belongs to, its webhook subscriptions receive the events of the wallet.
+	Operator string
 }
 
-func (w *Wallet) WithdrawAndDeposit(deposit, withdraw int64) error {
-	if w.Balance < withdraw {
-		return ErrNotEnoughMoney
+// Validate checks a wallet about to be created.
+func (w *Wallet) Validate() error {
+	if w.UserName == "" || w.Operator == "" || len(w.Currency) != 3 || w.Balance < 0 {
+		return ErrInvalidWallet
 	}
 
-	w.Balance -= withdraw
-	w.Balance += deposit
 	return nil
 }
 
-func (w *Wallet) Rollback(deposit, withdraw int64) error {
-	if w.Balance < deposit {
+// TotalBalance is the sum of the real and bonus sub-balances. Wallet operations never let it overflow.
+func (w *Wallet) TotalBalance() int64 {
+	return w.Balance + w.Bonus.Balance
+}
+
+func (w *Wallet) Balances() Balance {
+	return Balance{Real: w.Balance, Bonus: w.Bonus.Balance, Held: w.Held}
+}
+
+// WithdrawAndDeposit takes the withdrawal from the real and bonus sub-balances in the given order
+// and credits the deposit. The bonus part of the withdrawal is stored in tx.WithdrawBonus,
+// tx.DepositBonus of the deposit goes to the bonus sub-balance. A withdrawal spending the last of the bonus
+// stores its wagering state in tx.LostBonus. The wallet is left untouched on error.
+func (w *Wallet) WithdrawAndDeposit(tx *Transaction, order SpendOrder) error {
+	withdraw, deposit := tx.WithdrawAmount(), tx.DepositAmount()
+	if err := w.checkMovementAllowed(withdraw > 0); err != nil {
+		return err
+	}
+
+	if withdraw < 0 {
+		return ErrNegativeWithdrawal
+	}
+
+	if deposit < 0 || tx.DepositBonus < 0 {
+		return ErrNegativeDeposit
+	}
+
+	if tx.DepositBonus > deposit {
+		return ErrInvalidBonusDeposit
+	}
+
+	if w.spendable() < withdraw {
 		return ErrNotEnoughMoney
 	}
 
-	w.Balance -= deposit
-	w.Balance += withdraw
+	var (
+		c         checked
+		lostBonus *Bonus
+	)
+	bonusPart := w.bonusPart(withdraw, order)
+	balance, bonus := w.Balance, w.Bonus
+	var wagered int64
+	if bonus.Active() {
+		wagered = saturatingAdd(bonus.Wagered, withdraw) - bonus.Wagered
+		bonus.Wagered += wagered
+	}
+
+	balance = c.sub(balance, withdraw-bonusPart)
+	bonus.Balance = c.sub(bonus.Balance, bonusPart)
+
+	if bonusPart > 0 && bonus.Balance == 0 {
+		// the bonus is lost, a new one starts with a clean wagering state, a rollback brings this one back
+		lost := bonus
+		lostBonus = &lost
+		bonus.reset()
+	}
+
+	balance = c.add(balance, deposit-tx.DepositBonus)
+	bonus.Balance = c.add(bonus.Balance, tx.DepositBonus)
+	c.add(balance, bonus.Balance)
+	if c.err != nil {
+		return c.err
+	}
+
+	tx.WithdrawBonus, tx.LostBonus, tx.Wagered = bonusPart, lostBonus, wagered
+	w.Balance, w.Bonus = balance, bonus
 	return nil
 }
+
+// Rollback reverts tx, returning every part of it to the sub-balance it came from, and takes the bonus
+// converted after tx back from the real balance.
+// When the player already spent the money being taken back, RollbackPolicyReject fails with ErrNotEnoughMoney
+// while other policies let the sub-balances go below zero and return the shortfall this rollback caused.
+// The shortfall is then settled with MoveBonusShortfall and TakeDebt. The wallet is left untouched on error.
+func (w *Wallet) Rollback(tx *Transaction, policy RollbackPolicy) (int64, error) {
+	withdraw, deposit := tx.WithdrawAmount(), tx.DepositAmount()
+	if err := w.checkMovementAllowed(false); err != nil {
+		return 0, err
+	}
+
+	if policy == RollbackPolicyReject && (w.Balance < deposit-tx.DepositBonus || w.Bonus.Balance < tx.DepositBonus) {
+		return 0, ErrNotEnoughMoney
+	}
+
+	var c checked
+	balance, bonus := w.Balance, w.Bonus
+	balance = c.sub(balance, deposit-tx.DepositBonus)
+	bonus.Balance = c.sub(bonus.Balance, tx.DepositBonus)
+	balance = c.add(balance, withdraw-tx.WithdrawBonus)
+	bonus.Balance = c.add(bonus.Balance, tx.WithdrawBonus)
+	if tx.ConvertedBonus != nil {
+		balance = c.sub(balance, tx.ConvertedBonus.Balance)
+		bonus.Balance = c.add(bonus.Balance, tx.ConvertedBonus.Balance)
+	}
+	c.add(balance, bonus.Balance)
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	if policy == RollbackPolicyReject && tx.ConvertedBonus != nil && balance < 0 {
+		return 0, ErrNotEnoughMoney
+	}
+
+	// a lost or converted bonus comes back with the wagering it still needed, not as a bonus free to convert
+	for _, restored := range []*Bonus{tx.LostBonus, tx.ConvertedBonus} {
+		if restored == nil {
+			continue
+		}
+		bonus.WageringRequirement = saturatingAdd(bonus.WageringRequirement, restored.WageringRequirement)
+		bonus.Wagered = saturatingAdd(bonus.Wagered, restored.Wagered)
+		if bonus.ExpiresAt == nil {
+			bonus.ExpiresAt = restored.ExpiresAt
+		}
+	}
+
+	bonus.Wagered = max64(bonus.Wagered-tx.Wagered, 0)
+	bonus.WageringRequirement = max64(bonus.WageringRequirement-tx.WageringAdded, 0)
+
+	// a real balance which was negative before is not this rollback's shortfall
+	shortfall := max64(max64(-balance, 0)+max64(-bonus.Balance, 0)-max64(-w.Balance, 0), 0)
+
+	w.Balance, w.Bonus = balance, bonus
+	return shortfall, nil
+}
+
+// GrantBonus credits amount to the bonus sub-balance and adds requirement to the wagering requirement.
+// The later of the current and the given expiry wins.
+func (w *Wallet) GrantBonus(amount, requirement int64, expiresAt *time.Time) error {
+	if err := w.checkMovementAllowed(false); err != nil {
+		return err
+	}
+
+	if amount <= 0 || requirement < 0 {
+		return ErrInvalidBonus
+	}
+
+	var c checked
+	bonus := c.add(w.Bonus.Balance, amount)
+	c.add(w.Balance, bonus)
+	if c.err != nil {
+		return c.err
+	}
+
+	w.Bonus.Balance = bonus
+	w.Bonus.WageringRequirement = saturatingAdd(w.Bonus.WageringRequirement, requirement)
+	if expiresAt != nil && (w.Bonus.ExpiresAt == nil || expiresAt.After(*w.Bonus.ExpiresAt)) {
+		w.Bonus.ExpiresAt = expiresAt
+	}
+
+	return nil
+}
+
+// AddWageringRequirement makes amount of bonus funds credited outside of a grant subject to
+// wagering multiplier times. It returns the added requirement, which a rollback takes back.
+func (w *Wallet) AddWageringRequirement(amount, multiplier int64) int64 {
+	if amount <= 0 || multiplier <= 0 {
+		return 0
+	}
+
+	added := saturatingMul(amount, multiplier)
+	w.Bonus.WageringRequirement = saturatingAdd(w.Bonus.WageringRequirement, added)
+	return added
+}
+
+// ConvertBonus moves the bonus sub-balance to the real balance once the wagering requirement is met.
+// It returns the converted amount.
+func (w *Wallet) ConvertBonus() int64 {
+	if w.Bonus.Balance == 0 || w.Bonus.Wagered < w.Bonus.WageringRequirement {
+		return 0
+	}
+
+	converted := w.Bonus.Balance
+	w.Balance += converted
+	w.Bonus.reset()
+	return converted
+}
+
+// ExpireBonus forfeits the bonus sub-balance if its time ran out. It returns the forfeited amount.
+func (w *Wallet) ExpireBonus(now time.Time) int64 {
+	if !w.Bonus.Active() || !w.Bonus.expired(now) {
+		return 0
+	}
+
+	forfeited := w.Bonus.Balance
+	w.Bonus.reset()
+	return forfeited
+}
+
+// ChangeStatus moves the wallet to the given status if the transition is allowed.
+func (w *Wallet) ChangeStatus(to WalletStatus) error {
+	if !to.Valid() {
+		return ErrUnknownWalletStatus
+	}
+
+	if !w.Status.CanTransitTo(to) {
+		return ErrIllegalStatusTransition
+	}
+
+	w.Status = to
+	return nil
+}
+
+func (w *Wallet) bonusPart(withdraw int64, order SpendOrder) int64 {
+	if order == SpendBonusFirst {
+		return min64(withdraw, w.Bonus.Balance)
+	}
+
+	return max64(withdraw-max64(w.Balance, 0), 0)
+}
+
+// spendable is the money a bet may take. A negative real balance is owed, the bonus must not pay it off.
+func (w *Wallet) spendable() int64 {
+	return max64(w.Balance, 0) + w.Bonus.Balance - w.Held
+}
+
+// checkMovementAllowed reports whether money may move on the wallet.
+// Suspended wallets accept wins and rollbacks but no bets, frozen and closed wallets accept nothing.
+func (w *Wallet) checkMovementAllowed(isBet bool) error {
+	switch w.Status {
+	case WalletStatusActive:
+		return nil
+	case WalletStatusSuspended:
+		if isBet {
+			return w.Status.Err()
+		}
+		return nil
+	default:
+		return w.Status.Err()
+	}
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
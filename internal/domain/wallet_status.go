@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+type WalletStatus string
+
+const (
+	WalletStatusActive    WalletStatus = "active"
+	WalletStatusSuspended WalletStatus = "suspended"
+	WalletStatusFrozen    WalletStatus = "frozen"
+	WalletStatusClosed    WalletStatus = "closed"
+)
+
+// walletStatusTransitions describes allowed status changes. Closed is terminal.
+var walletStatusTransitions = map[WalletStatus][]WalletStatus{
+	WalletStatusActive:    {WalletStatusSuspended, WalletStatusFrozen, WalletStatusClosed},
+	WalletStatusSuspended: {WalletStatusActive, WalletStatusFrozen, WalletStatusClosed},
+	WalletStatusFrozen:    {WalletStatusActive, WalletStatusSuspended, WalletStatusClosed},
+	WalletStatusClosed:    {},
+}
+
+func (s WalletStatus) Valid() bool {
+	_, ok := walletStatusTransitions[s]
+	return ok
+}
+
+func (s WalletStatus) CanTransitTo(to WalletStatus) bool {
+	for _, allowed := range walletStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Err returns the domain error describing why money can't move on a wallet in this status.
+func (s WalletStatus) Err() error {
+	switch s {
+	case WalletStatusSuspended:
+		return ErrWalletSuspended
+	case WalletStatusFrozen:
+		return ErrWalletFrozen
+	case WalletStatusClosed:
+		return ErrWalletClosed
+	default:
+		return nil
+	}
+}
+
+type WalletStatusChange struct {
+	ID         int64
+	WalletID   int64
+	PlayerName string
+	From       WalletStatus
+	To         WalletStatus
+	Actor      string
+	Reason     string
+	CreatedAt  time.Time
+}
@@ -0,0 +1,289 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTx(deposit, withdraw int64) *Transaction {
+	return &Transaction{Deposit: &deposit, Withdraw: &withdraw}
+}
+
+func TestWallet_StatusMovements(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		status      WalletStatus
+		deposit     int64
+		withdraw    int64
+		wantErr     error
+		rollbackErr error
+	}{
+		{
+			name:     "active wallet accepts bets",
+			status:   WalletStatusActive,
+			deposit:  10,
+			withdraw: 10,
+		},
+		{
+			name:     "suspended wallet rejects bets",
+			status:   WalletStatusSuspended,
+			deposit:  10,
+			withdraw: 10,
+			wantErr:  ErrWalletSuspended,
+		},
+		{
+			name:    "suspended wallet accepts wins",
+			status:  WalletStatusSuspended,
+			deposit: 10,
+		},
+		{
+			name:        "frozen wallet rejects wins and rollbacks",
+			status:      WalletStatusFrozen,
+			deposit:     10,
+			wantErr:     ErrWalletFrozen,
+			rollbackErr: ErrWalletFrozen,
+		},
+		{
+			name:        "closed wallet rejects wins and rollbacks",
+			status:      WalletStatusClosed,
+			deposit:     10,
+			wantErr:     ErrWalletClosed,
+			rollbackErr: ErrWalletClosed,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := &Wallet{Balance: 100, Status: tt.status}
+			if err := w.WithdrawAndDeposit(newTx(tt.deposit, tt.withdraw), SpendRealFirst); !errors.Is(err, tt.wantErr) {
+				t.Errorf("WithdrawAndDeposit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			w = &Wallet{Balance: 100, Status: tt.status}
+			if _, err := w.Rollback(newTx(tt.deposit, tt.withdraw), RollbackPolicyReject); !errors.Is(err, tt.rollbackErr) {
+				t.Errorf("Rollback() error = %v, wantErr %v", err, tt.rollbackErr)
+			}
+		})
+	}
+}
+
+func TestWallet_ChangeStatus(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		from    WalletStatus
+		to      WalletStatus
+		wantErr error
+	}{
+		{name: "active to suspended", from: WalletStatusActive, to: WalletStatusSuspended},
+		{name: "frozen to active", from: WalletStatusFrozen, to: WalletStatusActive},
+		{name: "suspended to closed", from: WalletStatusSuspended, to: WalletStatusClosed},
+		{name: "closed is terminal", from: WalletStatusClosed, to: WalletStatusActive, wantErr: ErrIllegalStatusTransition},
+		{name: "same status", from: WalletStatusActive, to: WalletStatusActive, wantErr: ErrIllegalStatusTransition},
+		{name: "unknown status", from: WalletStatusActive, to: "deleted", wantErr: ErrUnknownWalletStatus},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := &Wallet{Status: tt.from}
+			if err := w.ChangeStatus(tt.to); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ChangeStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWallet_BonusSpendOrder(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		order         SpendOrder
+		withdraw      int64
+		wantErr       error
+		wantReal      int64
+		wantBonus     int64
+		wantBonusPart int64
+	}{
+		{name: "real first", order: SpendRealFirst, withdraw: 30, wantReal: 70, wantBonus: 50},
+		{name: "real first uses bonus for remainder", order: SpendRealFirst, withdraw: 120, wantReal: 0, wantBonus: 30, wantBonusPart: 20},
+		{name: "bonus first", order: SpendBonusFirst, withdraw: 30, wantReal: 100, wantBonus: 20, wantBonusPart: 30},
+		{name: "bonus first uses real for remainder", order: SpendBonusFirst, withdraw: 80, wantReal: 70, wantBonusPart: 50},
+		{name: "not enough money", order: SpendRealFirst, withdraw: 151, wantReal: 100, wantBonus: 50, wantErr: ErrNotEnoughMoney},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := &Wallet{Balance: 100, Bonus: Bonus{Balance: 50, WageringRequirement: 1000}, Status: WalletStatusActive}
+			tx := newTx(0, tt.withdraw)
+			if err := w.WithdrawAndDeposit(tx, tt.order); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithdrawAndDeposit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if w.Balance != tt.wantReal || w.Bonus.Balance != tt.wantBonus || tx.WithdrawBonus != tt.wantBonusPart {
+				t.Errorf("got real %d, bonus %d, bonus part %d, want %d, %d, %d",
+					w.Balance, w.Bonus.Balance, tx.WithdrawBonus, tt.wantReal, tt.wantBonus, tt.wantBonusPart)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+			if _, err := w.Rollback(tx, RollbackPolicyReject); err != nil {
+				t.Fatalf("Rollback() error = %v", err)
+			}
+			if w.Balance != 100 || w.Bonus.Balance != 50 {
+				t.Errorf("after rollback got real %d, bonus %d, want 100, 50", w.Balance, w.Bonus.Balance)
+			}
+		})
+	}
+}
+
+func TestWallet_BonusLifecycle(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+
+	w := &Wallet{Balance: 100, Status: WalletStatusActive}
+	if err := w.GrantBonus(50, 200, &expiresAt); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+
+	if err := w.WithdrawAndDeposit(newTx(0, 150), SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if converted := w.ConvertBonus(); converted != 0 {
+		t.Errorf("ConvertBonus() = %d before wagering is met", converted)
+	}
+
+	if err := w.WithdrawAndDeposit(newTx(100, 0), SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if w.Balance != 100 || w.Bonus != (Bonus{}) {
+		t.Errorf("got real %d, bonus %+v, want 100 and a lost bonus", w.Balance, w.Bonus)
+	}
+
+	if err := w.GrantBonus(30, 10, &expiresAt); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+	if forfeited := w.ExpireBonus(now); forfeited != 0 {
+		t.Errorf("ExpireBonus() = %d before expiry", forfeited)
+	}
+	if forfeited := w.ExpireBonus(expiresAt); forfeited != 30 {
+		t.Errorf("ExpireBonus() = %d, want 30", forfeited)
+	}
+
+	if err := w.GrantBonus(30, 10, nil); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+	if err := w.WithdrawAndDeposit(newTx(0, 10), SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if converted := w.ConvertBonus(); converted != 30 {
+		t.Errorf("ConvertBonus() = %d, want 30", converted)
+	}
+	if w.Balance != 120 || w.Bonus != (Bonus{}) {
+		t.Errorf("got real %d, bonus %+v, want 120 and no bonus", w.Balance, w.Bonus)
+	}
+}
+
+func TestWallet_RollbackRestoresLostBonus(t *testing.T) {
+	t.Parallel()
+	w := &Wallet{Balance: 0, Status: WalletStatusActive}
+	if err := w.GrantBonus(50, 500, nil); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+
+	bet := newTx(0, 50)
+	if err := w.WithdrawAndDeposit(bet, SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if w.Bonus != (Bonus{}) || bet.LostBonus == nil {
+		t.Fatalf("got bonus %+v, lost bonus %+v, want a lost bonus kept by the bet", w.Bonus, bet.LostBonus)
+	}
+
+	if _, err := w.Rollback(bet, RollbackPolicyReject); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	want := Bonus{Balance: 50, WageringRequirement: 500}
+	if w.Bonus != want {
+		t.Errorf("after rollback got bonus %+v, want %+v", w.Bonus, want)
+	}
+	if converted := w.ConvertBonus(); converted != 0 {
+		t.Errorf("ConvertBonus() = %d after rollback, want 0", converted)
+	}
+}
+
+func TestWallet_RollbackRevertsOwnWagering(t *testing.T) {
+	t.Parallel()
+	w := &Wallet{Balance: 100, Status: WalletStatusActive}
+	before := newTx(0, 40)
+	if err := w.WithdrawAndDeposit(before, SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if err := w.GrantBonus(50, 500, nil); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+	during := newTx(0, 30)
+	if err := w.WithdrawAndDeposit(during, SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if before.Wagered != 0 || during.Wagered != 30 {
+		t.Fatalf("got wagered %d, %d, want only the bet on the bonus counted", before.Wagered, during.Wagered)
+	}
+
+	for _, tx := range []*Transaction{before, during} {
+		if _, err := w.Rollback(tx, RollbackPolicyReject); err != nil {
+			t.Fatalf("Rollback() error = %v", err)
+		}
+		if w.Bonus.Wagered != 30-tx.Wagered {
+			t.Errorf("after rolling back a bet of %d got wagered %d, want %d", tx.WithdrawAmount(), w.Bonus.Wagered, 30-tx.Wagered)
+		}
+	}
+}
+
+func TestWallet_RollbackRevertsConversion(t *testing.T) {
+	t.Parallel()
+	w := &Wallet{Balance: 100, Status: WalletStatusActive}
+	if err := w.GrantBonus(50, 100, nil); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+
+	bet := newTx(0, 100)
+	if err := w.WithdrawAndDeposit(bet, SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	converted := w.Bonus
+	if w.ConvertBonus() != 50 {
+		t.Fatalf("ConvertBonus() didn't convert the bonus once wagering was met")
+	}
+	bet.ConvertedBonus = &converted
+
+	if _, err := w.Rollback(bet, RollbackPolicyReject); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	want := Bonus{Balance: 50, WageringRequirement: 100}
+	if w.Balance != 100 || w.Bonus != want {
+		t.Errorf("after rollback got real %d, bonus %+v, want 100, %+v", w.Balance, w.Bonus, want)
+	}
+
+	spent := &Wallet{Balance: 100, Status: WalletStatusActive}
+	if err := spent.GrantBonus(50, 10, nil); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+	bet = newTx(0, 10)
+	if err := spent.WithdrawAndDeposit(bet, SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	converted = spent.Bonus
+	spent.ConvertBonus()
+	bet.ConvertedBonus = &converted
+	if err := spent.WithdrawAndDeposit(newTx(0, 140), SpendRealFirst); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if _, err := spent.Rollback(bet, RollbackPolicyReject); !errors.Is(err, ErrNotEnoughMoney) {
+		t.Errorf("Rollback() of a spent conversion error = %v, want %v", err, ErrNotEnoughMoney)
+	}
+}
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"net/url"
+	"time"
+)
+
+// WebhookSubscription sends the events of the listed types to the URL of an operator backend,
+// signed with the secret.
+type WebhookSubscription struct {
+	ID         int64
+	Operator   string
+	URL        string
+	EventTypes []EventType
+	Secret     string
+	Active     bool
+	CreatedAt  time.Time
+}
+
+func (s *WebhookSubscription) Validate() error {
+	if s.Operator == "" || s.Secret == "" || len(s.EventTypes) == 0 {
+		return ErrInvalidWebhookSubscription
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookSubscription
+	}
+
+	for _, t := range s.EventTypes {
+		if !t.Valid() {
+			return ErrInvalidWebhookSubscription
+		}
+	}
+
+	return nil
+}
+
+func (s *WebhookSubscription) Matches(eventType EventType) bool {
+	if !s.Active {
+		return false
+	}
+
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "dead"
+)
+
+func (s WebhookDeliveryStatus) Valid() bool {
+	return s == WebhookDeliveryStatusPending || s == WebhookDeliveryStatusDelivered || s == WebhookDeliveryStatusDead
+}
+
+// WebhookDelivery is an event on its way to a subscription. Payload is the request body.
+// ReplayOf is the delivery a replay queued the event once more for.
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        int64
+	EventType      EventType
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+	ReplayOf       *int64
+}
+
+// WebhookRetry describes how failed deliveries are retried.
+type WebhookRetry struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff is the delay after the failed attempt, doubling from BaseDelay up to MaxDelay.
+func (r WebhookRetry) Backoff(attempt int) time.Duration {
+	delay := r.BaseDelay
+	for i := 1; i < attempt && delay < r.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > r.MaxDelay {
+		return r.MaxDelay
+	}
+	return delay
+}
+
+// Claim leases the delivery to a sender until now plus lease, when it is due again should the sender
+// stop before recording the attempt. The lease is kept to the microseconds postgres stores.
+func (d *WebhookDelivery) Claim(now time.Time, lease time.Duration) {
+	d.NextAttemptAt = now.Add(lease).Truncate(time.Microsecond)
+}
+
+// ClaimedAs reports whether the delivery is still pending under the claim, no other sender took it over
+// when the lease ran out.
+func (d *WebhookDelivery) ClaimedAs(claim *WebhookDelivery) bool {
+	return d.Status == WebhookDeliveryStatusPending && d.Attempts == claim.Attempts &&
+		d.NextAttemptAt.Equal(claim.NextAttemptAt)
+}
+
+func (d *WebhookDelivery) Delivered(now time.Time) {
+	d.Attempts++
+	d.Status = WebhookDeliveryStatusDelivered
+	d.LastError = ""
+	d.DeliveredAt = &now
+}
+
+// Failed records a failed attempt and schedules the next one. It reports whether the delivery ran out
+// of attempts and is dead.
+func (d *WebhookDelivery) Failed(cause error, now time.Time, retry WebhookRetry) bool {
+	d.Attempts++
+	d.LastError = cause.Error()
+
+	if d.Attempts >= retry.MaxAttempts {
+		d.Status = WebhookDeliveryStatusDead
+		return true
+	}
+
+	d.NextAttemptAt = now.Add(retry.Backoff(d.Attempts))
+	return false
+}
+
+// Replay returns a new pending delivery of the same event.
+func (d *WebhookDelivery) Replay(now time.Time) *WebhookDelivery {
+	replayOf := d.ID
+	return &WebhookDelivery{
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         WebhookDeliveryStatusPending,
+		NextAttemptAt:  now,
+		ReplayOf:       &replayOf,
+	}
+}
+
+// WebhookDeadLetter keeps a delivery which ran out of attempts until it is replayed.
+type WebhookDeadLetter struct {
+	ID             int64
+	DeliveryID     int64
+	SubscriptionID int64
+	EventType      EventType
+	Attempts       int
+	LastError      string
+	CreatedAt      time.Time
+	ReplayedAt     *time.Time
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWebhookRetry_Backoff(t *testing.T) {
+	t.Parallel()
+	retry := WebhookRetry{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := retry.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestWebhookDelivery_Failed(t *testing.T) {
+	t.Parallel()
+	retry := WebhookRetry{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	now := time.Now()
+	d := &WebhookDelivery{Status: WebhookDeliveryStatusPending}
+
+	if d.Failed(errors.New("timeout"), now, retry) {
+		t.Fatalf("Failed() after the first attempt = dead")
+	}
+	if d.Status != WebhookDeliveryStatusPending || !d.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("delivery = %s next at %s, want pending next at %s", d.Status, d.NextAttemptAt, now.Add(time.Minute))
+	}
+
+	if !d.Failed(errors.New("timeout"), now, retry) {
+		t.Fatalf("Failed() after the last attempt = not dead")
+	}
+	if d.Status != WebhookDeliveryStatusDead || d.Attempts != 2 || d.LastError != "timeout" {
+		t.Errorf("delivery = %+v, want dead after 2 attempts", d)
+	}
+}
+
+func TestWebhookDelivery_Claim(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	claim := &WebhookDelivery{ID: 1, Status: WebhookDeliveryStatusPending, Attempts: 1}
+	claim.Claim(now, time.Minute)
+	if !claim.NextAttemptAt.Equal(now.Add(time.Minute).Truncate(time.Microsecond)) {
+		t.Errorf("claimed until %s, want %s", claim.NextAttemptAt, now.Add(time.Minute))
+	}
+
+	stored := *claim
+	if !stored.ClaimedAs(claim) {
+		t.Errorf("ClaimedAs() of the stored claim = false")
+	}
+
+	takenOver := stored
+	takenOver.Claim(now.Add(2*time.Minute), time.Minute)
+	recorded := stored
+	recorded.Delivered(now)
+	for name, d := range map[string]WebhookDelivery{"taken over": takenOver, "recorded": recorded} {
+		if d.ClaimedAs(claim) {
+			t.Errorf("ClaimedAs() of the %s delivery = true", name)
+		}
+	}
+}
+
+func TestWebhookSubscription_Validate(t *testing.T) {
+	t.Parallel()
+	valid := WebhookSubscription{
+		Operator:   "op",
+		URL:        "https://operator.example/hooks",
+		EventTypes: []EventType{EventTypeBalanceChanged},
+		Secret:     "s",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(s *WebhookSubscription)
+		valid  bool
+	}{
+		{name: "valid", modify: func(s *WebhookSubscription) {}, valid: true},
+		{name: "no secret", modify: func(s *WebhookSubscription) { s.Secret = "" }},
+		{name: "relative url", modify: func(s *WebhookSubscription) { s.URL = "/hooks" }},
+		{name: "unknown event", modify: func(s *WebhookSubscription) { s.EventTypes = []EventType{"wallet_opened"} }},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := valid
+			tt.modify(&s)
+			if err := s.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() error = %v, valid %v", err, tt.valid)
+			}
+		})
+	}
+}
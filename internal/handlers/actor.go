@@ -0,0 +1,37 @@
+package handlers
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor returns ctx carrying the actor authenticated by the admin API.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// requestActor returns the actor a request acts as. It is the authenticated actor when the admin API
+// authenticates them, else the actor named by the request is trusted.
+func requestActor(ctx context.Context, named string) (string, error) {
+	if authenticated, ok := ctx.Value(actorKey{}).(string); ok {
+		if named != "" && named != authenticated {
+			return "", NewError(ErrInvalidParams, "request names another actor than the authenticated one")
+		}
+		return authenticated, nil
+	}
+
+	if named == "" {
+		return "", NewError(ErrInvalidParams, "actor is required")
+	}
+
+	return named, nil
+}
+
+// reviewActor returns the authenticated actor of a maker-checker request. The actor named by the request
+// can't be trusted there, so adjustments are refused while the admin API doesn't authenticate actors.
+func reviewActor(ctx context.Context, named string) (string, error) {
+	if _, ok := ctx.Value(actorKey{}).(string); !ok {
+		return "", NewError(ErrUnauthorized, "adjustments need an actor authenticated by an admin_auth token")
+	}
+
+	return requestActor(ctx, named)
+}
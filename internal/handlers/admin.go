@@ -0,0 +1,539 @@
+package handlers
+
+import (
+	"context"
+
+	"mascot/internal/domain"
+	"mascot/internal/services"
+)
+
+// AdminHandler serves back office methods which must not be exposed to game providers.
+type AdminHandler struct {
+	walletService     *services.Wallet
+	freeRoundsService *services.FreeRounds
+	jackpotService    *services.Jackpot
+	ledgerService     *services.Ledger
+	adjustmentService *services.Adjustment
+	webhookService    *services.Webhooks
+}
+
+func NewAdminHandler(
+	walletService *services.Wallet,
+	freeRoundsService *services.FreeRounds,
+	jackpotService *services.Jackpot,
+	ledgerService *services.Ledger,
+	adjustmentService *services.Adjustment,
+	webhookService *services.Webhooks,
+) *AdminHandler {
+	return &AdminHandler{
+		walletService:     walletService,
+		freeRoundsService: freeRoundsService,
+		jackpotService:    jackpotService,
+		ledgerService:     ledgerService,
+		adjustmentService: adjustmentService,
+		webhookService:    webhookService,
+	}
+}
+
+func (h *AdminHandler) CreateWallet(ctx context.Context, req *CreateWalletRequest) (*CreateWalletResponse, error) {
+	wallet, err := h.walletService.CreateWallet(ctx, req.PlayerName, req.Currency, req.Operator, req.Balance)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return &CreateWalletResponse{
+		PlayerName:         wallet.UserName,
+		Currency:           wallet.Currency,
+		Operator:           wallet.Operator,
+		GetBalanceResponse: newGetBalanceResponse(wallet.Balances()),
+	}, nil
+}
+
+func (h *AdminHandler) SetWalletStatus(ctx context.Context, req *SetWalletStatusRequest) (*WalletStatusChange, error) {
+	actor, err := requestActor(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := h.walletService.ChangeStatus(ctx, req.PlayerName, domain.WalletStatus(req.Status), actor, req.Reason)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newWalletStatusChange(change), nil
+}
+
+func (h *AdminHandler) GetWalletStatusHistory(ctx context.Context, req *GetWalletStatusHistoryRequest) (*GetWalletStatusHistoryResponse, error) {
+	changes, err := h.walletService.GetStatusHistory(ctx, req.PlayerName)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetWalletStatusHistoryResponse{Changes: make([]*WalletStatusChange, 0, len(changes))}
+	for _, change := range changes {
+		resp.Changes = append(resp.Changes, newWalletStatusChange(change))
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) SetWalletRollbackPolicy(ctx context.Context, req *SetWalletRollbackPolicyRequest) error {
+	err := h.walletService.SetRollbackPolicy(ctx, req.PlayerName, domain.RollbackPolicy(req.Policy))
+	if err != nil {
+		return MapDomainToTransportError(err)
+	}
+
+	return nil
+}
+
+func (h *AdminHandler) GetDebtReport(ctx context.Context) (*GetDebtReportResponse, error) {
+	entries, err := h.walletService.GetDebtReport(ctx)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetDebtReportResponse{
+		Debts:       make([]*Debt, 0, len(entries)),
+		Outstanding: make(map[string]int64),
+	}
+	for _, e := range entries {
+		resp.Debts = append(resp.Debts, &Debt{
+			PlayerName:      e.PlayerName,
+			Currency:        e.Currency,
+			Debt:            e.Debt,
+			NegativeBalance: e.NegativeBalance,
+			Outstanding:     e.Outstanding(),
+		})
+		resp.Outstanding[e.Currency] += e.Outstanding()
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GrantBonus(ctx context.Context, req *GrantBonusRequest) (*GrantBonusResponse, error) {
+	grant, err := h.walletService.GrantBonus(ctx, req.PlayerName, req.Amount, req.WageringRequirement, req.ExpiresAt)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return &GrantBonusResponse{
+		TransactionID: grant.ID,
+		NewBalance:    *grant.BalanceAfterCommit,
+	}, nil
+}
+
+func (h *AdminHandler) GetRound(ctx context.Context, req *GetRoundRequest) (*GetRoundResponse, error) {
+	round, transactions, err := h.walletService.GetRound(ctx, req.PlayerName, req.GameID, req.GameRoundRef)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetRoundResponse{
+		Round:        newRound(round),
+		Transactions: make([]*Transaction, 0, len(transactions)),
+	}
+	for _, tx := range transactions {
+		resp.Transactions = append(resp.Transactions, NewTransaction(tx))
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GrantFreeRounds(ctx context.Context, req *GrantFreeRoundsRequest) (*FreeRounds, error) {
+	freeRounds := &domain.FreeRounds{
+		Ref:                req.FreeRoundsRef,
+		PlayerName:         req.PlayerName,
+		GameID:             req.GameID,
+		Campaign:           req.Campaign,
+		Count:              req.Count,
+		BetValue:           req.BetValue,
+		WageringMultiplier: req.WageringMultiplier,
+		ExpiresAt:          req.ExpiresAt,
+	}
+
+	if err := h.freeRoundsService.Grant(ctx, freeRounds); err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newFreeRounds(freeRounds), nil
+}
+
+func (h *AdminHandler) CancelFreeRounds(ctx context.Context, req *CancelFreeRoundsRequest) (*FreeRounds, error) {
+	freeRounds, err := h.freeRoundsService.Cancel(ctx, req.FreeRoundsRef)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newFreeRounds(freeRounds), nil
+}
+
+func (h *AdminHandler) GetPlayerFreeRounds(ctx context.Context, req *GetPlayerFreeRoundsRequest) (*GetPlayerFreeRoundsResponse, error) {
+	grants, err := h.freeRoundsService.GetPlayerFreeRounds(ctx, req.PlayerName)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetPlayerFreeRoundsResponse{FreeRounds: make([]*FreeRounds, 0, len(grants))}
+	for _, freeRounds := range grants {
+		resp.FreeRounds = append(resp.FreeRounds, newFreeRounds(freeRounds))
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GetFreeRoundsCampaignReport(
+	ctx context.Context,
+	req *GetFreeRoundsCampaignReportRequest,
+) (*FreeRoundsCampaignReport, error) {
+	report, err := h.freeRoundsService.GetCampaignReport(ctx, req.Campaign)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return &FreeRoundsCampaignReport{
+		Campaign:        report.Campaign,
+		Grants:          report.Grants,
+		Players:         report.Players,
+		RoundsGranted:   report.RoundsGranted,
+		RoundsUsed:      report.RoundsUsed,
+		RoundsRemaining: report.RoundsRemaining,
+		TotalBetValue:   report.TotalBetValue,
+		TotalWin:        report.TotalWin,
+	}, nil
+}
+
+func (h *AdminHandler) CreateJackpotPool(ctx context.Context, req *CreateJackpotPoolRequest) (*JackpotPool, error) {
+	pool := &domain.JackpotPool{Name: req.Pool, Currency: req.Currency, Balance: req.Seed}
+	if err := h.jackpotService.CreatePool(ctx, pool); err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newJackpotPool(pool), nil
+}
+
+func (h *AdminHandler) SetJackpotContribution(ctx context.Context, req *SetJackpotContributionRequest) (*JackpotContribution, error) {
+	c, err := h.jackpotService.SetContribution(ctx, req.Pool, req.GameID, req.RateBasisPoints)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return &JackpotContribution{
+		Pool:            c.PoolName,
+		GameID:          c.GameID,
+		Currency:        c.Currency,
+		RateBasisPoints: c.RateBasisPoints,
+	}, nil
+}
+
+func (h *AdminHandler) GetJackpotPools(ctx context.Context) (*GetJackpotPoolsResponse, error) {
+	pools, err := h.jackpotService.GetPools(ctx)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetJackpotPoolsResponse{
+		Pools:     make([]*JackpotPool, 0, len(pools)),
+		Liability: make(map[string]int64),
+	}
+	for _, pool := range pools {
+		resp.Pools = append(resp.Pools, newJackpotPool(pool))
+		resp.Liability[pool.Currency] += pool.Balance
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GetJackpotPoolLedger(ctx context.Context, req *GetJackpotPoolLedgerRequest) (*GetJackpotPoolLedgerResponse, error) {
+	if req.Limit == 0 {
+		req.Limit = defaultPageLimit
+	}
+
+	entries, err := h.jackpotService.GetPoolLedger(ctx, req.Pool, req.BeforeID, req.Limit)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetJackpotPoolLedgerResponse{Entries: make([]*JackpotLedgerEntry, 0, len(entries))}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, &JackpotLedgerEntry{
+			ID:            e.ID,
+			Pool:          e.PoolName,
+			TransactionID: e.TransactionID,
+			Kind:          string(e.Kind),
+			Amount:        e.Amount,
+			BalanceAfter:  e.BalanceAfter,
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GetTransactionHistory(ctx context.Context, req *AdminGetTransactionHistoryRequest) (*GetTransactionHistoryResponse, error) {
+	return getTransactionHistory(ctx, h.walletService, (*GetTransactionHistoryRequest)(req))
+}
+
+func (h *AdminHandler) CheckLedger(ctx context.Context) (*CheckLedgerResponse, error) {
+	check, err := h.ledgerService.Check(ctx)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &CheckLedgerResponse{
+		OK:         check.OK(),
+		CheckedAt:  check.CheckedAt,
+		EntrySets:  check.EntrySets,
+		Unbalanced: make([]*UnbalancedEntrySet, 0, len(check.Unbalanced)),
+		Mismatches: make([]*ProjectionMismatch, 0, len(check.Mismatches)),
+	}
+	for _, set := range check.Unbalanced {
+		resp.Unbalanced = append(resp.Unbalanced, &UnbalancedEntrySet{SetID: set.SetID, Sum: set.Sum})
+	}
+	for _, m := range check.Mismatches {
+		resp.Mismatches = append(resp.Mismatches, &ProjectionMismatch{
+			Account:    m.Account,
+			Projection: m.Projection,
+			Ledger:     m.Ledger,
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GetLedgerAccount(ctx context.Context, req *GetLedgerAccountRequest) (*GetLedgerAccountResponse, error) {
+	if req.Limit == 0 {
+		req.Limit = defaultPageLimit
+	}
+
+	entries, err := h.ledgerService.GetAccountEntries(ctx, req.Account, req.BeforeID, req.Limit)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetLedgerAccountResponse{Entries: make([]*LedgerEntry, 0, len(entries))}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, &LedgerEntry{
+			ID:            e.ID,
+			SetID:         e.SetID,
+			TransactionID: e.TransactionID,
+			Kind:          e.Kind,
+			Account:       e.Account,
+			Currency:      e.Currency,
+			Amount:        e.Amount,
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) TransferFunds(ctx context.Context, req *TransferFundsRequest) (*TransferFundsResponse, error) {
+	transfer := &domain.Transfer{
+		Ref:        req.TransferRef,
+		FromPlayer: req.FromPlayer,
+		ToPlayer:   req.ToPlayer,
+		Currency:   req.Currency,
+		Amount:     req.Amount,
+	}
+
+	if err := h.walletService.Transfer(ctx, transfer); err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return &TransferFundsResponse{
+		TransferRef: transfer.Ref,
+		Out:         NewTransaction(transfer.Out),
+		In:          NewTransaction(transfer.In),
+	}, nil
+}
+
+func (h *AdminHandler) CreateAdjustment(ctx context.Context, req *CreateAdjustmentRequest) (*Adjustment, error) {
+	actor, err := reviewActor(ctx, req.Operator)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment := &domain.Adjustment{
+		PlayerName: req.PlayerName,
+		Currency:   req.Currency,
+		Amount:     req.Amount,
+		Reason:     domain.AdjustmentReason(req.Reason),
+		Comment:    req.Comment,
+		CreatedBy:  actor,
+	}
+
+	if err := h.adjustmentService.CreateAdjustment(ctx, adjustment); err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newAdjustment(adjustment), nil
+}
+
+func (h *AdminHandler) ApproveAdjustment(ctx context.Context, req *ReviewAdjustmentRequest) (*Adjustment, error) {
+	return h.reviewAdjustment(ctx, req, true)
+}
+
+func (h *AdminHandler) RejectAdjustment(ctx context.Context, req *ReviewAdjustmentRequest) (*Adjustment, error) {
+	return h.reviewAdjustment(ctx, req, false)
+}
+
+func (h *AdminHandler) reviewAdjustment(ctx context.Context, req *ReviewAdjustmentRequest, approve bool) (*Adjustment, error) {
+	actor, err := reviewActor(ctx, req.Operator)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment, err := h.adjustmentService.ReviewAdjustment(ctx, req.AdjustmentID, actor, req.Comment, approve)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newAdjustment(adjustment), nil
+}
+
+func (h *AdminHandler) GetPendingAdjustments(ctx context.Context) (*GetPendingAdjustmentsResponse, error) {
+	adjustments, err := h.adjustmentService.GetPendingAdjustments(ctx)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetPendingAdjustmentsResponse{Adjustments: make([]*Adjustment, 0, len(adjustments))}
+	for _, adjustment := range adjustments {
+		resp.Adjustments = append(resp.Adjustments, newAdjustment(adjustment))
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GetAdjustmentHistory(ctx context.Context, req *GetAdjustmentHistoryRequest) (*GetAdjustmentHistoryResponse, error) {
+	events, err := h.adjustmentService.GetAdjustmentEvents(ctx, req.AdjustmentID)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetAdjustmentHistoryResponse{Events: make([]*AdjustmentEvent, 0, len(events))}
+	for _, e := range events {
+		resp.Events = append(resp.Events, &AdjustmentEvent{
+			Status:    string(e.Status),
+			Actor:     e.Actor,
+			Comment:   e.Comment,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GetAdjustmentReport(ctx context.Context, req *GetAdjustmentReportRequest) (*GetAdjustmentReportResponse, error) {
+	report, err := h.adjustmentService.GetOperatorReport(ctx, req.From, req.To)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetAdjustmentReportResponse{Operators: make([]*OperatorAdjustments, 0, len(report))}
+	for _, o := range report {
+		resp.Operators = append(resp.Operators, &OperatorAdjustments{
+			Operator: o.Operator,
+			Currency: o.Currency,
+			Created:  o.Created,
+			Pending:  o.Pending,
+			Credited: o.Credited,
+			Debited:  o.Debited,
+			Approved: o.Approved,
+			Rejected: o.Rejected,
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) CreateWebhookSubscription(ctx context.Context, req *CreateWebhookSubscriptionRequest) (*WebhookSubscription, error) {
+	subscription := &domain.WebhookSubscription{
+		Operator: req.Operator,
+		URL:      req.URL,
+		Secret:   req.Secret,
+	}
+	for _, t := range req.EventTypes {
+		subscription.EventTypes = append(subscription.EventTypes, domain.EventType(t))
+	}
+
+	if err := h.webhookService.CreateSubscription(ctx, subscription); err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newWebhookSubscription(subscription), nil
+}
+
+func (h *AdminHandler) SetWebhookSubscriptionActive(ctx context.Context, req *SetWebhookSubscriptionActiveRequest) error {
+	if err := h.webhookService.SetSubscriptionActive(ctx, req.SubscriptionID, req.Active); err != nil {
+		return MapDomainToTransportError(err)
+	}
+
+	return nil
+}
+
+func (h *AdminHandler) GetWebhookSubscriptions(ctx context.Context, req *GetWebhookSubscriptionsRequest) (*GetWebhookSubscriptionsResponse, error) {
+	subscriptions, err := h.webhookService.GetSubscriptions(ctx, req.Operator)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetWebhookSubscriptionsResponse{Subscriptions: make([]*WebhookSubscription, 0, len(subscriptions))}
+	for _, subscription := range subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, newWebhookSubscription(subscription))
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GetWebhookDeliveries(ctx context.Context, req *GetWebhookDeliveriesRequest) (*GetWebhookDeliveriesResponse, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(
+		ctx, req.SubscriptionID, domain.WebhookDeliveryStatus(req.Status), req.BeforeID, limit,
+	)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetWebhookDeliveriesResponse{Deliveries: make([]*WebhookDelivery, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, newWebhookDelivery(delivery))
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) GetWebhookDeadLetters(ctx context.Context) (*GetWebhookDeadLettersResponse, error) {
+	letters, err := h.webhookService.GetDeadLetters(ctx)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetWebhookDeadLettersResponse{DeadLetters: make([]*WebhookDeadLetter, 0, len(letters))}
+	for _, l := range letters {
+		resp.DeadLetters = append(resp.DeadLetters, &WebhookDeadLetter{
+			ID:             l.ID,
+			DeliveryID:     l.DeliveryID,
+			SubscriptionID: l.SubscriptionID,
+			EventType:      string(l.EventType),
+			Attempts:       l.Attempts,
+			LastError:      l.LastError,
+			CreatedAt:      l.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) ReplayWebhookDelivery(ctx context.Context, req *ReplayWebhookDeliveryRequest) (*WebhookDelivery, error) {
+	delivery, err := h.webhookService.ReplayDelivery(ctx, req.DeliveryID)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newWebhookDelivery(delivery), nil
+}
@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+const defaultPageLimit = 100
+
+// CreateWalletRequest creates an active wallet of the operator, whose webhook subscriptions receive its events.
+type CreateWalletRequest struct {
+	PlayerName string `json:"playerName" validate:"required"`
+	Currency   string `json:"currency" validate:"required"`
+	Operator   string `json:"operator" validate:"required"`
+	Balance    int64  `json:"balance" validate:"gte=0"`
+}
+
+type CreateWalletResponse struct {
+	PlayerName string `json:"playerName"`
+	Currency   string `json:"currency"`
+	Operator   string `json:"operator"`
+	*GetBalanceResponse
+}
+
+type SetWalletStatusRequest struct {
+	PlayerName string `json:"playerName" validate:"required"`
+	Status     string `json:"status" validate:"required,oneof=active suspended frozen closed"`
+	// Actor is required unless the admin API authenticates actors, then it must name the authenticated one.
+	Actor  string `json:"actor"`
+	Reason string `json:"reason" validate:"required"`
+}
+
+// SetWalletRollbackPolicyRequest sets the policy of the wallet, an empty policy falls back to the currency one.
+type SetWalletRollbackPolicyRequest struct {
+	PlayerName string `json:"playerName" validate:"required"`
+	Policy     string `json:"policy" validate:"omitempty,oneof=reject negative_balance debt"`
+}
+
+type GetDebtReportResponse struct {
+	Debts []*Debt `json:"debts"`
+	// Outstanding is the sum of outstanding debts by currency.
+	Outstanding map[string]int64 `json:"outstanding"`
+}
+
+type Debt struct {
+	PlayerName      string `json:"playerName"`
+	Currency        string `json:"currency"`
+	Debt            int64  `json:"debt"`
+	NegativeBalance int64  `json:"negativeBalance"`
+	Outstanding     int64  `json:"outstanding"`
+}
+
+type GetWalletStatusHistoryRequest struct {
+	PlayerName string `json:"playerName" validate:"required"`
+}
+
+type GetWalletStatusHistoryResponse struct {
+	Changes []*WalletStatusChange `json:"changes"`
+}
+
+type WalletStatusChange struct {
+	PlayerName string    `json:"playerName"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func newWalletStatusChange(change *domain.WalletStatusChange) *WalletStatusChange {
+	return &WalletStatusChange{
+		PlayerName: change.PlayerName,
+		From:       string(change.From),
+		To:         string(change.To),
+		Actor:      change.Actor,
+		Reason:     change.Reason,
+		CreatedAt:  change.CreatedAt,
+	}
+}
+
+type GrantBonusRequest struct {
+	PlayerName          string     `json:"playerName" validate:"required"`
+	Amount              int64      `json:"amount" validate:"gt=0"`
+	WageringRequirement int64      `json:"wageringRequirement" validate:"gte=0"`
+	ExpiresAt           *time.Time `json:"expiresAt"`
+}
+
+type GrantBonusResponse struct {
+	TransactionID string `json:"transactionId"`
+	NewBalance    int64  `json:"newBalance"`
+}
+
+type GetRoundRequest struct {
+	PlayerName   string `json:"playerName" validate:"required"`
+	GameID       string `json:"gameId"`
+	GameRoundRef string `json:"gameRoundRef" validate:"required"`
+}
+
+type GetRoundResponse struct {
+	Round        *Round         `json:"round"`
+	Transactions []*Transaction `json:"transactions"`
+}
+
+type Round struct {
+	PlayerName   string     `json:"playerName"`
+	GameID       string     `json:"gameId"`
+	GameRoundRef string     `json:"gameRoundRef"`
+	Currency     string     `json:"currency"`
+	TotalBet     int64      `json:"totalBet"`
+	TotalWin     int64      `json:"totalWin"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+}
+
+func newRound(round *domain.Round) *Round {
+	return &Round{
+		PlayerName:   round.PlayerName,
+		GameID:       round.GameID,
+		GameRoundRef: round.RoundRef,
+		Currency:     round.Currency,
+		TotalBet:     round.TotalBet,
+		TotalWin:     round.TotalWin,
+		Status:       string(round.Status),
+		CreatedAt:    round.CreatedAt,
+		UpdatedAt:    round.UpdatedAt,
+		FinishedAt:   round.FinishedAt,
+	}
+}
+
+type GrantFreeRoundsRequest struct {
+	FreeRoundsRef      string    `json:"freeRoundsRef" validate:"required"`
+	PlayerName         string    `json:"playerName" validate:"required"`
+	GameID             string    `json:"gameId" validate:"required"`
+	Campaign           string    `json:"campaign"`
+	Count              int64     `json:"count" validate:"gt=0"`
+	BetValue           int64     `json:"betValue" validate:"gt=0"`
+	WageringMultiplier int64     `json:"wageringMultiplier" validate:"gte=0"`
+	ExpiresAt          time.Time `json:"expiresAt" validate:"required"`
+}
+
+type CancelFreeRoundsRequest struct {
+	FreeRoundsRef string `json:"freeRoundsRef" validate:"required"`
+}
+
+type GetPlayerFreeRoundsRequest struct {
+	PlayerName string `json:"playerName" validate:"required"`
+}
+
+type GetPlayerFreeRoundsResponse struct {
+	FreeRounds []*FreeRounds `json:"freeRounds"`
+}
+
+type FreeRounds struct {
+	FreeRoundsRef      string    `json:"freeRoundsRef"`
+	PlayerName         string    `json:"playerName"`
+	GameID             string    `json:"gameId"`
+	Campaign           string    `json:"campaign"`
+	Currency           string    `json:"currency"`
+	Count              int64     `json:"count"`
+	Remaining          int64     `json:"remaining"`
+	BetValue           int64     `json:"betValue"`
+	WageringMultiplier int64     `json:"wageringMultiplier"`
+	TotalWin           int64     `json:"totalWin"`
+	Status             string    `json:"status"`
+	ExpiresAt          time.Time `json:"expiresAt"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+func newFreeRounds(f *domain.FreeRounds) *FreeRounds {
+	return &FreeRounds{
+		FreeRoundsRef:      f.Ref,
+		PlayerName:         f.PlayerName,
+		GameID:             f.GameID,
+		Campaign:           f.Campaign,
+		Currency:           f.Currency,
+		Count:              f.Count,
+		Remaining:          f.Remaining,
+		BetValue:           f.BetValue,
+		WageringMultiplier: f.WageringMultiplier,
+		TotalWin:           f.TotalWin,
+		Status:             string(f.Status),
+		ExpiresAt:          f.ExpiresAt,
+		CreatedAt:          f.CreatedAt,
+	}
+}
+
+type GetFreeRoundsCampaignReportRequest struct {
+	Campaign string `json:"campaign" validate:"required"`
+}
+
+type FreeRoundsCampaignReport struct {
+	Campaign        string `json:"campaign"`
+	Grants          int64  `json:"grants"`
+	Players         int64  `json:"players"`
+	RoundsGranted   int64  `json:"roundsGranted"`
+	RoundsUsed      int64  `json:"roundsUsed"`
+	RoundsRemaining int64  `json:"roundsRemaining"`
+	TotalBetValue   int64  `json:"totalBetValue"`
+	TotalWin        int64  `json:"totalWin"`
+}
+
+type CreateJackpotPoolRequest struct {
+	Pool     string `json:"pool" validate:"required"`
+	Currency string `json:"currency" validate:"required"`
+	Seed     int64  `json:"seed" validate:"gte=0"`
+}
+
+type JackpotPool struct {
+	Pool      string    `json:"pool"`
+	Currency  string    `json:"currency"`
+	Balance   int64     `json:"balance"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func newJackpotPool(pool *domain.JackpotPool) *JackpotPool {
+	return &JackpotPool{
+		Pool:      pool.Name,
+		Currency:  pool.Currency,
+		Balance:   pool.Balance,
+		UpdatedAt: pool.UpdatedAt,
+	}
+}
+
+type SetJackpotContributionRequest struct {
+	Pool            string `json:"pool" validate:"required"`
+	GameID          string `json:"gameId"`
+	RateBasisPoints int64  `json:"rateBasisPoints" validate:"gte=0,lte=10000"`
+}
+
+type JackpotContribution struct {
+	Pool            string `json:"pool"`
+	GameID          string `json:"gameId"`
+	Currency        string `json:"currency"`
+	RateBasisPoints int64  `json:"rateBasisPoints"`
+}
+
+type GetJackpotPoolsResponse struct {
+	Pools []*JackpotPool `json:"pools"`
+	// Liability is the sum of pool balances by currency.
+	Liability map[string]int64 `json:"liability"`
+}
+
+type GetJackpotPoolLedgerRequest struct {
+	Pool     string `json:"pool" validate:"required"`
+	BeforeID int64  `json:"beforeId" validate:"gte=0"`
+	Limit    int    `json:"limit" validate:"gte=0,lte=1000"`
+}
+
+type GetJackpotPoolLedgerResponse struct {
+	Entries []*JackpotLedgerEntry `json:"entries"`
+}
+
+type JackpotLedgerEntry struct {
+	ID            int64     `json:"id"`
+	Pool          string    `json:"pool"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	Kind          string    `json:"kind"`
+	Amount        int64     `json:"amount"`
+	BalanceAfter  int64     `json:"balanceAfter"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// AdminGetTransactionHistoryRequest is GetTransactionHistoryRequest without the player scope.
+type AdminGetTransactionHistoryRequest struct {
+	PlayerName   string     `json:"playerName"`
+	Currency     string     `json:"currency"`
+	From         *time.Time `json:"from"`
+	To           *time.Time `json:"to"`
+	GameID       string     `json:"gameId"`
+	GameRoundRef string     `json:"gameRoundRef"`
+	Status       string     `json:"status" validate:"omitempty,oneof=committed rolled_back"`
+	Cursor       string     `json:"cursor"`
+	Limit        int        `json:"limit" validate:"gte=0,lte=1000"`
+}
+
+type CheckLedgerResponse struct {
+	OK         bool                  `json:"ok"`
+	CheckedAt  time.Time             `json:"checkedAt"`
+	EntrySets  int64                 `json:"entrySets"`
+	Unbalanced []*UnbalancedEntrySet `json:"unbalanced"`
+	Mismatches []*ProjectionMismatch `json:"mismatches"`
+}
+
+type UnbalancedEntrySet struct {
+	SetID string `json:"setId"`
+	Sum   int64  `json:"sum"`
+}
+
+// ProjectionMismatch is a stored balance which differs from its ledger account.
+type ProjectionMismatch struct {
+	Account    string `json:"account"`
+	Projection int64  `json:"projection"`
+	Ledger     int64  `json:"ledger"`
+}
+
+type GetLedgerAccountRequest struct {
+	Account  string `json:"account" validate:"required"`
+	BeforeID int64  `json:"beforeId" validate:"gte=0"`
+	Limit    int    `json:"limit" validate:"gte=0,lte=1000"`
+}
+
+type GetLedgerAccountResponse struct {
+	Entries []*LedgerEntry `json:"entries"`
+}
+
+type LedgerEntry struct {
+	ID            int64     `json:"id"`
+	SetID         string    `json:"setId"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	Kind          string    `json:"kind"`
+	Account       string    `json:"account"`
+	Currency      string    `json:"currency"`
+	Amount        int64     `json:"amount"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type TransferFundsRequest struct {
+	TransferRef string `json:"transferRef" validate:"required"`
+	FromPlayer  string `json:"fromPlayer" validate:"required"`
+	ToPlayer    string `json:"toPlayer" validate:"required,nefield=FromPlayer"`
+	Currency    string `json:"currency" validate:"required"`
+	Amount      int64  `json:"amount" validate:"gt=0"`
+}
+
+// TransferFundsResponse holds the linked transactions of the transfer.
+type TransferFundsResponse struct {
+	TransferRef string       `json:"transferRef"`
+	Out         *Transaction `json:"out"`
+	In          *Transaction `json:"in"`
+}
+
+// CreateAdjustmentRequest credits the real balance by a positive amount and debits it by a negative one.
+type CreateAdjustmentRequest struct {
+	PlayerName string `json:"playerName" validate:"required"`
+	Currency   string `json:"currency"`
+	Amount     int64  `json:"amount" validate:"ne=0"`
+	Reason     string `json:"reason" validate:"required"`
+	Comment    string `json:"comment" validate:"required"`
+	// Operator is the actor making the adjustment. Adjustments need an authenticated actor, Operator may
+	// only name them.
+	Operator string `json:"operator"`
+}
+
+type ReviewAdjustmentRequest struct {
+	AdjustmentID int64 `json:"adjustmentId" validate:"gt=0"`
+	// Operator is the actor reviewing the adjustment, see CreateAdjustmentRequest.Operator.
+	Operator string `json:"operator"`
+	Comment  string `json:"comment"`
+}
+
+type Adjustment struct {
+	ID            int64      `json:"id"`
+	PlayerName    string     `json:"playerName"`
+	Currency      string     `json:"currency"`
+	Amount        int64      `json:"amount"`
+	Reason        string     `json:"reason"`
+	Comment       string     `json:"comment"`
+	CreatedBy     string     `json:"createdBy"`
+	ReviewedBy    string     `json:"reviewedBy,omitempty"`
+	ReviewComment string     `json:"reviewComment,omitempty"`
+	Status        string     `json:"status"`
+	TransactionID string     `json:"transactionId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ReviewedAt    *time.Time `json:"reviewedAt,omitempty"`
+}
+
+func newAdjustment(a *domain.Adjustment) *Adjustment {
+	return &Adjustment{
+		ID:            a.ID,
+		PlayerName:    a.PlayerName,
+		Currency:      a.Currency,
+		Amount:        a.Amount,
+		Reason:        string(a.Reason),
+		Comment:       a.Comment,
+		CreatedBy:     a.CreatedBy,
+		ReviewedBy:    a.ReviewedBy,
+		ReviewComment: a.ReviewComment,
+		Status:        string(a.Status),
+		TransactionID: a.TransactionID,
+		CreatedAt:     a.CreatedAt,
+		ReviewedAt:    a.ReviewedAt,
+	}
+}
+
+type GetPendingAdjustmentsResponse struct {
+	Adjustments []*Adjustment `json:"adjustments"`
+}
+
+type GetAdjustmentHistoryRequest struct {
+	AdjustmentID int64 `json:"adjustmentId" validate:"gt=0"`
+}
+
+type GetAdjustmentHistoryResponse struct {
+	Events []*AdjustmentEvent `json:"events"`
+}
+
+type AdjustmentEvent struct {
+	Status    string    `json:"status"`
+	Actor     string    `json:"actor"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type GetAdjustmentReportRequest struct {
+	From time.Time `json:"from" validate:"required"`
+	To   time.Time `json:"to" validate:"required,gtfield=From"`
+}
+
+type GetAdjustmentReportResponse struct {
+	Operators []*OperatorAdjustments `json:"operators"`
+}
+
+type OperatorAdjustments struct {
+	Operator string `json:"operator"`
+	Currency string `json:"currency"`
+	Created  int64  `json:"created"`
+	Pending  int64  `json:"pending"`
+	Credited int64  `json:"credited"`
+	Debited  int64  `json:"debited"`
+	Approved int64  `json:"approved"`
+	Rejected int64  `json:"rejected"`
+}
+
+type CreateWebhookSubscriptionRequest struct {
+	Operator   string   `json:"operator" validate:"required"`
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"eventTypes" validate:"required,min=1"`
+	Secret     string   `json:"secret" validate:"required"`
+}
+
+type SetWebhookSubscriptionActiveRequest struct {
+	SubscriptionID int64 `json:"subscriptionId" validate:"gt=0"`
+	Active         bool  `json:"active"`
+}
+
+type GetWebhookSubscriptionsRequest struct {
+	Operator string `json:"operator"`
+}
+
+type GetWebhookSubscriptionsResponse struct {
+	Subscriptions []*WebhookSubscription `json:"subscriptions"`
+}
+
+// WebhookSubscription never carries the secret back.
+type WebhookSubscription struct {
+	ID         int64     `json:"id"`
+	Operator   string    `json:"operator"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func newWebhookSubscription(s *domain.WebhookSubscription) *WebhookSubscription {
+	res := &WebhookSubscription{
+		ID:         s.ID,
+		Operator:   s.Operator,
+		URL:        s.URL,
+		EventTypes: make([]string, 0, len(s.EventTypes)),
+		Active:     s.Active,
+		CreatedAt:  s.CreatedAt,
+	}
+	for _, t := range s.EventTypes {
+		res.EventTypes = append(res.EventTypes, string(t))
+	}
+	return res
+}
+
+type GetWebhookDeliveriesRequest struct {
+	SubscriptionID int64  `json:"subscriptionId" validate:"gt=0"`
+	Status         string `json:"status"`
+	BeforeID       int64  `json:"beforeId" validate:"gte=0"`
+	Limit          int    `json:"limit" validate:"gte=0,lte=1000"`
+}
+
+type GetWebhookDeliveriesResponse struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscriptionId"`
+	EventID        int64           `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+	ReplayOf       *int64          `json:"replayOf,omitempty"`
+}
+
+func newWebhookDelivery(d *domain.WebhookDelivery) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      string(d.EventType),
+		Payload:        d.Payload,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+		ReplayOf:       d.ReplayOf,
+	}
+}
+
+type GetWebhookDeadLettersResponse struct {
+	DeadLetters []*WebhookDeadLetter `json:"deadLetters"`
+}
+
+type WebhookDeadLetter struct {
+	ID             int64     `json:"id"`
+	DeliveryID     int64     `json:"deliveryId"`
+	SubscriptionID int64     `json:"subscriptionId"`
+	EventType      string    `json:"eventType"`
+	Attempts       int       `json:"attempts"`
+	LastError      string    `json:"lastError"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type ReplayWebhookDeliveryRequest struct {
+	DeliveryID int64 `json:"deliveryId" validate:"gt=0"`
+}
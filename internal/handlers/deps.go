@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"context"
+
+	"mascot/internal/domain"
+)
+
+// WalletService is the wallet engine behind the seamless API, see services.Wallet.
+type WalletService interface {
+	GetBalance(ctx context.Context, playerName, currency string) (domain.Balance, error)
+	WithdrawAndDeposit(ctx context.Context, transaction *domain.Transaction) error
+	RollbackTransaction(ctx context.Context, transaction *domain.Transaction) error
+	GetTransactionHistory(ctx context.Context, filter domain.TransactionFilter) ([]*domain.Transaction, string, error)
+	ReserveFunds(ctx context.Context, reservation *domain.Reservation) (domain.Balance, error)
+	CommitReservation(ctx context.Context, playerName, ref string, amount int64) (*domain.Transaction, error)
+	ReleaseReservation(ctx context.Context, playerName, ref string) (domain.Balance, error)
+}
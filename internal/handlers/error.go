@@ -14,6 +14,7 @@ const (
 	ErrInvalidRequest     = -32600
 	ErrInternalError      = -32603
 	ErrDefaultServerError = -32000
+	ErrUnauthorized       = -32001
 
 	ErrNotEnoughMoneyCode          = 1
 	ErrIllegalCurrencyCode         = 2
@@ -21,6 +22,39 @@ const (
 	ErrNegativeWithdrawalCode      = 4
 	ErrSpendingBudgetExceeded      = 5
 	ErrTransactionIsRolledBackCode = 6
+	ErrWalletSuspendedCode         = 7
+	ErrWalletFrozenCode            = 8
+	ErrWalletClosedCode            = 9
+	ErrIllegalStatusTransitionCode = 10
+	ErrInvalidBonusCode            = 11
+	ErrRoundNotFoundCode           = 12
+	ErrRoundIsFinishedCode         = 13
+	ErrInvalidFreeRoundsCode       = 14
+	ErrFreeRoundsNotFoundCode      = 15
+	ErrFreeRoundsUnavailableCode   = 16
+	ErrJackpotPoolNotFoundCode     = 17
+	ErrInvalidJackpotCode          = 18
+	ErrJackpotPoolInsufficientCode = 19
+	ErrAmountOverflowCode          = 20
+	ErrBetLimitExceededCode        = 21
+	ErrWinLimitExceededCode        = 22
+	ErrBalanceLimitExceededCode    = 23
+	ErrInvalidFilterCode           = 24
+	ErrUnbalancedEntrySetCode      = 25
+	ErrIdempotencyConflictCode     = 26
+	ErrUnknownRollbackPolicyCode   = 27
+	ErrReservationNotFoundCode     = 28
+	ErrReservationUnavailableCode  = 29
+	ErrInvalidTransferCode         = 30
+	ErrInvalidAdjustmentCode       = 31
+	ErrAdjustmentNotFoundCode      = 32
+	ErrAdjustmentNotPendingCode    = 33
+	ErrAdjustmentSelfReviewCode    = 34
+	ErrInvalidWebhookCode          = 35
+	ErrWebhookNotFoundCode         = 36
+	ErrRollbackNotAllowedCode      = 37
+	ErrReplayUnavailableCode       = 38
+	ErrInvalidWalletCode           = 39
 )
 
 type Error struct {
@@ -42,8 +76,17 @@ func (e *Error) WithData(data interface{}) *Error {
 	return e
 }
 
+// IdempotencyConflictData lists the request fields which differ from the handled transaction.
+type IdempotencyConflictData struct {
+	Fields []string `json:"fields"`
+}
+
 func MapDomainToTransportError(err error) error {
+	var conflict *domain.IdempotencyConflict
 	switch {
+	case errors.As(err, &conflict):
+		return NewError(ErrIdempotencyConflictCode, err.Error()).
+			WithData(&IdempotencyConflictData{Fields: conflict.Fields})
 	case errors.Is(err, domain.ErrIllegalCurrency):
 		return NewError(ErrIllegalCurrencyCode, err.Error())
 	case errors.Is(err, domain.ErrNotEnoughMoney):
@@ -54,6 +97,85 @@ func MapDomainToTransportError(err error) error {
 		return NewError(ErrNegativeDepositCode, err.Error())
 	case errors.Is(err, domain.ErrTransactionIsRolledBack):
 		return NewError(ErrTransactionIsRolledBackCode, err.Error())
+	case errors.Is(err, domain.ErrWalletSuspended):
+		return NewError(ErrWalletSuspendedCode, err.Error())
+	case errors.Is(err, domain.ErrWalletFrozen):
+		return NewError(ErrWalletFrozenCode, err.Error())
+	case errors.Is(err, domain.ErrWalletClosed):
+		return NewError(ErrWalletClosedCode, err.Error())
+	case errors.Is(err, domain.ErrIllegalStatusTransition), errors.Is(err, domain.ErrUnknownWalletStatus):
+		return NewError(ErrIllegalStatusTransitionCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidBonus), errors.Is(err, domain.ErrInvalidBonusDeposit):
+		return NewError(ErrInvalidBonusCode, err.Error())
+	case errors.Is(err, domain.ErrRoundNotFound):
+		return NewError(ErrRoundNotFoundCode, err.Error())
+	case errors.Is(err, domain.ErrRoundIsFinished):
+		return NewError(ErrRoundIsFinishedCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidFreeRounds),
+		errors.Is(err, domain.ErrFreeRoundsExists),
+		errors.Is(err, domain.ErrFreeRoundWithdrawal):
+		return NewError(ErrInvalidFreeRoundsCode, err.Error())
+	case errors.Is(err, domain.ErrFreeRoundsNotFound):
+		return NewError(ErrFreeRoundsNotFoundCode, err.Error())
+	case errors.Is(err, domain.ErrFreeRoundsCancelled),
+		errors.Is(err, domain.ErrFreeRoundsGameMismatch),
+		errors.Is(err, domain.ErrFreeRoundsExpired),
+		errors.Is(err, domain.ErrFreeRoundsExhausted),
+		errors.Is(err, domain.ErrFreeRoundsRoundMismatch):
+		return NewError(ErrFreeRoundsUnavailableCode, err.Error())
+	case errors.Is(err, domain.ErrJackpotPoolNotFound):
+		return NewError(ErrJackpotPoolNotFoundCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidJackpotPayout),
+		errors.Is(err, domain.ErrInvalidJackpotPool),
+		errors.Is(err, domain.ErrJackpotPoolExists):
+		return NewError(ErrInvalidJackpotCode, err.Error())
+	case errors.Is(err, domain.ErrJackpotPoolInsufficient):
+		return NewError(ErrJackpotPoolInsufficientCode, err.Error())
+	case errors.Is(err, domain.ErrAmountOverflow):
+		return NewError(ErrAmountOverflowCode, err.Error())
+	case errors.Is(err, domain.ErrBetLimitExceeded):
+		return NewError(ErrBetLimitExceededCode, err.Error())
+	case errors.Is(err, domain.ErrWinLimitExceeded):
+		return NewError(ErrWinLimitExceededCode, err.Error())
+	case errors.Is(err, domain.ErrBalanceLimitExceeded):
+		return NewError(ErrBalanceLimitExceededCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidCursor), errors.Is(err, domain.ErrInvalidFilter):
+		return NewError(ErrInvalidFilterCode, err.Error())
+	case errors.Is(err, domain.ErrRollbackNotAllowed),
+		errors.Is(err, domain.ErrRollbackPlayerMismatch):
+		return NewError(ErrRollbackNotAllowedCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidWallet),
+		errors.Is(err, domain.ErrWalletExists):
+		return NewError(ErrInvalidWalletCode, err.Error())
+	case errors.Is(err, domain.ErrReplayUnavailable):
+		return NewError(ErrReplayUnavailableCode, err.Error())
+	case errors.Is(err, domain.ErrUnknownRollbackPolicy):
+		return NewError(ErrUnknownRollbackPolicyCode, err.Error())
+	case errors.Is(err, domain.ErrReservationNotFound):
+		return NewError(ErrReservationNotFoundCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidReservation),
+		errors.Is(err, domain.ErrReservationCommitted),
+		errors.Is(err, domain.ErrReservationReleased),
+		errors.Is(err, domain.ErrReservationExpired):
+		return NewError(ErrReservationUnavailableCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidTransfer):
+		return NewError(ErrInvalidTransferCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidAdjustment),
+		errors.Is(err, domain.ErrUnknownAdjustmentReason):
+		return NewError(ErrInvalidAdjustmentCode, err.Error())
+	case errors.Is(err, domain.ErrAdjustmentNotFound):
+		return NewError(ErrAdjustmentNotFoundCode, err.Error())
+	case errors.Is(err, domain.ErrAdjustmentNotPending):
+		return NewError(ErrAdjustmentNotPendingCode, err.Error())
+	case errors.Is(err, domain.ErrAdjustmentSelfReview):
+		return NewError(ErrAdjustmentSelfReviewCode, err.Error())
+	case errors.Is(err, domain.ErrInvalidWebhookSubscription):
+		return NewError(ErrInvalidWebhookCode, err.Error())
+	case errors.Is(err, domain.ErrWebhookSubscriptionNotFound),
+		errors.Is(err, domain.ErrWebhookDeliveryNotFound):
+		return NewError(ErrWebhookNotFoundCode, err.Error())
+	case errors.Is(err, domain.ErrUnbalancedEntrySet):
+		return NewError(ErrUnbalancedEntrySetCode, err.Error())
 	default:
 		return NewError(ErrDefaultServerError, err.Error())
 	}
@@ -4,14 +4,13 @@ import (
 	"context"
 
 	"mascot/internal/domain"
-	"mascot/internal/services"
 )
 
 type Handler struct {
-	walletService *services.Wallet
+	walletService WalletService
 }
 
-func NewHandler(walletService *services.Wallet) *Handler {
+func NewHandler(walletService WalletService) *Handler {
 	return &Handler{walletService: walletService}
 }
 
@@ -21,21 +20,63 @@ func (h *Handler) GetBalance(ctx context.Context, req *GetBalanceRequest) (*GetB
 		return nil, MapDomainToTransportError(err)
 	}
 
-	return &GetBalanceResponse{
-		Balance: balance,
-	}, nil
+	return newGetBalanceResponse(balance), nil
 }
 
 func (h *Handler) WithdrawAndDeposit(ctx context.Context, req *WithdrawAndDepositRequest) (*WithdrawAndDepositResponse, error) {
 	tx := &domain.Transaction{
+		PlayerName:    req.PlayerName,
+		Withdraw:      req.Withdraw,
+		Deposit:       req.Deposit,
+		Currency:      req.Currency,
+		ExternalID:    req.TransactionRef,
+		GameID:        req.GameID,
+		RoundRef:      req.GameRoundRef,
+		Finished:      req.Finished,
+		FreeRoundsRef: req.FreeRoundsRef,
+	}
+
+	for _, payout := range req.JackpotPayouts {
+		tx.JackpotPayouts = append(tx.JackpotPayouts, domain.JackpotPayout{PoolName: payout.Pool, Amount: payout.Amount})
+	}
+
+	if err := h.walletService.WithdrawAndDeposit(ctx, tx); err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return &WithdrawAndDepositResponse{
+		NewBalance:    *tx.BalanceAfterCommit,
+		TransactionID: tx.ID,
+	}, nil
+}
+
+func (h *Handler) ReserveFunds(ctx context.Context, req *ReserveFundsRequest) (*ReserveFundsResponse, error) {
+	reservation := &domain.Reservation{
+		Ref:        req.ReservationRef,
 		PlayerName: req.PlayerName,
-		Withdraw:   req.Withdraw,
-		Deposit:    req.Deposit,
 		Currency:   req.Currency,
-		ExternalID: req.TransactionRef,
+		Amount:     req.Amount,
+		GameID:     req.GameID,
+		RoundRef:   req.GameRoundRef,
 	}
 
-	if err := h.walletService.WithdrawAndDeposit(ctx, tx); err != nil {
+	balance, err := h.walletService.ReserveFunds(ctx, reservation)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return &ReserveFundsResponse{
+		ReservationRef:     reservation.Ref,
+		Amount:             reservation.Amount,
+		Status:             string(reservation.Status),
+		ExpiresAt:          reservation.ExpiresAt,
+		GetBalanceResponse: newGetBalanceResponse(balance),
+	}, nil
+}
+
+func (h *Handler) CommitReservation(ctx context.Context, req *CommitReservationRequest) (*WithdrawAndDepositResponse, error) {
+	tx, err := h.walletService.CommitReservation(ctx, req.PlayerName, req.ReservationRef, req.Amount)
+	if err != nil {
 		return nil, MapDomainToTransportError(err)
 	}
 
@@ -45,6 +86,15 @@ func (h *Handler) WithdrawAndDeposit(ctx context.Context, req *WithdrawAndDeposi
 	}, nil
 }
 
+func (h *Handler) ReleaseReservation(ctx context.Context, req *ReleaseReservationRequest) (*GetBalanceResponse, error) {
+	balance, err := h.walletService.ReleaseReservation(ctx, req.PlayerName, req.ReservationRef)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	return newGetBalanceResponse(balance), nil
+}
+
 func (h *Handler) RollbackTransaction(ctx context.Context, req *RollbackTransactionRequest) error {
 	tx := &domain.Transaction{
 		PlayerName: req.PlayerName,
@@ -54,3 +104,47 @@ func (h *Handler) RollbackTransaction(ctx context.Context, req *RollbackTransact
 
 	return h.walletService.RollbackTransaction(ctx, tx)
 }
+
+func (h *Handler) GetTransactionHistory(ctx context.Context, req *GetTransactionHistoryRequest) (*GetTransactionHistoryResponse, error) {
+	return getTransactionHistory(ctx, h.walletService, req)
+}
+
+func getTransactionHistory(
+	ctx context.Context,
+	walletService WalletService,
+	req *GetTransactionHistoryRequest,
+) (*GetTransactionHistoryResponse, error) {
+	filter := domain.TransactionFilter{
+		PlayerName: req.PlayerName,
+		Currency:   req.Currency,
+		From:       req.From,
+		To:         req.To,
+		GameID:     req.GameID,
+		RoundRef:   req.GameRoundRef,
+		Status:     domain.TransactionStatus(req.Status),
+		Limit:      req.Limit,
+	}
+
+	if req.Cursor != "" {
+		cursor, err := domain.DecodeTransactionCursor(req.Cursor)
+		if err != nil {
+			return nil, MapDomainToTransportError(err)
+		}
+		filter.After = cursor
+	}
+
+	transactions, next, err := walletService.GetTransactionHistory(ctx, filter)
+	if err != nil {
+		return nil, MapDomainToTransportError(err)
+	}
+
+	resp := &GetTransactionHistoryResponse{
+		Transactions: make([]*Transaction, 0, len(transactions)),
+		NextCursor:   next,
+	}
+	for _, tx := range transactions {
+		resp.Transactions = append(resp.Transactions, NewTransaction(tx))
+	}
+
+	return resp, nil
+}
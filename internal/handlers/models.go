@@ -1,5 +1,11 @@
 package handlers
 
+import (
+	"time"
+
+	"mascot/internal/domain"
+)
+
 type GetBalanceRequest struct {
 	PlayerName string `json:"playerName" validate:"required"`
 	Currency   string `json:"currency" validate:"required"`
@@ -7,7 +13,22 @@ type GetBalanceRequest struct {
 }
 
 type GetBalanceResponse struct {
-	Balance int64 `json:"balance"`
+	Balance      int64 `json:"balance"`
+	RealBalance  int64 `json:"realBalance"`
+	BonusBalance int64 `json:"bonusBalance"`
+	// AvailableBalance is the part of Balance which isn't held by reservations.
+	AvailableBalance int64 `json:"availableBalance"`
+	HeldBalance      int64 `json:"heldBalance"`
+}
+
+func newGetBalanceResponse(balance domain.Balance) *GetBalanceResponse {
+	return &GetBalanceResponse{
+		Balance:          balance.Total(),
+		RealBalance:      balance.Real,
+		BonusBalance:     balance.Bonus,
+		AvailableBalance: balance.Available(),
+		HeldBalance:      balance.Held,
+	}
 }
 
 type WithdrawAndDepositRequest struct {
@@ -16,6 +37,17 @@ type WithdrawAndDepositRequest struct {
 	Deposit        *int64 `json:"deposit" validate:"required"`
 	Currency       string `json:"currency" validate:"required"`
 	TransactionRef string `json:"transactionRef" validate:"required"`
+	GameID         string `json:"gameId"`
+	GameRoundRef   string `json:"gameRoundRef"`
+	Finished       bool   `json:"finished"`
+	FreeRoundsRef  string `json:"freeRoundsRef"`
+	// JackpotPayouts are parts of Deposit won from jackpot pools.
+	JackpotPayouts []JackpotPayout `json:"jackpotPayouts" validate:"dive"`
+}
+
+type JackpotPayout struct {
+	Pool   string `json:"pool" validate:"required"`
+	Amount int64  `json:"amount" validate:"gt=0"`
 }
 
 type WithdrawAndDepositResponse struct {
@@ -23,7 +55,102 @@ type WithdrawAndDepositResponse struct {
 	TransactionID string `json:"transactionId"`
 }
 
+type ReserveFundsRequest struct {
+	PlayerName     string `json:"playerName" validate:"required"`
+	Currency       string `json:"currency" validate:"required"`
+	ReservationRef string `json:"reservationRef" validate:"required"`
+	Amount         int64  `json:"amount" validate:"gt=0"`
+	GameID         string `json:"gameId"`
+	GameRoundRef   string `json:"gameRoundRef"`
+}
+
+type ReserveFundsResponse struct {
+	ReservationRef string    `json:"reservationRef"`
+	Amount         int64     `json:"amount"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	*GetBalanceResponse
+}
+
+type CommitReservationRequest struct {
+	PlayerName     string `json:"playerName" validate:"required"`
+	ReservationRef string `json:"reservationRef" validate:"required"`
+	// Amount is the part of the reservation to withdraw, the rest is released.
+	Amount int64 `json:"amount" validate:"gt=0"`
+}
+
+type ReleaseReservationRequest struct {
+	PlayerName     string `json:"playerName" validate:"required"`
+	ReservationRef string `json:"reservationRef" validate:"required"`
+}
+
 type RollbackTransactionRequest struct {
 	PlayerName     string `json:"playerName" validate:"required"`
 	TransactionRef string `json:"transactionRef" validate:"required"`
 }
+
+type GetTransactionHistoryRequest struct {
+	PlayerName   string     `json:"playerName" validate:"required"`
+	Currency     string     `json:"currency"`
+	From         *time.Time `json:"from"`
+	To           *time.Time `json:"to"`
+	GameID       string     `json:"gameId"`
+	GameRoundRef string     `json:"gameRoundRef"`
+	Status       string     `json:"status" validate:"omitempty,oneof=committed rolled_back"`
+	Cursor       string     `json:"cursor"`
+	Limit        int        `json:"limit" validate:"gte=0,lte=1000"`
+}
+
+type GetTransactionHistoryResponse struct {
+	Transactions []*Transaction `json:"transactions"`
+	NextCursor   string         `json:"nextCursor,omitempty"`
+}
+
+type Transaction struct {
+	TransactionID      string    `json:"transactionId"`
+	TransactionRef     string    `json:"transactionRef"`
+	Kind               string    `json:"kind"`
+	PlayerName         string    `json:"playerName"`
+	Withdraw           int64     `json:"withdraw"`
+	Deposit            int64     `json:"deposit"`
+	WithdrawBonus      int64     `json:"withdrawBonus"`
+	DepositBonus       int64     `json:"depositBonus"`
+	Currency           string    `json:"currency"`
+	GameID             string    `json:"gameId,omitempty"`
+	GameRoundRef       string    `json:"gameRoundRef,omitempty"`
+	Finished           bool      `json:"finished"`
+	FreeRoundsRef      string    `json:"freeRoundsRef,omitempty"`
+	BalanceAfterCommit *int64    `json:"balanceAfterCommit,omitempty"`
+	RolledBack         bool      `json:"rolledBack"`
+	RollbackShortfall  int64     `json:"rollbackShortfall,omitempty"`
+	TransferRef        string    `json:"transferRef,omitempty"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
+
+// NewTransaction returns the API model of the transaction, which the operational commands print as well.
+func NewTransaction(tx *domain.Transaction) *Transaction {
+	return &Transaction{
+		TransactionID:      tx.ID,
+		TransactionRef:     tx.ExternalID,
+		Kind:               string(tx.Kind),
+		PlayerName:         tx.PlayerName,
+		Withdraw:           tx.WithdrawAmount(),
+		Deposit:            tx.DepositAmount(),
+		WithdrawBonus:      tx.WithdrawBonus,
+		DepositBonus:       tx.DepositBonus,
+		Currency:           tx.Currency,
+		GameID:             tx.GameID,
+		GameRoundRef:       tx.RoundRef,
+		Finished:           tx.Finished,
+		FreeRoundsRef:      tx.FreeRoundsRef,
+		BalanceAfterCommit: tx.BalanceAfterCommit,
+		RolledBack:         tx.RolledBack,
+		RollbackShortfall:  tx.RollbackShortfall,
+		TransferRef:        tx.TransferRef,
+		Status:             string(tx.Status()),
+		CreatedAt:          tx.CreatedAt,
+		UpdatedAt:          tx.UpdatedAt,
+	}
+}
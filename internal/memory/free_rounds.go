@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"mascot/internal/domain"
+)
+
+type FreeRounds struct {
+	store *Store
+}
+
+func NewFreeRounds(store *Store) *FreeRounds {
+	return &FreeRounds{store}
+}
+
+// GetFreeRounds returns free rounds by their reference or nil if they don't exist.
+func (r *FreeRounds) GetFreeRounds(ctx context.Context, ref string) (*domain.FreeRounds, error) {
+	var res *domain.FreeRounds
+	r.store.view(ctx, func() {
+		if f, ok := r.store.freeRounds[ref]; ok {
+			copied := *f
+			res = &copied
+		}
+	})
+
+	return res, nil
+}
+
+func (r *FreeRounds) InsertFreeRounds(ctx context.Context, f *domain.FreeRounds) error {
+	return r.store.update(ctx, func(onRollback func(func())) error {
+		if _, ok := r.store.freeRounds[f.Ref]; ok {
+			return domain.ErrFreeRoundsExists
+		}
+
+		f.ID = r.store.nextID()
+		f.CreatedAt = r.store.now()
+		f.UpdatedAt = f.CreatedAt
+
+		stored := *f
+		r.store.freeRounds[f.Ref] = &stored
+		onRollback(func() { delete(r.store.freeRounds, f.Ref) })
+		return nil
+	})
+}
+
+func (r *FreeRounds) UpdateFreeRounds(ctx context.Context, f *domain.FreeRounds) error {
+	return r.store.update(ctx, func(onRollback func(func())) error {
+		old, ok := r.store.freeRounds[f.Ref]
+		if !ok {
+			return fmt.Errorf("free rounds %s don't exist", f.Ref)
+		}
+
+		f.UpdatedAt = r.store.now()
+		updated := *old
+		updated.Remaining, updated.TotalWin, updated.Status, updated.UpdatedAt = f.Remaining, f.TotalWin, f.Status, f.UpdatedAt
+		r.store.freeRounds[f.Ref] = &updated
+		onRollback(func() { r.store.freeRounds[f.Ref] = old })
+		return nil
+	})
+}
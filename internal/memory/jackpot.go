@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"mascot/internal/domain"
+)
+
+type Jackpot struct {
+	store *Store
+}
+
+func NewJackpot(store *Store) *Jackpot {
+	return &Jackpot{store}
+}
+
+func (j *Jackpot) InsertPool(ctx context.Context, pool *domain.JackpotPool) error {
+	return j.store.update(ctx, func(onRollback func(func())) error {
+		if _, ok := j.store.jackpotPools[pool.Name]; ok {
+			return domain.ErrJackpotPoolExists
+		}
+
+		pool.ID = j.store.nextID()
+		pool.CreatedAt = j.store.now()
+		pool.UpdatedAt = pool.CreatedAt
+
+		stored := *pool
+		j.store.jackpotPools[pool.Name] = &stored
+		onRollback(func() { delete(j.store.jackpotPools, pool.Name) })
+		return nil
+	})
+}
+
+// GetPoolsByName returns the pools ordered by id.
+func (j *Jackpot) GetPoolsByName(ctx context.Context, names []string) ([]*domain.JackpotPool, error) {
+	var res []*domain.JackpotPool
+	j.store.view(ctx, func() {
+		for _, name := range names {
+			if pool, ok := j.store.jackpotPools[name]; ok {
+				copied := *pool
+				res = append(res, &copied)
+			}
+		}
+	})
+	sort.Slice(res, func(i, k int) bool { return res[i].ID < res[k].ID })
+
+	return res, nil
+}
+
+func (j *Jackpot) UpdatePoolBalance(ctx context.Context, pool *domain.JackpotPool) error {
+	return j.store.update(ctx, func(onRollback func(func())) error {
+		old, ok := j.store.jackpotPools[pool.Name]
+		if !ok {
+			return nil
+		}
+
+		updated := *old
+		updated.Balance = pool.Balance
+		updated.UpdatedAt = j.store.now()
+		j.store.jackpotPools[pool.Name] = &updated
+		onRollback(func() { j.store.jackpotPools[pool.Name] = old })
+		return nil
+	})
+}
+
+// SaveContribution creates the contribution rule of the pool and game or changes its rate.
+func (j *Jackpot) SaveContribution(ctx context.Context, c *domain.JackpotContribution) error {
+	return j.store.update(ctx, func(onRollback func(func())) error {
+		for i, old := range j.store.contributions {
+			if old.PoolID == c.PoolID && old.GameID == c.GameID {
+				c.ID = old.ID
+				updated := *old
+				updated.RateBasisPoints = c.RateBasisPoints
+				j.store.contributions[i] = &updated
+				onRollback(func() { j.store.contributions[i] = old })
+				return nil
+			}
+		}
+
+		c.ID = j.store.nextID()
+		stored := *c
+		n := len(j.store.contributions)
+		j.store.contributions = append(j.store.contributions, &stored)
+		onRollback(func() { j.store.contributions = j.store.contributions[:n] })
+		return nil
+	})
+}
+
+// GetContributions returns rules matching the currency and game, game specific rules first.
+func (j *Jackpot) GetContributions(ctx context.Context, currency, gameID string) ([]*domain.JackpotContribution, error) {
+	var res []*domain.JackpotContribution
+	j.store.view(ctx, func() {
+		for _, c := range j.store.contributions {
+			if c.Currency != currency || (c.GameID != gameID && c.GameID != "") {
+				continue
+			}
+
+			copied := *c
+			copied.PoolName = j.poolName(c.PoolID)
+			res = append(res, &copied)
+		}
+	})
+	sort.Slice(res, func(i, k int) bool {
+		if res[i].GameID != res[k].GameID {
+			return res[i].GameID > res[k].GameID
+		}
+		return res[i].PoolID < res[k].PoolID
+	})
+
+	return res, nil
+}
+
+func (j *Jackpot) InsertLedgerEntry(ctx context.Context, entry *domain.JackpotLedgerEntry) error {
+	return j.store.update(ctx, func(onRollback func(func())) error {
+		entry.ID = j.store.nextID()
+		entry.CreatedAt = j.store.now()
+
+		stored := *entry
+		n := len(j.store.jackpotLedger)
+		j.store.jackpotLedger = append(j.store.jackpotLedger, &stored)
+		onRollback(func() { j.store.jackpotLedger = j.store.jackpotLedger[:n] })
+		return nil
+	})
+}
+
+func (j *Jackpot) GetLedgerEntriesByTransaction(ctx context.Context, transactionID string) ([]*domain.JackpotLedgerEntry, error) {
+	var res []*domain.JackpotLedgerEntry
+	j.store.view(ctx, func() {
+		for _, entry := range j.store.jackpotLedger {
+			if entry.TransactionID == transactionID {
+				copied := *entry
+				copied.PoolName = j.poolName(entry.PoolID)
+				res = append(res, &copied)
+			}
+		}
+	})
+
+	return res, nil
+}
+
+func (j *Jackpot) poolName(poolID int64) string {
+	for name, pool := range j.store.jackpotPools {
+		if pool.ID == poolID {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+
+	"mascot/internal/domain"
+)
+
+type Ledger struct {
+	store *Store
+}
+
+func NewLedger(store *Store) *Ledger {
+	return &Ledger{store}
+}
+
+func (l *Ledger) InsertEntrySet(ctx context.Context, set *domain.EntrySet) error {
+	return l.store.update(ctx, func(onRollback func(func())) error {
+		n := len(l.store.ledgerEntries)
+		for _, entry := range set.Entries {
+			entry.ID = l.store.nextID()
+			entry.CreatedAt = l.store.now()
+
+			stored := *entry
+			l.store.ledgerEntries = append(l.store.ledgerEntries, &stored)
+		}
+		onRollback(func() { l.store.ledgerEntries = l.store.ledgerEntries[:n] })
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+
+	"mascot/internal/domain"
+)
+
+// Outbox keeps the wallet events. Nothing dispatches them, Events returns them for inspection.
+type Outbox struct {
+	store *Store
+}
+
+func NewOutbox(store *Store) *Outbox {
+	return &Outbox{store}
+}
+
+func (o *Outbox) InsertEvent(ctx context.Context, event *domain.Event) error {
+	return o.store.update(ctx, func(onRollback func(func())) error {
+		event.ID = o.store.nextID()
+		event.CreatedAt = o.store.now()
+
+		stored := *event
+		n := len(o.store.events)
+		o.store.events = append(o.store.events, &stored)
+		onRollback(func() { o.store.events = o.store.events[:n] })
+		return nil
+	})
+}
+
+// Events returns the committed events of the player in the order they were written.
+func (o *Outbox) Events(ctx context.Context, playerName string) []*domain.Event {
+	var res []*domain.Event
+	o.store.view(ctx, func() {
+		for _, event := range o.store.events {
+			if event.PlayerName == playerName {
+				copied := *event
+				res = append(res, &copied)
+			}
+		}
+	})
+
+	return res
+}
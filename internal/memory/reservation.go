@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+type Reservation struct {
+	store *Store
+}
+
+func NewReservation(store *Store) *Reservation {
+	return &Reservation{store}
+}
+
+// GetReservation returns the reservation or nil if it doesn't exist.
+func (r *Reservation) GetReservation(ctx context.Context, ref string) (*domain.Reservation, error) {
+	var res *domain.Reservation
+	r.store.view(ctx, func() {
+		if reservation, ok := r.store.reservations[ref]; ok {
+			copied := *reservation
+			res = &copied
+		}
+	})
+
+	return res, nil
+}
+
+// GetExpiredReservations returns up to limit held reservations which ran out of time before now.
+func (r *Reservation) GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*domain.Reservation, error) {
+	var res []*domain.Reservation
+	r.store.view(ctx, func() {
+		for _, reservation := range r.store.reservations {
+			if reservation.Status == domain.ReservationStatusHeld && !reservation.ExpiresAt.After(now) {
+				copied := *reservation
+				res = append(res, &copied)
+			}
+		}
+	})
+
+	sort.Slice(res, func(i, j int) bool { return res[i].ExpiresAt.Before(res[j].ExpiresAt) })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res, nil
+}
+
+func (r *Reservation) InsertReservation(ctx context.Context, reservation *domain.Reservation) error {
+	return r.store.update(ctx, func(onRollback func(func())) error {
+		if _, ok := r.store.reservations[reservation.Ref]; ok {
+			return fmt.Errorf("reservation %s already exists", reservation.Ref)
+		}
+
+		reservation.ID = r.store.nextID()
+		reservation.CreatedAt = r.store.now()
+		reservation.UpdatedAt = reservation.CreatedAt
+
+		stored := *reservation
+		r.store.reservations[reservation.Ref] = &stored
+		onRollback(func() { delete(r.store.reservations, reservation.Ref) })
+		return nil
+	})
+}
+
+func (r *Reservation) UpdateReservation(ctx context.Context, reservation *domain.Reservation) error {
+	return r.store.update(ctx, func(onRollback func(func())) error {
+		old, ok := r.store.reservations[reservation.Ref]
+		if !ok {
+			return fmt.Errorf("reservation %s doesn't exist", reservation.Ref)
+		}
+
+		reservation.UpdatedAt = r.store.now()
+		updated := *old
+		updated.Status = reservation.Status
+		updated.CommittedAmount = reservation.CommittedAmount
+		updated.TransactionID = reservation.TransactionID
+		updated.UpdatedAt = reservation.UpdatedAt
+		r.store.reservations[reservation.Ref] = &updated
+		onRollback(func() { r.store.reservations[reservation.Ref] = old })
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+
+	"mascot/internal/domain"
+)
+
+type roundKey struct {
+	playerName string
+	gameID     string
+	roundRef   string
+}
+
+type Round struct {
+	store *Store
+}
+
+func NewRound(store *Store) *Round {
+	return &Round{store}
+}
+
+// GetRound returns the round or nil if it doesn't exist. The transaction holds the whole store, so there is nothing to lock.
+func (r *Round) GetRound(ctx context.Context, playerName, gameID, roundRef string) (*domain.Round, error) {
+	return r.ReadRound(ctx, playerName, gameID, roundRef)
+}
+
+// ReadRound returns the round or nil if it doesn't exist.
+func (r *Round) ReadRound(ctx context.Context, playerName, gameID, roundRef string) (*domain.Round, error) {
+	var res *domain.Round
+	r.store.view(ctx, func() {
+		if round, ok := r.store.rounds[roundKey{playerName, gameID, roundRef}]; ok {
+			copied := *round
+			res = &copied
+		}
+	})
+
+	return res, nil
+}
+
+func (r *Round) SaveRound(ctx context.Context, round *domain.Round) error {
+	return r.store.update(ctx, func(onRollback func(func())) error {
+		key := roundKey{round.PlayerName, round.GameID, round.RoundRef}
+		old, ok := r.store.rounds[key]
+		if ok {
+			round.ID, round.CreatedAt = old.ID, old.CreatedAt
+		} else {
+			round.ID, round.CreatedAt = r.store.nextID(), r.store.now()
+		}
+		round.UpdatedAt = r.store.now()
+
+		stored := *round
+		r.store.rounds[key] = &stored
+		onRollback(func() {
+			if ok {
+				r.store.rounds[key] = old
+			} else {
+				delete(r.store.rounds, key)
+			}
+		})
+		return nil
+	})
+}
@@ -0,0 +1,169 @@
+// Package memory keeps the data of the wallet engine in process memory instead of Postgres. It backs
+// the memory storage mode for local demos and the service tests, the data is lost when the process exits.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+)
+
+type txKey struct{}
+
+// tx is a running transaction. Writes change the store in place and register how to undo them.
+type tx struct {
+	store *Store
+	undo  []func()
+	// changed are the players whose wallets changed, the listeners hear of them once the transaction commits.
+	changed []string
+}
+
+func (t *tx) onRollback(f func()) {
+	t.undo = append(t.undo, f)
+}
+
+// rollback undoes the writes made after the first mark of them, newest first.
+func (t *tx) rollback(mark int) {
+	for i := len(t.undo) - 1; i >= mark; i-- {
+		t.undo[i]()
+	}
+	t.undo = t.undo[:mark]
+}
+
+// Store holds the tables of the memory storage. Transactions run one at a time, which makes them
+// serializable: a transaction never sees the writes of another one before it ends.
+type Store struct {
+	// mu is held by the running transaction until it ends, and by reads made outside of transactions.
+	mu     sync.Mutex
+	now    func() time.Time
+	lastID int64
+
+	wallets        map[string]*domain.Wallet
+	statusChanges  []*domain.WalletStatusChange
+	transactions   []*domain.Transaction
+	transactionIDs map[string]int
+	externalIDs    map[string]int
+	rounds         map[roundKey]*domain.Round
+	freeRounds     map[string]*domain.FreeRounds
+	jackpotPools   map[string]*domain.JackpotPool
+	contributions  []*domain.JackpotContribution
+	jackpotLedger  []*domain.JackpotLedgerEntry
+	ledgerEntries  []*domain.LedgerEntry
+	reservations   map[string]*domain.Reservation
+	events         []*domain.Event
+
+	walletListeners []db.NotificationHandler
+}
+
+func NewStore() *Store {
+	return &Store{
+		now:            time.Now,
+		wallets:        make(map[string]*domain.Wallet),
+		transactionIDs: make(map[string]int),
+		externalIDs:    make(map[string]int),
+		rounds:         make(map[roundKey]*domain.Round),
+		freeRounds:     make(map[string]*domain.FreeRounds),
+		jackpotPools:   make(map[string]*domain.JackpotPool),
+		reservations:   make(map[string]*domain.Reservation),
+	}
+}
+
+// WithTx runs txFunc in a transaction, its writes are undone if it returns an error. Called with a context
+// which already carries a transaction, an error only undoes the writes of txFunc, like a savepoint.
+// The options are ignored, every transaction is serializable.
+func (s *Store) WithTx(ctx context.Context, txFunc func(ctx context.Context) error, _ ...db.TxOption) error {
+	if t := s.tx(ctx); t != nil {
+		mark := len(t.undo)
+		if err := txFunc(ctx); err != nil {
+			t.rollback(mark)
+			return err
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &tx{store: s}
+	committed := false
+	defer func() {
+		if !committed {
+			t.rollback(0)
+		}
+	}()
+
+	if err := txFunc(context.WithValue(ctx, txKey{}, t)); err != nil {
+		return err
+	}
+
+	committed = true
+	for _, playerName := range t.changed {
+		for _, listener := range s.walletListeners {
+			listener.Notify(playerName)
+		}
+	}
+
+	return nil
+}
+
+// ListenWalletChanged tells handler of the players whose wallets changed once their transactions commit,
+// like the notifications of repositories.WalletChangedChannel. The memory storage never misses one.
+func (s *Store) ListenWalletChanged(handler db.NotificationHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.walletListeners = append(s.walletListeners, handler)
+	handler.Listening(true)
+}
+
+func (s *Store) tx(ctx context.Context) *tx {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok && t.store == s {
+		return t
+	}
+	return nil
+}
+
+// view runs f in the transaction of ctx, outside of a transaction under the store lock.
+func (s *Store) view(ctx context.Context, f func()) {
+	if s.tx(ctx) != nil {
+		f()
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f()
+}
+
+// update runs f in the transaction of ctx, outside of a transaction in one of its own. The functions f
+// passes to onRollback undo its writes when the transaction rolls back.
+func (s *Store) update(ctx context.Context, f func(onRollback func(func())) error) error {
+	if t := s.tx(ctx); t != nil {
+		return f(t.onRollback)
+	}
+
+	return s.WithTx(ctx, func(ctx context.Context) error {
+		return f(s.tx(ctx).onRollback)
+	})
+}
+
+// walletChanged tells the listeners of the change of the wallet once the transaction commits. A change
+// undone with a savepoint isn't told, like a notification of a rolled back subtransaction.
+func (s *Store) walletChanged(ctx context.Context, playerName string) error {
+	return s.WithTx(ctx, func(ctx context.Context) error {
+		t := s.tx(ctx)
+		t.changed = append(t.changed, playerName)
+		n := len(t.changed) - 1
+		t.onRollback(func() { t.changed = t.changed[:n] })
+		return nil
+	})
+}
+
+// nextID returns the next row id. Like a Postgres sequence it is not given back on rollback.
+func (s *Store) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mascot/internal/domain"
+)
+
+func TestStore_WithTx(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := NewStore()
+	wallets := NewWallet(store)
+	if err := wallets.InsertWallet(ctx, &domain.Wallet{UserName: "player", Currency: "USD", Balance: 100}); err != nil {
+		t.Fatalf("InsertWallet() error = %v", err)
+	}
+
+	setBalance := func(ctx context.Context, balance int64) error {
+		wallet, err := wallets.GetWallet(ctx, "player")
+		if err != nil {
+			return err
+		}
+		wallet.Balance = balance
+		return wallets.UpdateBalance(ctx, wallet)
+	}
+	balance := func() int64 {
+		t.Helper()
+		wallet, err := wallets.ReadWallet(ctx, "player")
+		if err != nil {
+			t.Fatalf("ReadWallet() error = %v", err)
+		}
+		return wallet.Balance
+	}
+
+	errFailed := errors.New("failed")
+	err := store.WithTx(ctx, func(ctx context.Context) error {
+		if err := setBalance(ctx, 200); err != nil {
+			return err
+		}
+		if err := wallets.InsertTransaction(ctx, &domain.Transaction{ID: "1", ExternalID: "1", PlayerName: "player"}); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("WithTx() error = %v, want %v", err, errFailed)
+	}
+	if got := balance(); got != 100 {
+		t.Errorf("balance after rollback = %d, want 100", got)
+	}
+	if tx, _ := wallets.GetTransactionByExternalID(ctx, "1"); tx != nil {
+		t.Errorf("transaction of a rolled back tx = %+v", tx)
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context) error {
+		if err := setBalance(ctx, 300); err != nil {
+			return err
+		}
+
+		// a failed nested transaction only undoes its own writes
+		err := store.WithTx(ctx, func(ctx context.Context) error {
+			if err := setBalance(ctx, 400); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			return err
+		}
+
+		if got, _ := wallets.GetWallet(ctx, "player"); got.Balance != 300 {
+			t.Errorf("balance after the nested rollback = %d, want 300", got.Balance)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	if got := balance(); got != 300 {
+		t.Errorf("balance after commit = %d, want 300", got)
+	}
+}
@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+type Wallet struct {
+	store *Store
+}
+
+func NewWallet(store *Store) *Wallet {
+	return &Wallet{store}
+}
+
+// InsertWallet creates the wallet.
+func (w *Wallet) InsertWallet(ctx context.Context, wallet *domain.Wallet) error {
+	return w.store.update(ctx, func(onRollback func(func())) error {
+		if _, ok := w.store.wallets[wallet.UserName]; ok {
+			return domain.ErrWalletExists
+		}
+
+		wallet.ID = w.store.nextID()
+		if wallet.Status == "" {
+			wallet.Status = domain.WalletStatusActive
+		}
+
+		stored := *wallet
+		w.store.wallets[wallet.UserName] = &stored
+		onRollback(func() { delete(w.store.wallets, wallet.UserName) })
+		return nil
+	})
+}
+
+// GetWallet returns the wallet. The transaction holds the whole store, so there is nothing to lock.
+func (w *Wallet) GetWallet(ctx context.Context, playerName string) (*domain.Wallet, error) {
+	return w.ReadWallet(ctx, playerName)
+}
+
+func (w *Wallet) ReadWallet(ctx context.Context, playerName string) (*domain.Wallet, error) {
+	var res *domain.Wallet
+	w.store.view(ctx, func() {
+		if wallet, ok := w.store.wallets[playerName]; ok {
+			copied := *wallet
+			res = &copied
+		}
+	})
+	if res == nil {
+		return nil, domain.ErrWalletNotFound
+	}
+
+	return res, nil
+}
+
+// ListWallets returns up to limit wallets ordered by player name, starting after the given name.
+func (w *Wallet) ListWallets(ctx context.Context, after string, limit int) ([]*domain.Wallet, error) {
+	var res []*domain.Wallet
+	w.store.view(ctx, func() {
+		for name, wallet := range w.store.wallets {
+			if name > after {
+				copied := *wallet
+				res = append(res, &copied)
+			}
+		}
+	})
+
+	sort.Slice(res, func(i, j int) bool { return res[i].UserName < res[j].UserName })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res, nil
+}
+
+func (w *Wallet) UpdateBalance(ctx context.Context, wallet *domain.Wallet) error {
+	return w.updateWallet(ctx, wallet.UserName, func(stored *domain.Wallet) {
+		stored.Balance = wallet.Balance
+		stored.Bonus = wallet.Bonus
+		stored.Debt = wallet.Debt
+		stored.Held = wallet.Held
+	})
+}
+
+func (w *Wallet) UpdateStatus(ctx context.Context, wallet *domain.Wallet) error {
+	return w.updateWallet(ctx, wallet.UserName, func(stored *domain.Wallet) {
+		stored.Status = wallet.Status
+	})
+}
+
+func (w *Wallet) UpdateRollbackPolicy(ctx context.Context, wallet *domain.Wallet) error {
+	return w.updateWallet(ctx, wallet.UserName, func(stored *domain.Wallet) {
+		stored.RollbackPolicy = wallet.RollbackPolicy
+	})
+}
+
+// NotifyWalletChanged tells the listeners of the store of the change once the transaction commits.
+func (w *Wallet) NotifyWalletChanged(ctx context.Context, playerName string) error {
+	return w.store.walletChanged(ctx, playerName)
+}
+
+// updateWallet replaces the stored wallet by a changed copy. Like an UPDATE it does nothing if the wallet doesn't exist.
+func (w *Wallet) updateWallet(ctx context.Context, playerName string, change func(stored *domain.Wallet)) error {
+	return w.store.update(ctx, func(onRollback func(func())) error {
+		old, ok := w.store.wallets[playerName]
+		if !ok {
+			return nil
+		}
+
+		updated := *old
+		change(&updated)
+		w.store.wallets[playerName] = &updated
+		onRollback(func() { w.store.wallets[playerName] = old })
+		return nil
+	})
+}
+
+// GetPlayersWithExpiredBonus returns players whose bonus expired before now but is not forfeited yet.
+func (w *Wallet) GetPlayersWithExpiredBonus(ctx context.Context, now time.Time) ([]string, error) {
+	var res []string
+	w.store.view(ctx, func() {
+		for name, wallet := range w.store.wallets {
+			bonus := wallet.Bonus
+			if bonus.ExpiresAt != nil && !bonus.ExpiresAt.After(now) && bonus.Active() {
+				res = append(res, name)
+			}
+		}
+	})
+	sort.Strings(res)
+
+	return res, nil
+}
+
+// GetDebtReport returns the wallets owing money, by debt or by a negative balance.
+func (w *Wallet) GetDebtReport(ctx context.Context) ([]*domain.DebtReportEntry, error) {
+	var res []*domain.DebtReportEntry
+	w.store.view(ctx, func() {
+		for name, wallet := range w.store.wallets {
+			if wallet.Debt <= 0 && wallet.Balance >= 0 {
+				continue
+			}
+
+			var negative int64
+			if wallet.Balance < 0 {
+				negative = -wallet.Balance
+			}
+			res = append(res, &domain.DebtReportEntry{
+				PlayerName:      name,
+				Currency:        strings.ToUpper(wallet.Currency),
+				Debt:            wallet.Debt,
+				NegativeBalance: negative,
+			})
+		}
+	})
+	sort.Slice(res, func(i, j int) bool { return res[i].PlayerName < res[j].PlayerName })
+
+	return res, nil
+}
+
+func (w *Wallet) InsertStatusChange(ctx context.Context, change *domain.WalletStatusChange) error {
+	return w.store.update(ctx, func(onRollback func(func())) error {
+		change.ID = w.store.nextID()
+		change.CreatedAt = w.store.now()
+
+		stored := *change
+		n := len(w.store.statusChanges)
+		w.store.statusChanges = append(w.store.statusChanges, &stored)
+		onRollback(func() { w.store.statusChanges = w.store.statusChanges[:n] })
+		return nil
+	})
+}
+
+func (w *Wallet) GetStatusChanges(ctx context.Context, playerName string) ([]*domain.WalletStatusChange, error) {
+	var res []*domain.WalletStatusChange
+	w.store.view(ctx, func() {
+		wallet, ok := w.store.wallets[playerName]
+		if !ok {
+			return
+		}
+
+		for _, change := range w.store.statusChanges {
+			if change.WalletID == wallet.ID {
+				copied := *change
+				copied.PlayerName = playerName
+				res = append(res, &copied)
+			}
+		}
+	})
+
+	return res, nil
+}
+
+func (w *Wallet) GetTransactionByExternalID(ctx context.Context, externalID string) (*domain.Transaction, error) {
+	var res *domain.Transaction
+	w.store.view(ctx, func() {
+		if i, ok := w.store.externalIDs[externalID]; ok {
+			copied := *w.store.transactions[i]
+			res = &copied
+		}
+	})
+
+	return res, nil
+}
+
+func (w *Wallet) GetTransactionsByRound(ctx context.Context, playerName, gameID, roundRef string) ([]*domain.Transaction, error) {
+	res := w.transactions(ctx, func(tx *domain.Transaction) bool {
+		return tx.PlayerName == playerName && tx.GameID == gameID && tx.RoundRef == roundRef
+	})
+	sort.SliceStable(res, func(i, j int) bool { return transactionAfter(res[j], res[i].CreatedAt, res[i].ID) })
+
+	return res, nil
+}
+
+// ListTransactions returns up to filter.Limit transactions matching the filter, newest first.
+func (w *Wallet) ListTransactions(ctx context.Context, filter domain.TransactionFilter) ([]*domain.Transaction, error) {
+	res := w.transactions(ctx, func(tx *domain.Transaction) bool {
+		switch {
+		case filter.PlayerName != "" && tx.PlayerName != filter.PlayerName:
+			return false
+		case filter.Currency != "" && !strings.EqualFold(tx.Currency, filter.Currency):
+			return false
+		case filter.From != nil && tx.CreatedAt.Before(*filter.From):
+			return false
+		case filter.To != nil && !tx.CreatedAt.Before(*filter.To):
+			return false
+		case filter.GameID != "" && tx.GameID != filter.GameID:
+			return false
+		case filter.RoundRef != "" && tx.RoundRef != filter.RoundRef:
+			return false
+		case filter.Status != "" && tx.Status() != filter.Status:
+			return false
+		case filter.After != nil && (tx.ID == filter.After.ID || transactionAfter(tx, filter.After.CreatedAt, filter.After.ID)):
+			return false
+		}
+		return true
+	})
+
+	sort.SliceStable(res, func(i, j int) bool { return transactionAfter(res[i], res[j].CreatedAt, res[j].ID) })
+	if len(res) > filter.Limit {
+		res = res[:filter.Limit]
+	}
+
+	return res, nil
+}
+
+// transactionAfter reports whether tx comes after the position in (created_at, id) order.
+func transactionAfter(tx *domain.Transaction, createdAt time.Time, id string) bool {
+	if !tx.CreatedAt.Equal(createdAt) {
+		return tx.CreatedAt.After(createdAt)
+	}
+	return tx.ID > id
+}
+
+// transactions returns copies of the transactions matching the filter in insertion order.
+func (w *Wallet) transactions(ctx context.Context, match func(tx *domain.Transaction) bool) []*domain.Transaction {
+	var res []*domain.Transaction
+	w.store.view(ctx, func() {
+		for _, tx := range w.store.transactions {
+			if match(tx) {
+				copied := *tx
+				res = append(res, &copied)
+			}
+		}
+	})
+
+	return res
+}
+
+func (w *Wallet) InsertTransaction(ctx context.Context, tx *domain.Transaction) error {
+	return w.store.update(ctx, func(onRollback func(func())) error {
+		_, idTaken := w.store.transactionIDs[tx.ID]
+		_, externalIDTaken := w.store.externalIDs[tx.ExternalID]
+		if idTaken || externalIDTaken {
+			return domain.ErrTransactionExists
+		}
+
+		stored := *tx
+		stored.JackpotPayouts = nil
+		stored.CreatedAt = w.store.now()
+		stored.UpdatedAt = stored.CreatedAt
+
+		n := len(w.store.transactions)
+		w.store.transactions = append(w.store.transactions, &stored)
+		w.store.transactionIDs[tx.ID] = n
+		w.store.externalIDs[tx.ExternalID] = n
+		onRollback(func() {
+			w.store.transactions = w.store.transactions[:n]
+			delete(w.store.transactionIDs, tx.ID)
+			delete(w.store.externalIDs, tx.ExternalID)
+		})
+		return nil
+	})
+}
+
+func (w *Wallet) SetTransactionRolledBack(ctx context.Context, tx *domain.Transaction) error {
+	return w.store.update(ctx, func(onRollback func(func())) error {
+		i, ok := w.store.transactionIDs[tx.ID]
+		if !ok {
+			return nil
+		}
+
+		old := w.store.transactions[i]
+		updated := *old
+		updated.RolledBack = true
+		updated.RollbackShortfall = tx.RollbackShortfall
+		updated.UpdatedAt = w.store.now()
+		w.store.transactions[i] = &updated
+		onRollback(func() { w.store.transactions[i] = old })
+		return nil
+	})
+}
+
+// LockTransactionRef does nothing, transactions of the memory storage never run concurrently.
+func (w *Wallet) LockTransactionRef(_ context.Context, _ string) error {
+	return nil
+}
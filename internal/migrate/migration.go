@@ -0,0 +1,132 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const goosePrefix = "-- +goose"
+
+// Migration is a goose SQL migration, named <version>_<description>.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+	// NoTx is set by the NO TRANSACTION annotation, the statements then run outside of a transaction.
+	NoTx bool
+}
+
+// Load reads the migrations in the root of fsys ordered by version.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*Migration, 0, len(names))
+	versions := make(map[int64]string, len(names))
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, err := Parse(name, string(content))
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := versions[migration.Version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, name)
+		}
+		versions[migration.Version] = name
+		res = append(res, migration)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
+
+// Parse splits the migration into statements like goose does: a statement ends with a semicolon at the end
+// of a line, unless it is enclosed in StatementBegin and StatementEnd annotations.
+func Parse(name, content string) (*Migration, error) {
+	prefix := strings.SplitN(path.Base(name), "_", 2)[0]
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version <= 0 || !strings.HasSuffix(name, ".sql") {
+		return nil, fmt.Errorf("%s: migration name must be <version>_<description>.sql", name)
+	}
+
+	migration := &Migration{Version: version, Name: path.Base(name)}
+	var (
+		statements *[]string
+		inBlock    bool
+		buf        strings.Builder
+	)
+
+	flush := func() {
+		if statement := strings.TrimSpace(buf.String()); statement != "" {
+			*statements = append(*statements, statement)
+		}
+		buf.Reset()
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, goosePrefix) {
+			annotation := strings.TrimSpace(strings.TrimPrefix(trimmed, goosePrefix))
+			switch {
+			case annotation == "Up" || annotation == "Down":
+				if inBlock || strings.TrimSpace(buf.String()) != "" {
+					return nil, fmt.Errorf("%s:%d: unterminated statement before %s", name, lineNo, annotation)
+				}
+				statements = &migration.Up
+				if annotation == "Down" {
+					statements = &migration.Down
+				}
+			case annotation == "StatementBegin" && statements != nil && !inBlock:
+				inBlock = true
+			case annotation == "StatementEnd" && inBlock:
+				flush()
+				inBlock = false
+			case annotation == "NO TRANSACTION":
+				migration.NoTx = true
+			default:
+				return nil, fmt.Errorf("%s:%d: unexpected annotation %q", name, lineNo, trimmed)
+			}
+			continue
+		}
+
+		if !inBlock && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		if statements == nil {
+			return nil, fmt.Errorf("%s:%d: statement before the Up annotation", name, lineNo)
+		}
+
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if inBlock || strings.TrimSpace(buf.String()) != "" {
+		return nil, fmt.Errorf("%s: unterminated statement at the end", name)
+	}
+
+	return migration, nil
+}
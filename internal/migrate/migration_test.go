@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+
+	"mascot/migrations"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	content := `-- +goose Up
+CREATE TABLE a (id INT);
+-- a comment
+CREATE INDEX a_idx
+    ON a (id);
+
+-- +goose StatementBegin
+CREATE FUNCTION f() RETURNS INT AS $$
+BEGIN
+    RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE a;
+`
+
+	migration, err := Parse("20221108090000_create_a.sql", content)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	wantUp := []string{
+		"CREATE TABLE a (id INT);",
+		"CREATE INDEX a_idx\n    ON a (id);",
+		"CREATE FUNCTION f() RETURNS INT AS $$\nBEGIN\n    RETURN 1;\nEND;\n$$ LANGUAGE plpgsql;",
+	}
+	if migration.Version != 20221108090000 || !reflect.DeepEqual(migration.Up, wantUp) {
+		t.Errorf("Parse() = %d %q, want %q", migration.Version, migration.Up, wantUp)
+	}
+	if !reflect.DeepEqual(migration.Down, []string{"DROP TABLE a;"}) || migration.NoTx {
+		t.Errorf("Parse() down = %q, no tx %v", migration.Down, migration.NoTx)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "no version", file: "create_a.sql", content: "-- +goose Up\nSELECT 1;\n"},
+		{name: "no up", file: "1_a.sql", content: "SELECT 1;\n"},
+		{name: "unterminated", file: "1_a.sql", content: "-- +goose Up\nSELECT 1\n-- +goose Down\n"},
+		{name: "unterminated block", file: "1_a.sql", content: "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := Parse(tt.file, tt.content); err == nil {
+				t.Errorf("Parse() succeeded")
+			}
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	t.Parallel()
+	list, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(list) == 0 {
+		t.Fatalf("Load() found no migrations")
+	}
+	for i, migration := range list {
+		if len(migration.Up) == 0 || len(migration.Down) == 0 {
+			t.Errorf("%s has no up or down statements", migration.Name)
+		}
+		if i > 0 && list[i-1].Version >= migration.Version {
+			t.Errorf("%s is not ordered after %s", migration.Name, list[i-1].Name)
+		}
+	}
+}
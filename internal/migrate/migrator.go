@@ -0,0 +1,280 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+// versionTable is the goose version table, so the databases migrated by goose and by the runner are interchangeable.
+const versionTable = "goose_db_version"
+
+// migrateLock is the advisory lock key held while migrating, concurrent runners wait for each other.
+const migrateLock = 0x6d696772617465
+
+// ErrNoMigration is returned by Down and Redo when no migration is applied.
+var ErrNoMigration = errors.New("no migration to roll back")
+
+// Status is the state of a migration in the database, AppliedAt is nil while it is pending.
+type Status struct {
+	Migration *Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	conn       *pgxpool.Pool
+	migrations []*Migration
+	logger     *zap.Logger
+}
+
+func NewMigrator(conn *pgxpool.Pool, migrations []*Migration, logger *zap.Logger) *Migrator {
+	return &Migrator{conn: conn, migrations: migrations, logger: logger}
+}
+
+// Up applies the pending migrations in version order. It returns the number of applied migrations.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	var applied int
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			if s.AppliedAt != nil {
+				continue
+			}
+
+			if err := m.run(ctx, conn, s.Migration, true); err != nil {
+				return err
+			}
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the latest applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgx.Conn) error {
+		migration, err := m.latest(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		return m.run(ctx, conn, migration, false)
+	})
+}
+
+// Redo rolls back the latest applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgx.Conn) error {
+		migration, err := m.latest(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err := m.run(ctx, conn, migration, false); err != nil {
+			return err
+		}
+		return m.run(ctx, conn, migration, true)
+	})
+}
+
+// Status returns the state of every migration in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.conn.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	return m.status(ctx, conn.Conn())
+}
+
+// Pending returns the number of migrations not applied to the database.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var pending int
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+// withLock runs f on a connection holding the migration lock, creating the version table if needed.
+func (m *Migrator) withLock(ctx context.Context, f func(conn *pgx.Conn) error) error {
+	pooled, err := m.conn.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer pooled.Release()
+	conn := pooled.Conn()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrateLock); err != nil {
+		return fmt.Errorf("take migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrateLock); err != nil {
+			m.logger.Error("release migration lock", zap.Error(err))
+		}
+	}()
+
+	if err := m.createVersionTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return f(conn)
+}
+
+// createVersionTable creates the goose version table with its initial version 0 if it doesn't exist yet.
+func (m *Migrator) createVersionTable(ctx context.Context, conn *pgx.Conn) error {
+	exists, err := versionTableExists(ctx, conn)
+	if err != nil || exists {
+		return err
+	}
+
+	return conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "CREATE TABLE "+versionTable+" (id SERIAL NOT NULL PRIMARY KEY, "+
+			"version_id BIGINT NOT NULL, is_applied BOOLEAN NOT NULL, tstamp TIMESTAMP DEFAULT now())")
+		if err != nil {
+			return fmt.Errorf("create version table: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, "INSERT INTO "+versionTable+" (version_id, is_applied) VALUES (0, TRUE)")
+		return err
+	})
+}
+
+func versionTableExists(ctx context.Context, conn *pgx.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", versionTable).Scan(&exists)
+	return exists, err
+}
+
+// status matches the migrations with the version table. The latest row of a version tells whether it is
+// applied: older goose versions record a rollback as a row which is not applied, newer ones delete the rows.
+func (m *Migrator) status(ctx context.Context, conn *pgx.Conn) ([]Status, error) {
+	exists, err := versionTableExists(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]*time.Time)
+	if exists {
+		rows, err := conn.Query(ctx, "SELECT version_id, is_applied, tstamp FROM "+versionTable+" ORDER BY id DESC")
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		seen := make(map[int64]bool)
+		for rows.Next() {
+			var (
+				version   int64
+				isApplied bool
+				at        *time.Time
+			)
+			if err := rows.Scan(&version, &isApplied, &at); err != nil {
+				return nil, err
+			}
+
+			if seen[version] {
+				continue
+			}
+			seen[version] = true
+
+			if isApplied {
+				if at == nil {
+					at = &time.Time{}
+				}
+				applied[version] = at
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		res = append(res, Status{Migration: migration, AppliedAt: applied[migration.Version]})
+	}
+
+	return res, nil
+}
+
+// latest returns the applied migration with the highest version.
+func (m *Migrator) latest(ctx context.Context, conn *pgx.Conn) (*Migration, error) {
+	statuses, err := m.status(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].AppliedAt != nil {
+			return statuses[i].Migration, nil
+		}
+	}
+
+	return nil, ErrNoMigration
+}
+
+// run applies or rolls back the migration and records it in the version table, in one transaction
+// unless the migration is marked NO TRANSACTION.
+func (m *Migrator) run(ctx context.Context, conn *pgx.Conn, migration *Migration, up bool) error {
+	statements, record, direction := migration.Down, "DELETE FROM "+versionTable+" WHERE version_id = $1", "down"
+	if up {
+		statements = migration.Up
+		record = "INSERT INTO " + versionTable + " (version_id, is_applied) VALUES ($1, TRUE)"
+		direction = "up"
+	}
+
+	start := time.Now()
+	var err error
+	if migration.NoTx {
+		err = execAll(ctx, conn, statements, record, migration.Version)
+	} else {
+		err = conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+			return execAll(ctx, tx, statements, record, migration.Version)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("migrate %s %s: %w", direction, migration.Name, err)
+	}
+
+	m.logger.Info("migrated", zap.String("direction", direction), zap.String("migration", migration.Name),
+		zap.Duration("took", time.Since(start)))
+	return nil
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+func execAll(ctx context.Context, e execer, statements []string, record string, version int64) error {
+	for _, statement := range statements {
+		if _, err := e.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	_, err := e.Exec(ctx, record, version)
+	return err
+}
@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"mascot/internal/domain"
+)
+
+type Adjustment struct {
+	querier Querier
+}
+
+func NewAdjustment(querier Querier) *Adjustment {
+	return &Adjustment{querier}
+}
+
+const adjustmentColumns = "id, player_name, currency, amount, reason, comment, created_by, reviewed_by, review_comment, " +
+	"status, transaction_id, created_at, reviewed_at"
+
+func scanAdjustment(row pgx.Row) (*domain.Adjustment, error) {
+	a := &domain.Adjustment{}
+	err := row.Scan(
+		&a.ID,
+		&a.PlayerName,
+		&a.Currency,
+		&a.Amount,
+		&a.Reason,
+		&a.Comment,
+		&a.CreatedBy,
+		&a.ReviewedBy,
+		&a.ReviewComment,
+		&a.Status,
+		&a.TransactionID,
+		&a.CreatedAt,
+		&a.ReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// GetAdjustment locks the adjustment for review.
+func (r *Adjustment) GetAdjustment(ctx context.Context, id int64) (*domain.Adjustment, error) {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+adjustmentColumns+" FROM adjustments WHERE id = $1 FOR UPDATE",
+		id,
+	)
+
+	adjustment, err := scanAdjustment(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAdjustmentNotFound
+	}
+
+	return adjustment, err
+}
+
+// GetAdjustments returns up to limit adjustments in the status, oldest first.
+func (r *Adjustment) GetAdjustments(ctx context.Context, status domain.AdjustmentStatus, limit int) ([]*domain.Adjustment, error) {
+	rows, err := r.querier.Conn(ctx).Query(ctx,
+		"SELECT "+adjustmentColumns+" FROM adjustments WHERE status = $1 ORDER BY created_at, id LIMIT $2",
+		status, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.Adjustment
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, adjustment)
+	}
+
+	return res, rows.Err()
+}
+
+func (r *Adjustment) InsertAdjustment(ctx context.Context, adjustment *domain.Adjustment) error {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO adjustments (player_name, currency, amount, reason, comment, created_by, status) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at",
+		adjustment.PlayerName,
+		adjustment.Currency,
+		adjustment.Amount,
+		adjustment.Reason,
+		adjustment.Comment,
+		adjustment.CreatedBy,
+		adjustment.Status,
+	)
+
+	return row.Scan(&adjustment.ID, &adjustment.CreatedAt)
+}
+
+func (r *Adjustment) UpdateAdjustment(ctx context.Context, adjustment *domain.Adjustment) error {
+	_, err := r.querier.Conn(ctx).Exec(ctx,
+		"UPDATE adjustments SET status = $1, reviewed_by = $2, review_comment = $3, reviewed_at = $4, "+
+			"transaction_id = $5 WHERE id = $6",
+		adjustment.Status,
+		adjustment.ReviewedBy,
+		adjustment.ReviewComment,
+		adjustment.ReviewedAt,
+		adjustment.TransactionID,
+		adjustment.ID,
+	)
+
+	return err
+}
+
+func (r *Adjustment) InsertEvent(ctx context.Context, event *domain.AdjustmentEvent) error {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO adjustment_events (adjustment_id, status, actor, comment) "+
+			"VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		event.AdjustmentID, event.Status, event.Actor, event.Comment,
+	)
+
+	return row.Scan(&event.ID, &event.CreatedAt)
+}
+
+func (r *Adjustment) GetEvents(ctx context.Context, adjustmentID int64) ([]*domain.AdjustmentEvent, error) {
+	rows, err := r.querier.Conn(ctx).Query(ctx,
+		"SELECT id, adjustment_id, status, actor, comment, created_at FROM adjustment_events "+
+			"WHERE adjustment_id = $1 ORDER BY id",
+		adjustmentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.AdjustmentEvent
+	for rows.Next() {
+		e := &domain.AdjustmentEvent{}
+		if err := rows.Scan(&e.ID, &e.AdjustmentID, &e.Status, &e.Actor, &e.Comment, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
+
+// GetOperatorReport sums up the adjustments created and reviewed by every operator in [from, to).
+func (r *Adjustment) GetOperatorReport(ctx context.Context, from, to time.Time) ([]*domain.OperatorAdjustments, error) {
+	rows, err := r.querier.Conn(ctx).Query(ctx, `
+WITH created AS (
+    SELECT created_by AS operator, upper(currency) AS currency, count(*) AS created,
+        count(*) FILTER (WHERE status = 'pending') AS pending,
+        coalesce(sum(amount) FILTER (WHERE status = 'applied' AND amount > 0), 0) AS credited,
+        coalesce(-sum(amount) FILTER (WHERE status = 'applied' AND amount < 0), 0) AS debited,
+        0 AS approved, 0 AS rejected
+    FROM adjustments WHERE created_at >= $1 AND created_at < $2
+    GROUP BY 1, 2
+), reviewed AS (
+    SELECT reviewed_by, upper(currency), 0, 0, 0, 0,
+        count(*) FILTER (WHERE status = 'applied'),
+        count(*) FILTER (WHERE status = 'rejected')
+    FROM adjustments WHERE reviewed_by <> '' AND reviewed_at >= $1 AND reviewed_at < $2
+    GROUP BY 1, 2
+)
+SELECT operator, currency, sum(created)::bigint, sum(pending)::bigint, sum(credited)::bigint, sum(debited)::bigint,
+    sum(approved)::bigint, sum(rejected)::bigint
+FROM (SELECT * FROM created UNION ALL SELECT * FROM reviewed) r
+GROUP BY 1, 2 ORDER BY 1, 2`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.OperatorAdjustments
+	for rows.Next() {
+		o := &domain.OperatorAdjustments{}
+		err := rows.Scan(&o.Operator, &o.Currency, &o.Created, &o.Pending, &o.Credited, &o.Debited, &o.Approved, &o.Rejected)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, o)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+
+	"mascot/internal/domain"
+)
+
+const freeRoundsColumns = "id, ref, player_name, game_id, campaign, currency, count, remaining, bet_value, " +
+	"wagering_multiplier, total_win, status, expires_at, created_at, updated_at"
+
+type FreeRounds struct {
+	querier Querier
+}
+
+func NewFreeRounds(querier Querier) *FreeRounds {
+	return &FreeRounds{querier}
+}
+
+func scanFreeRounds(row pgx.Row) (*domain.FreeRounds, error) {
+	f := &domain.FreeRounds{}
+	err := row.Scan(
+		&f.ID,
+		&f.Ref,
+		&f.PlayerName,
+		&f.GameID,
+		&f.Campaign,
+		&f.Currency,
+		&f.Count,
+		&f.Remaining,
+		&f.BetValue,
+		&f.WageringMultiplier,
+		&f.TotalWin,
+		&f.Status,
+		&f.ExpiresAt,
+		&f.CreatedAt,
+		&f.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// GetFreeRounds returns locked free rounds by their reference or nil if they don't exist.
+func (r *FreeRounds) GetFreeRounds(ctx context.Context, ref string) (*domain.FreeRounds, error) {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+freeRoundsColumns+" FROM free_rounds WHERE ref = $1 FOR UPDATE",
+		ref,
+	)
+
+	f, err := scanFreeRounds(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (r *FreeRounds) GetPlayerFreeRounds(ctx context.Context, playerName string) ([]*domain.FreeRounds, error) {
+	rows, err := r.querier.Conn(ctx).Query(ctx,
+		"SELECT "+freeRoundsColumns+" FROM free_rounds WHERE player_name = $1 ORDER BY id",
+		playerName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.FreeRounds
+	for rows.Next() {
+		f, err := scanFreeRounds(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, f)
+	}
+
+	return res, rows.Err()
+}
+
+func (r *FreeRounds) InsertFreeRounds(ctx context.Context, f *domain.FreeRounds) error {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO free_rounds (ref, player_name, game_id, campaign, currency, count, remaining, bet_value, "+
+			"wagering_multiplier, status, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) "+
+			"ON CONFLICT (ref) DO NOTHING RETURNING id, created_at, updated_at",
+		f.Ref,
+		f.PlayerName,
+		f.GameID,
+		f.Campaign,
+		f.Currency,
+		f.Count,
+		f.Remaining,
+		f.BetValue,
+		f.WageringMultiplier,
+		f.Status,
+		f.ExpiresAt,
+	)
+
+	err := row.Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrFreeRoundsExists
+	}
+
+	return err
+}
+
+func (r *FreeRounds) UpdateFreeRounds(ctx context.Context, f *domain.FreeRounds) error {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"UPDATE free_rounds SET remaining = $1, total_win = $2, status = $3, updated_at = now() "+
+			"WHERE id = $4 RETURNING updated_at",
+		f.Remaining, f.TotalWin, f.Status, f.ID,
+	)
+
+	return row.Scan(&f.UpdatedAt)
+}
+
+func (r *FreeRounds) GetCampaignReport(ctx context.Context, campaign string) (*domain.FreeRoundsCampaignReport, error) {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT count(*), count(DISTINCT player_name), coalesce(sum(count), 0)::BIGINT, "+
+			"coalesce(sum(count - remaining), 0)::BIGINT, "+
+			"coalesce(sum(remaining) FILTER (WHERE status = 'active' AND expires_at > now()), 0)::BIGINT, "+
+			"coalesce(sum((count - remaining) * bet_value), 0)::BIGINT, coalesce(sum(total_win), 0)::BIGINT "+
+			"FROM free_rounds WHERE campaign = $1",
+		campaign,
+	)
+
+	report := &domain.FreeRoundsCampaignReport{Campaign: campaign}
+	err := row.Scan(
+		&report.Grants,
+		&report.Players,
+		&report.RoundsGranted,
+		&report.RoundsUsed,
+		&report.RoundsRemaining,
+		&report.TotalBetValue,
+		&report.TotalWin,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
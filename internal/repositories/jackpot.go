@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+
+	"mascot/internal/domain"
+)
+
+const jackpotPoolColumns = "id, name, currency, balance, created_at, updated_at"
+
+type Jackpot struct {
+	querier Querier
+}
+
+func NewJackpot(querier Querier) *Jackpot {
+	return &Jackpot{querier}
+}
+
+func scanJackpotPool(row pgx.Row) (*domain.JackpotPool, error) {
+	pool := &domain.JackpotPool{}
+	if err := row.Scan(&pool.ID, &pool.Name, &pool.Currency, &pool.Balance, &pool.CreatedAt, &pool.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// GetPool returns the locked pool by name.
+func (j *Jackpot) GetPool(ctx context.Context, name string) (*domain.JackpotPool, error) {
+	row := j.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+jackpotPoolColumns+" FROM jackpot_pools WHERE name = $1 FOR UPDATE",
+		name,
+	)
+
+	pool, err := scanJackpotPool(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrJackpotPoolNotFound
+	}
+
+	return pool, err
+}
+
+// GetPoolsByName returns the locked pools ordered by id, so concurrent callers lock them in the same order.
+func (j *Jackpot) GetPoolsByName(ctx context.Context, names []string) ([]*domain.JackpotPool, error) {
+	rows, err := j.querier.Conn(ctx).Query(ctx,
+		"SELECT "+jackpotPoolColumns+" FROM jackpot_pools WHERE name = ANY($1) ORDER BY id FOR UPDATE",
+		names,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.JackpotPool
+	for rows.Next() {
+		pool, err := scanJackpotPool(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, pool)
+	}
+
+	return res, rows.Err()
+}
+
+func (j *Jackpot) GetPools(ctx context.Context) ([]*domain.JackpotPool, error) {
+	rows, err := j.querier.Conn(ctx).Query(ctx, "SELECT "+jackpotPoolColumns+" FROM jackpot_pools ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.JackpotPool
+	for rows.Next() {
+		pool, err := scanJackpotPool(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, pool)
+	}
+
+	return res, rows.Err()
+}
+
+func (j *Jackpot) InsertPool(ctx context.Context, pool *domain.JackpotPool) error {
+	row := j.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO jackpot_pools (name, currency, balance) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (name) DO NOTHING RETURNING id, created_at, updated_at",
+		pool.Name, pool.Currency, pool.Balance,
+	)
+
+	err := row.Scan(&pool.ID, &pool.CreatedAt, &pool.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrJackpotPoolExists
+	}
+
+	return err
+}
+
+func (j *Jackpot) UpdatePoolBalance(ctx context.Context, pool *domain.JackpotPool) error {
+	_, err := j.querier.Conn(ctx).Exec(ctx,
+		"UPDATE jackpot_pools SET balance = $1, updated_at = now() WHERE id = $2",
+		pool.Balance, pool.ID,
+	)
+
+	return err
+}
+
+// GetContributions returns rules matching the currency and game, game specific rules first.
+func (j *Jackpot) GetContributions(ctx context.Context, currency, gameID string) ([]*domain.JackpotContribution, error) {
+	rows, err := j.querier.Conn(ctx).Query(ctx,
+		"SELECT c.id, c.pool_id, p.name, c.game_id, c.currency, c.rate_basis_points FROM jackpot_contributions c "+
+			"JOIN jackpot_pools p ON p.id = c.pool_id WHERE c.currency = $1 AND c.game_id IN ($2, '') "+
+			"ORDER BY c.game_id DESC, c.pool_id",
+		currency, gameID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.JackpotContribution
+	for rows.Next() {
+		c := &domain.JackpotContribution{}
+		if err := rows.Scan(&c.ID, &c.PoolID, &c.PoolName, &c.GameID, &c.Currency, &c.RateBasisPoints); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+
+	return res, rows.Err()
+}
+
+func (j *Jackpot) SaveContribution(ctx context.Context, c *domain.JackpotContribution) error {
+	row := j.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO jackpot_contributions (pool_id, game_id, currency, rate_basis_points) VALUES ($1, $2, $3, $4) "+
+			"ON CONFLICT ON CONSTRAINT jackpot_contributions_pool_game_key "+
+			"DO UPDATE SET rate_basis_points = EXCLUDED.rate_basis_points RETURNING id",
+		c.PoolID, c.GameID, c.Currency, c.RateBasisPoints,
+	)
+
+	return row.Scan(&c.ID)
+}
+
+func (j *Jackpot) InsertLedgerEntry(ctx context.Context, entry *domain.JackpotLedgerEntry) error {
+	row := j.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO jackpot_ledger (pool_id, transaction_id, kind, amount, balance_after) "+
+			"VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		entry.PoolID, entry.TransactionID, entry.Kind, entry.Amount, entry.BalanceAfter,
+	)
+
+	return row.Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (j *Jackpot) GetLedgerEntriesByTransaction(ctx context.Context, transactionID string) ([]*domain.JackpotLedgerEntry, error) {
+	return j.queryLedger(ctx, "WHERE l.transaction_id = $1 ORDER BY l.id", transactionID)
+}
+
+// GetPoolLedger returns up to limit entries of the pool older than beforeID, newest first. Zero beforeID means no bound.
+func (j *Jackpot) GetPoolLedger(ctx context.Context, poolName string, beforeID int64, limit int) ([]*domain.JackpotLedgerEntry, error) {
+	return j.queryLedger(ctx, "WHERE p.name = $1 AND ($2::BIGINT = 0 OR l.id < $2) ORDER BY l.id DESC LIMIT $3",
+		poolName, beforeID, limit,
+	)
+}
+
+func (j *Jackpot) queryLedger(ctx context.Context, where string, args ...interface{}) ([]*domain.JackpotLedgerEntry, error) {
+	rows, err := j.querier.Conn(ctx).Query(ctx, "SELECT l.id, l.pool_id, p.name, l.transaction_id, l.kind, l.amount, "+
+		"l.balance_after, l.created_at FROM jackpot_ledger l JOIN jackpot_pools p ON p.id = l.pool_id "+where,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.JackpotLedgerEntry
+	for rows.Next() {
+		e := &domain.JackpotLedgerEntry{}
+		err := rows.Scan(&e.ID, &e.PoolID, &e.PoolName, &e.TransactionID, &e.Kind, &e.Amount, &e.BalanceAfter, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
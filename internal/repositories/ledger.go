@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+
+	"mascot/internal/domain"
+)
+
+type Ledger struct {
+	querier Querier
+}
+
+func NewLedger(querier Querier) *Ledger {
+	return &Ledger{querier}
+}
+
+func (l *Ledger) InsertEntrySet(ctx context.Context, set *domain.EntrySet) error {
+	for _, entry := range set.Entries {
+		row := l.querier.Conn(ctx).QueryRow(ctx,
+			"INSERT INTO ledger_entries (set_id, transaction_id, kind, account, currency, amount) "+
+				"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at",
+			entry.SetID, entry.TransactionID, entry.Kind, entry.Account, entry.Currency, entry.Amount,
+		)
+
+		if err := row.Scan(&entry.ID, &entry.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Ledger) GetAccountEntries(ctx context.Context, account string, beforeID int64, limit int) ([]*domain.LedgerEntry, error) {
+	rows, err := l.querier.Conn(ctx).Query(ctx, "SELECT id, set_id, transaction_id, kind, account, currency, amount, "+
+		"created_at FROM ledger_entries WHERE account = $1 AND ($2::BIGINT = 0 OR id < $2) ORDER BY id DESC LIMIT $3",
+		account, beforeID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.LedgerEntry
+	for rows.Next() {
+		e := &domain.LedgerEntry{}
+		err := rows.Scan(&e.ID, &e.SetID, &e.TransactionID, &e.Kind, &e.Account, &e.Currency, &e.Amount, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
+
+func (l *Ledger) CountEntrySets(ctx context.Context) (int64, error) {
+	var count int64
+	err := l.querier.Conn(ctx).QueryRow(ctx, "SELECT count(DISTINCT set_id) FROM ledger_entries").Scan(&count)
+	return count, err
+}
+
+// GetUnbalancedSets returns entry sets whose entries don't sum to zero.
+func (l *Ledger) GetUnbalancedSets(ctx context.Context) ([]domain.UnbalancedEntrySet, error) {
+	rows, err := l.querier.Conn(ctx).Query(ctx,
+		"SELECT set_id, sum(amount)::BIGINT FROM ledger_entries GROUP BY set_id HAVING sum(amount) <> 0 ORDER BY set_id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []domain.UnbalancedEntrySet
+	for rows.Next() {
+		var set domain.UnbalancedEntrySet
+		if err := rows.Scan(&set.SetID, &set.Sum); err != nil {
+			return nil, err
+		}
+		res = append(res, set)
+	}
+
+	return res, rows.Err()
+}
+
+// GetProjectionMismatches compares wallet and jackpot pool balances with their ledger accounts.
+func (l *Ledger) GetProjectionMismatches(ctx context.Context) ([]domain.ProjectionMismatch, error) {
+	rows, err := l.querier.Conn(ctx).Query(ctx, `
+WITH accounts AS (
+    SELECT account, sum(amount)::BIGINT AS balance FROM ledger_entries GROUP BY account
+), projections AS (
+    SELECT 'player:' || player_name || ':real' AS account, balance FROM wallets
+    UNION ALL
+    SELECT 'player:' || player_name || ':bonus', bonus_balance FROM wallets
+    UNION ALL
+    SELECT 'player:' || player_name || ':debt', -debt FROM wallets
+    UNION ALL
+    SELECT 'jackpot:' || name, balance FROM jackpot_pools
+)
+SELECT p.account, p.balance, coalesce(a.balance, 0)
+FROM projections p LEFT JOIN accounts a ON a.account = p.account
+WHERE p.balance <> coalesce(a.balance, 0)
+ORDER BY p.account`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []domain.ProjectionMismatch
+	for rows.Next() {
+		var m domain.ProjectionMismatch
+		if err := rows.Scan(&m.Account, &m.Projection, &m.Ledger); err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+
+	return res, rows.Err()
+}
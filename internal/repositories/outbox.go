@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+// outboxDispatchLock is the advisory lock key held by the one dispatcher delivering the outbox.
+const outboxDispatchLock = 0x6f7574626f78
+
+type Outbox struct {
+	querier Querier
+}
+
+func NewOutbox(querier Querier) *Outbox {
+	return &Outbox{querier}
+}
+
+func (o *Outbox) InsertEvent(ctx context.Context, event *domain.Event) error {
+	row := o.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO outbox_events (event_type, player_name, payload) VALUES ($1, $2, $3) RETURNING id, created_at",
+		event.Type, event.PlayerName, string(event.Payload),
+	)
+
+	return row.Scan(&event.ID, &event.CreatedAt)
+}
+
+// TryLockDispatch takes the dispatcher lock until the end of the transaction. It returns false
+// when another dispatcher holds it.
+func (o *Outbox) TryLockDispatch(ctx context.Context) (bool, error) {
+	var locked bool
+	err := o.querier.Conn(ctx).QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", outboxDispatchLock).Scan(&locked)
+	return locked, err
+}
+
+// GetPendingEvents returns up to limit undelivered events in ID order, skipping the wallets
+// whose failed event isn't due again at now.
+func (o *Outbox) GetPendingEvents(ctx context.Context, now time.Time, limit int) ([]*domain.Event, error) {
+	rows, err := o.querier.Conn(ctx).Query(ctx,
+		"SELECT id, event_type, player_name, payload::text, attempts, created_at FROM outbox_events e "+
+			"WHERE dispatched_at IS NULL AND NOT EXISTS (SELECT 1 FROM outbox_events h "+
+			"WHERE h.player_name = e.player_name AND h.dispatched_at IS NULL AND h.next_attempt_at > $1) "+
+			"ORDER BY id LIMIT $2",
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.Event
+	for rows.Next() {
+		e := &domain.Event{}
+		var payload string
+		if err := rows.Scan(&e.ID, &e.Type, &e.PlayerName, &payload, &e.Attempts, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = []byte(payload)
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
+
+func (o *Outbox) MarkDispatched(ctx context.Context, id int64, at time.Time) error {
+	_, err := o.querier.Conn(ctx).Exec(ctx,
+		"UPDATE outbox_events SET dispatched_at = $1, attempts = attempts + 1, last_error = '' WHERE id = $2",
+		at, id,
+	)
+
+	return err
+}
+
+// MarkFailed holds the event and the later events of its wallet back until nextAttemptAt.
+func (o *Outbox) MarkFailed(ctx context.Context, id int64, cause error, nextAttemptAt time.Time) error {
+	_, err := o.querier.Conn(ctx).Exec(ctx,
+		"UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = $3",
+		cause.Error(), nextAttemptAt, id,
+	)
+
+	return err
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"mascot/internal/domain"
+)
+
+type Reservation struct {
+	querier Querier
+}
+
+func NewReservation(querier Querier) *Reservation {
+	return &Reservation{querier}
+}
+
+const reservationColumns = "id, ref, player_name, currency, amount, committed_amount, game_id, round_ref, status, " +
+	"transaction_id, expires_at, created_at, updated_at"
+
+func scanReservation(row pgx.Row) (*domain.Reservation, error) {
+	r := &domain.Reservation{}
+	err := row.Scan(
+		&r.ID,
+		&r.Ref,
+		&r.PlayerName,
+		&r.Currency,
+		&r.Amount,
+		&r.CommittedAmount,
+		&r.GameID,
+		&r.RoundRef,
+		&r.Status,
+		&r.TransactionID,
+		&r.ExpiresAt,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetReservation returns the reservation or nil if it doesn't exist.
+func (r *Reservation) GetReservation(ctx context.Context, ref string) (*domain.Reservation, error) {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+reservationColumns+" FROM reservations WHERE ref = $1 FOR UPDATE",
+		ref,
+	)
+
+	reservation, err := scanReservation(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	return reservation, err
+}
+
+// GetExpiredReservations returns up to limit held reservations which ran out of time before now.
+func (r *Reservation) GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*domain.Reservation, error) {
+	rows, err := r.querier.Conn(ctx).Query(ctx,
+		"SELECT "+reservationColumns+" FROM reservations WHERE status = $1 AND expires_at <= $2 ORDER BY expires_at LIMIT $3",
+		domain.ReservationStatusHeld, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.Reservation
+	for rows.Next() {
+		reservation, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, reservation)
+	}
+
+	return res, rows.Err()
+}
+
+func (r *Reservation) InsertReservation(ctx context.Context, reservation *domain.Reservation) error {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO reservations (ref, player_name, currency, amount, game_id, round_ref, status, expires_at) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, updated_at",
+		reservation.Ref,
+		reservation.PlayerName,
+		reservation.Currency,
+		reservation.Amount,
+		reservation.GameID,
+		reservation.RoundRef,
+		reservation.Status,
+		reservation.ExpiresAt,
+	)
+
+	return row.Scan(&reservation.ID, &reservation.CreatedAt, &reservation.UpdatedAt)
+}
+
+func (r *Reservation) UpdateReservation(ctx context.Context, reservation *domain.Reservation) error {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"UPDATE reservations SET status = $1, committed_amount = $2, transaction_id = $3, updated_at = now() "+
+			"WHERE id = $4 RETURNING updated_at",
+		reservation.Status, reservation.CommittedAmount, reservation.TransactionID, reservation.ID,
+	)
+
+	return row.Scan(&reservation.UpdatedAt)
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+
+	"mascot/internal/domain"
+)
+
+type Round struct {
+	querier Querier
+}
+
+func NewRound(querier Querier) *Round {
+	return &Round{querier}
+}
+
+const roundColumns = "id, player_name, game_id, round_ref, currency, total_bet, total_win, status, " +
+	"created_at, updated_at, finished_at"
+
+// GetRound returns the round locked for update or nil if it doesn't exist.
+func (r *Round) GetRound(ctx context.Context, playerName, gameID, roundRef string) (*domain.Round, error) {
+	return scanRound(r.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+roundColumns+" FROM rounds WHERE player_name = $1 AND game_id = $2 AND round_ref = $3 FOR UPDATE",
+		playerName, gameID, roundRef,
+	))
+}
+
+// ReadRound returns the round without locking it or nil if it doesn't exist.
+func (r *Round) ReadRound(ctx context.Context, playerName, gameID, roundRef string) (*domain.Round, error) {
+	return scanRound(r.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+roundColumns+" FROM rounds WHERE player_name = $1 AND game_id = $2 AND round_ref = $3",
+		playerName, gameID, roundRef,
+	))
+}
+
+func scanRound(row pgx.Row) (*domain.Round, error) {
+	round := &domain.Round{}
+	err := row.Scan(
+		&round.ID,
+		&round.PlayerName,
+		&round.GameID,
+		&round.RoundRef,
+		&round.Currency,
+		&round.TotalBet,
+		&round.TotalWin,
+		&round.Status,
+		&round.CreatedAt,
+		&round.UpdatedAt,
+		&round.FinishedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return round, nil
+}
+
+func (r *Round) SaveRound(ctx context.Context, round *domain.Round) error {
+	row := r.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO rounds (player_name, game_id, round_ref, currency, total_bet, total_win, status, finished_at) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT ON CONSTRAINT rounds_player_game_round_key DO UPDATE "+
+			"SET total_bet = EXCLUDED.total_bet, total_win = EXCLUDED.total_win, status = EXCLUDED.status, "+
+			"finished_at = EXCLUDED.finished_at, updated_at = now() RETURNING id, created_at, updated_at",
+		round.PlayerName,
+		round.GameID,
+		round.RoundRef,
+		round.Currency,
+		round.TotalBet,
+		round.TotalWin,
+		round.Status,
+		round.FinishedAt,
+	)
+
+	return row.Scan(&round.ID, &round.CreatedAt, &round.UpdatedAt)
+}
@@ -3,12 +3,21 @@ package repositories
 import (
 	"context"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 
 	"mascot/internal/domain"
 )
 
+const uniqueViolation = "23505"
+
+// WalletChangedChannel is notified with the player name when a wallet changes, see NotifyWalletChanged.
+const WalletChangedChannel = "wallet_changed"
+
 type Wallet struct {
 	querier Querier
 }
@@ -17,14 +26,86 @@ func NewWallet(querier Querier) *Wallet {
 	return &Wallet{querier}
 }
 
+const walletColumns = "id, player_name, currency, balance, bonus_balance, bonus_wagering_requirement, bonus_wagered, " +
+	"bonus_expires_at, status, debt, rollback_policy, held_balance, operator"
+
+// GetWallet locks the wallet until the end of the transaction.
 func (w *Wallet) GetWallet(ctx context.Context, playerName string) (*domain.Wallet, error) {
-	row := w.querier.Conn(ctx).QueryRow(ctx,
-		"SELECT id, player_name, currency, balance FROM wallets WHERE player_name = $1 FOR UPDATE",
+	return scanWallet(w.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+walletColumns+" FROM wallets WHERE player_name = $1 FOR UPDATE",
+		playerName,
+	))
+}
+
+// ReadWallet returns the wallet without locking it, the result may be stale by the time it is used.
+func (w *Wallet) ReadWallet(ctx context.Context, playerName string) (*domain.Wallet, error) {
+	return scanWallet(w.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+walletColumns+" FROM wallets WHERE player_name = $1",
 		playerName,
+	))
+}
+
+// InsertWallet creates the wallet with its balance as the opening balance reconciliation starts from.
+func (w *Wallet) InsertWallet(ctx context.Context, wallet *domain.Wallet) error {
+	err := w.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO wallets (player_name, currency, balance, opening_balance, status, operator) "+
+			"VALUES ($1, $2, $3, $3, $4, $5) RETURNING id",
+		wallet.UserName,
+		wallet.Currency,
+		wallet.Balance,
+		wallet.Status,
+		wallet.Operator,
+	).Scan(&wallet.ID)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return domain.ErrWalletExists
+	}
+
+	return err
+}
+
+// ListWallets returns up to limit wallets ordered by player name, starting after the given name.
+func (w *Wallet) ListWallets(ctx context.Context, after string, limit int) ([]*domain.Wallet, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx,
+		"SELECT "+walletColumns+" FROM wallets WHERE player_name > $1 ORDER BY player_name LIMIT $2",
+		after, limit,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	var res []*domain.Wallet
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, wallet)
+	}
+
+	return res, rows.Err()
+}
+
+func scanWallet(row pgx.Row) (*domain.Wallet, error) {
 	res := &domain.Wallet{}
-	if err := row.Scan(&res.ID, &res.UserName, &res.Currency, &res.Balance); err != nil {
+	err := row.Scan(
+		&res.ID,
+		&res.UserName,
+		&res.Currency,
+		&res.Balance,
+		&res.Bonus.Balance,
+		&res.Bonus.WageringRequirement,
+		&res.Bonus.Wagered,
+		&res.Bonus.ExpiresAt,
+		&res.Status,
+		&res.Debt,
+		&res.RollbackPolicy,
+		&res.Held,
+		&res.Operator,
+	)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrWalletNotFound
 		}
@@ -34,33 +115,254 @@ func (w *Wallet) GetWallet(ctx context.Context, playerName string) (*domain.Wall
 	return res, nil
 }
 
+// NotifyWalletChanged notifies WalletChangedChannel of the change of the wallet, Postgres delivers the
+// notification once the transaction commits.
+func (w *Wallet) NotifyWalletChanged(ctx context.Context, playerName string) error {
+	_, err := w.querier.Conn(ctx).Exec(ctx, "SELECT pg_notify($1, $2)", WalletChangedChannel, playerName)
+	return err
+}
+
 func (w *Wallet) UpdateBalance(ctx context.Context, wallet *domain.Wallet) error {
 	_, err := w.querier.Conn(ctx).Exec(ctx,
-		"UPDATE wallets SET balance = $1 WHERE player_name = $2",
-		wallet.Balance, wallet.UserName,
+		"UPDATE wallets SET balance = $1, bonus_balance = $2, bonus_wagering_requirement = $3, "+
+			"bonus_wagered = $4, bonus_expires_at = $5, debt = $6, held_balance = $7 WHERE player_name = $8",
+		wallet.Balance,
+		wallet.Bonus.Balance,
+		wallet.Bonus.WageringRequirement,
+		wallet.Bonus.Wagered,
+		wallet.Bonus.ExpiresAt,
+		wallet.Debt,
+		wallet.Held,
+		wallet.UserName,
 	)
 
 	return err
 }
 
-func (w *Wallet) GetTransactionByExternalID(ctx context.Context, externalID string) (*domain.Transaction, error) {
-	row := w.querier.Conn(ctx).QueryRow(ctx, "SELECT id, player_name, withdraw, deposit, "+
-		"currency, external_id, balance_after_commit, rolled_back FROM transactions WHERE  external_id = $1",
-		externalID,
+// GetPlayersWithExpiredBonus returns players whose bonus expired before now but is not forfeited yet.
+func (w *Wallet) GetPlayersWithExpiredBonus(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx, "SELECT player_name FROM wallets WHERE bonus_expires_at <= $1 "+
+		"AND (bonus_balance > 0 OR bonus_wagering_requirement > 0)",
+		now,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var playerName string
+		if err := rows.Scan(&playerName); err != nil {
+			return nil, err
+		}
+		res = append(res, playerName)
+	}
 
-	tx := &domain.Transaction{}
+	return res, rows.Err()
+}
+
+func (w *Wallet) UpdateStatus(ctx context.Context, wallet *domain.Wallet) error {
+	_, err := w.querier.Conn(ctx).Exec(ctx,
+		"UPDATE wallets SET status = $1 WHERE id = $2",
+		wallet.Status, wallet.ID,
+	)
+
+	return err
+}
+
+func (w *Wallet) UpdateRollbackPolicy(ctx context.Context, wallet *domain.Wallet) error {
+	_, err := w.querier.Conn(ctx).Exec(ctx,
+		"UPDATE wallets SET rollback_policy = $1 WHERE id = $2",
+		wallet.RollbackPolicy, wallet.ID,
+	)
+
+	return err
+}
+
+// GetDebtReport returns the wallets owing money, by debt or by a negative balance.
+func (w *Wallet) GetDebtReport(ctx context.Context) ([]*domain.DebtReportEntry, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx, "SELECT player_name, upper(currency), debt, greatest(-balance, 0) "+
+		"FROM wallets WHERE debt > 0 OR balance < 0 ORDER BY player_name",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.DebtReportEntry
+	for rows.Next() {
+		e := &domain.DebtReportEntry{}
+		if err := rows.Scan(&e.PlayerName, &e.Currency, &e.Debt, &e.NegativeBalance); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
+
+func (w *Wallet) InsertStatusChange(ctx context.Context, change *domain.WalletStatusChange) error {
+	row := w.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO wallet_status_changes (wallet_id, from_status, to_status, actor, reason) "+
+			"VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		change.WalletID, change.From, change.To, change.Actor, change.Reason,
+	)
+
+	return row.Scan(&change.ID, &change.CreatedAt)
+}
+
+func (w *Wallet) GetStatusChanges(ctx context.Context, playerName string) ([]*domain.WalletStatusChange, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx, "SELECT c.id, c.wallet_id, w.player_name, c.from_status, "+
+		"c.to_status, c.actor, c.reason, c.created_at FROM wallet_status_changes c "+
+		"JOIN wallets w ON w.id = c.wallet_id WHERE w.player_name = $1 ORDER BY c.id",
+		playerName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.WalletStatusChange
+	for rows.Next() {
+		change := &domain.WalletStatusChange{}
+		err := rows.Scan(
+			&change.ID,
+			&change.WalletID,
+			&change.PlayerName,
+			&change.From,
+			&change.To,
+			&change.Actor,
+			&change.Reason,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, change)
+	}
+
+	return res, rows.Err()
+}
+
+// GetWalletReconciliations returns every wallet with its opening balance and the net of its committed transactions.
+func (w *Wallet) GetWalletReconciliations(ctx context.Context) ([]*domain.WalletReconciliation, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx, `
+SELECT w.id, w.player_name, w.currency, w.opening_balance, w.opening_bonus_balance, w.balance, w.bonus_balance,
+       coalesce(t.real_movement, 0), coalesce(t.bonus_movement, 0), coalesce(t.transactions, 0)
+FROM wallets w LEFT JOIN (
+    SELECT player_name,
+           sum((coalesce(deposit, 0) - deposit_bonus) - (coalesce(withdraw, 0) - withdraw_bonus))::BIGINT AS real_movement,
+           sum(deposit_bonus - withdraw_bonus)::BIGINT AS bonus_movement,
+           count(*) AS transactions
+    FROM transactions WHERE NOT rolled_back GROUP BY player_name
+) t ON t.player_name = w.player_name
+ORDER BY w.id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.WalletReconciliation
+	for rows.Next() {
+		r := &domain.WalletReconciliation{}
+		err := rows.Scan(
+			&r.WalletID,
+			&r.PlayerName,
+			&r.Currency,
+			&r.Opening.Real,
+			&r.Opening.Bonus,
+			&r.Actual.Real,
+			&r.Actual.Bonus,
+			&r.Movements.Real,
+			&r.Movements.Bonus,
+			&r.Transactions,
+		)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+
+	return res, rows.Err()
+}
+
+const transactionColumns = "id, kind, player_name, withdraw, deposit, withdraw_bonus, deposit_bonus, currency, " +
+	"external_id, game_id, round_ref, finished, free_rounds_ref, free_round_spent, balance_after_commit, rolled_back, " +
+	"rollback_shortfall, transfer_ref, lost_bonus_requirement, lost_bonus_wagered, lost_bonus_expires_at, " +
+	"wagering_added, wagered, converted_bonus_balance, converted_bonus_requirement, converted_bonus_wagered, " +
+	"converted_bonus_expires_at, created_at, updated_at"
+
+func scanTransaction(row pgx.Row) (*domain.Transaction, error) {
+	var (
+		tx                                                       = &domain.Transaction{}
+		lostRequirement, lostWagered                             *int64
+		lostExpiresAt, convertedExpiresAt                        *time.Time
+		convertedBalance, convertedRequirement, convertedWagered *int64
+	)
 	err := row.Scan(
 		&tx.ID,
+		&tx.Kind,
 		&tx.PlayerName,
 		&tx.Withdraw,
 		&tx.Deposit,
+		&tx.WithdrawBonus,
+		&tx.DepositBonus,
 		&tx.Currency,
 		&tx.ExternalID,
+		&tx.GameID,
+		&tx.RoundRef,
+		&tx.Finished,
+		&tx.FreeRoundsRef,
+		&tx.FreeRoundSpent,
 		&tx.BalanceAfterCommit,
 		&tx.RolledBack,
+		&tx.RollbackShortfall,
+		&tx.TransferRef,
+		&lostRequirement,
+		&lostWagered,
+		&lostExpiresAt,
+		&tx.WageringAdded,
+		&tx.Wagered,
+		&convertedBalance,
+		&convertedRequirement,
+		&convertedWagered,
+		&convertedExpiresAt,
+		&tx.CreatedAt,
+		&tx.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lostRequirement != nil && lostWagered != nil {
+		tx.LostBonus = &domain.Bonus{
+			WageringRequirement: *lostRequirement,
+			Wagered:             *lostWagered,
+			ExpiresAt:           lostExpiresAt,
+		}
+	}
+
+	if convertedBalance != nil && convertedRequirement != nil && convertedWagered != nil {
+		tx.ConvertedBonus = &domain.Bonus{
+			Balance:             *convertedBalance,
+			WageringRequirement: *convertedRequirement,
+			Wagered:             *convertedWagered,
+			ExpiresAt:           convertedExpiresAt,
+		}
+	}
+
+	return tx, nil
+}
+
+func (w *Wallet) GetTransactionByExternalID(ctx context.Context, externalID string) (*domain.Transaction, error) {
+	row := w.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+transactionColumns+" FROM transactions WHERE  external_id = $1",
+		externalID,
 	)
 
+	tx, err := scanTransaction(row)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
@@ -72,21 +374,149 @@ func (w *Wallet) GetTransactionByExternalID(ctx context.Context, externalID stri
 	return tx, nil
 }
 
-func (w *Wallet) SetTransactionRolledBack(ctx context.Context, txID string) error {
-	_, err := w.querier.Conn(ctx).Exec(ctx, "UPDATE transactions SET rolled_back = TRUE WHERE id = $1", txID)
+func (w *Wallet) GetTransactionsByRound(ctx context.Context, playerName, gameID, roundRef string) ([]*domain.Transaction, error) {
+	return w.queryTransactions(ctx,
+		"SELECT "+transactionColumns+" FROM transactions WHERE player_name = $1 AND game_id = $2 AND round_ref = $3 "+
+			"ORDER BY created_at, id",
+		playerName, gameID, roundRef,
+	)
+}
+
+// ListTransactions returns up to filter.Limit transactions matching the filter, newest first.
+func (w *Wallet) ListTransactions(ctx context.Context, filter domain.TransactionFilter) ([]*domain.Transaction, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.PlayerName != "" {
+		conds = append(conds, "player_name = "+arg(filter.PlayerName))
+	}
+	if filter.Currency != "" {
+		conds = append(conds, "upper(currency) = upper("+arg(filter.Currency)+")")
+	}
+	if filter.From != nil {
+		conds = append(conds, "created_at >= "+arg(*filter.From))
+	}
+	if filter.To != nil {
+		conds = append(conds, "created_at < "+arg(*filter.To))
+	}
+	if filter.GameID != "" {
+		conds = append(conds, "game_id = "+arg(filter.GameID))
+	}
+	if filter.RoundRef != "" {
+		conds = append(conds, "round_ref = "+arg(filter.RoundRef))
+	}
+	if filter.Status != "" {
+		conds = append(conds, "rolled_back = "+arg(filter.Status == domain.TransactionStatusRolledBack))
+	}
+	if filter.After != nil {
+		conds = append(conds, "(created_at, id) < ("+arg(filter.After.CreatedAt)+", "+arg(filter.After.ID)+")")
+	}
+
+	query := "SELECT " + transactionColumns + " FROM transactions"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT " + arg(filter.Limit)
+
+	return w.queryTransactions(ctx, query, args...)
+}
+
+func (w *Wallet) queryTransactions(ctx context.Context, query string, args ...interface{}) ([]*domain.Transaction, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, tx)
+	}
+
+	return res, rows.Err()
+}
+
+func (w *Wallet) SetTransactionRolledBack(ctx context.Context, tx *domain.Transaction) error {
+	_, err := w.querier.Conn(ctx).Exec(ctx, "UPDATE transactions SET rolled_back = TRUE, rollback_shortfall = $1, "+
+		"updated_at = now() WHERE id = $2",
+		tx.RollbackShortfall, tx.ID,
+	)
 	return err
 }
 
 func (w *Wallet) InsertTransaction(ctx context.Context, tx *domain.Transaction) error {
+	var (
+		lostRequirement, lostWagered *int64
+		lostExpiresAt                *time.Time
+	)
+	if tx.LostBonus != nil {
+		lostRequirement, lostWagered = &tx.LostBonus.WageringRequirement, &tx.LostBonus.Wagered
+		lostExpiresAt = tx.LostBonus.ExpiresAt
+	}
+	var (
+		convertedBalance, convertedRequirement, convertedWagered *int64
+		convertedExpiresAt                                       *time.Time
+	)
+	if tx.ConvertedBonus != nil {
+		convertedBalance, convertedRequirement = &tx.ConvertedBonus.Balance, &tx.ConvertedBonus.WageringRequirement
+		convertedWagered, convertedExpiresAt = &tx.ConvertedBonus.Wagered, tx.ConvertedBonus.ExpiresAt
+	}
+
 	_, err := w.querier.Conn(ctx).Exec(ctx,
-		"INSERT INTO transactions (id, player_name, withdraw, deposit, currency, external_id, rolled_back) "+
-			"VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING",
-		tx.ID, tx.PlayerName, tx.Withdraw, tx.Deposit, tx.Currency, tx.ExternalID, tx.RolledBack,
+		"INSERT INTO transactions (id, kind, player_name, withdraw, deposit, withdraw_bonus, deposit_bonus, "+
+			"currency, external_id, game_id, round_ref, finished, free_rounds_ref, free_round_spent, balance_after_commit, "+
+			"rolled_back, transfer_ref, lost_bonus_requirement, lost_bonus_wagered, lost_bonus_expires_at, wagering_added, "+
+			"wagered, converted_bonus_balance, converted_bonus_requirement, converted_bonus_wagered, "+
+			"converted_bonus_expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, "+
+			"$17, $18, $19, $20, $21, $22, $23, $24, $25, $26)",
+		tx.ID,
+		tx.Kind,
+		tx.PlayerName,
+		tx.Withdraw,
+		tx.Deposit,
+		tx.WithdrawBonus,
+		tx.DepositBonus,
+		tx.Currency,
+		tx.ExternalID,
+		tx.GameID,
+		tx.RoundRef,
+		tx.Finished,
+		tx.FreeRoundsRef,
+		tx.FreeRoundSpent,
+		tx.BalanceAfterCommit,
+		tx.RolledBack,
+		tx.TransferRef,
+		lostRequirement,
+		lostWagered,
+		lostExpiresAt,
+		tx.WageringAdded,
+		tx.Wagered,
+		convertedBalance,
+		convertedRequirement,
+		convertedWagered,
+		convertedExpiresAt,
 	)
 
-	if err != nil {
-		return err
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return domain.ErrTransactionExists
 	}
 
-	return nil
+	return err
+}
+
+// LockTransactionRef serializes db transactions handling the same external id until the current one ends.
+func (w *Wallet) LockTransactionRef(ctx context.Context, externalID string) error {
+	_, err := w.querier.Conn(ctx).Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", externalID)
+	return err
 }
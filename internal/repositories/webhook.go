@@ -0,0 +1,293 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"mascot/internal/domain"
+)
+
+type Webhook struct {
+	querier Querier
+}
+
+func NewWebhook(querier Querier) *Webhook {
+	return &Webhook{querier}
+}
+
+const subscriptionColumns = "id, operator, url, event_types, secret, active, created_at"
+
+func scanSubscription(row pgx.Row) (*domain.WebhookSubscription, error) {
+	s := &domain.WebhookSubscription{}
+	var eventTypes []string
+	if err := row.Scan(&s.ID, &s.Operator, &s.URL, &eventTypes, &s.Secret, &s.Active, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	for _, t := range eventTypes {
+		s.EventTypes = append(s.EventTypes, domain.EventType(t))
+	}
+
+	return s, nil
+}
+
+func (w *Webhook) InsertSubscription(ctx context.Context, s *domain.WebhookSubscription) error {
+	eventTypes := make([]string, 0, len(s.EventTypes))
+	for _, t := range s.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+
+	row := w.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO webhook_subscriptions (operator, url, event_types, secret, active) "+
+			"VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		s.Operator, s.URL, eventTypes, s.Secret, s.Active,
+	)
+
+	return row.Scan(&s.ID, &s.CreatedAt)
+}
+
+func (w *Webhook) GetSubscription(ctx context.Context, id int64) (*domain.WebhookSubscription, error) {
+	row := w.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id = $1",
+		id,
+	)
+
+	s, err := scanSubscription(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrWebhookSubscriptionNotFound
+	}
+
+	return s, err
+}
+
+// GetPlayerSubscriptions returns the subscriptions of the operator of the player's wallet.
+func (w *Webhook) GetPlayerSubscriptions(ctx context.Context, playerName string) ([]*domain.WebhookSubscription, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx,
+		"SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE operator = "+
+			"(SELECT operator FROM wallets WHERE player_name = $1) ORDER BY id",
+		playerName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+func scanSubscriptions(rows pgx.Rows) ([]*domain.WebhookSubscription, error) {
+	defer rows.Close()
+
+	var res []*domain.WebhookSubscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+
+	return res, rows.Err()
+}
+
+// GetSubscriptions returns the subscriptions of the operator, of every operator if it is empty.
+func (w *Webhook) GetSubscriptions(ctx context.Context, operator string) ([]*domain.WebhookSubscription, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx,
+		"SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE $1 = '' OR operator = $1 ORDER BY id",
+		operator,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+func (w *Webhook) SetSubscriptionActive(ctx context.Context, id int64, active bool) error {
+	tag, err := w.querier.Conn(ctx).Exec(ctx,
+		"UPDATE webhook_subscriptions SET active = $1 WHERE id = $2",
+		active, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrWebhookSubscriptionNotFound
+	}
+
+	return nil
+}
+
+const deliveryColumns = "id, subscription_id, event_id, event_type, payload::text, status, attempts, next_attempt_at, " +
+	"last_error, created_at, delivered_at, replay_of"
+
+func scanDelivery(row pgx.Row) (*domain.WebhookDelivery, error) {
+	d := &domain.WebhookDelivery{}
+	var payload string
+	err := row.Scan(
+		&d.ID,
+		&d.SubscriptionID,
+		&d.EventID,
+		&d.EventType,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastError,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+		&d.ReplayOf,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Payload = []byte(payload)
+	return d, nil
+}
+
+// InsertDelivery queues the delivery. A delivery of an event already queued for the subscription is skipped,
+// leaving the id zero, so that publishing an event again doesn't deliver it twice. Replays are always queued.
+func (w *Webhook) InsertDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	row := w.querier.Conn(ctx).QueryRow(ctx,
+		"INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, "+
+			"replay_of) VALUES ($1, $2, $3, $4, $5, $6, $7) "+
+			"ON CONFLICT (subscription_id, event_id) WHERE replay_of IS NULL DO NOTHING RETURNING id, created_at",
+		d.SubscriptionID, d.EventID, d.EventType, string(d.Payload), d.Status, d.NextAttemptAt, d.ReplayOf,
+	)
+
+	err := row.Scan(&d.ID, &d.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+
+	return err
+}
+
+// GetDelivery locks the delivery until the end of the transaction.
+func (w *Webhook) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	row := w.querier.Conn(ctx).QueryRow(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = $1 FOR UPDATE",
+		id,
+	)
+
+	d, err := scanDelivery(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+
+	return d, err
+}
+
+// GetDueDeliveries locks up to limit of the oldest pending deliveries due at now which no other sender holds.
+func (w *Webhook) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE status = $1 AND next_attempt_at <= $2 "+
+			"ORDER BY next_attempt_at, id LIMIT $3 FOR UPDATE SKIP LOCKED",
+		domain.WebhookDeliveryStatusPending, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+
+	return res, rows.Err()
+}
+
+// GetDeliveries returns up to limit deliveries of the subscription with ID below beforeID, newest first.
+// An empty status matches every delivery, beforeID 0 starts from the newest one.
+func (w *Webhook) GetDeliveries(
+	ctx context.Context,
+	subscriptionID int64,
+	status domain.WebhookDeliveryStatus,
+	beforeID int64,
+	limit int,
+) ([]*domain.WebhookDelivery, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE subscription_id = $1 AND ($2 = '' OR status = $2) "+
+			"AND ($3 = 0 OR id < $3) ORDER BY id DESC LIMIT $4",
+		subscriptionID, status, beforeID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+
+	return res, rows.Err()
+}
+
+func (w *Webhook) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	_, err := w.querier.Conn(ctx).Exec(ctx,
+		"UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, "+
+			"delivered_at = $5 WHERE id = $6",
+		d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.DeliveredAt, d.ID,
+	)
+
+	return err
+}
+
+func (w *Webhook) InsertDeadLetter(ctx context.Context, d *domain.WebhookDelivery) error {
+	_, err := w.querier.Conn(ctx).Exec(ctx,
+		"INSERT INTO webhook_dead_letters (delivery_id, subscription_id, event_type, attempts, last_error) "+
+			"VALUES ($1, $2, $3, $4, $5)",
+		d.ID, d.SubscriptionID, d.EventType, d.Attempts, d.LastError,
+	)
+
+	return err
+}
+
+// GetDeadLetters returns up to limit dead letters which weren't replayed yet, oldest first.
+func (w *Webhook) GetDeadLetters(ctx context.Context, limit int) ([]*domain.WebhookDeadLetter, error) {
+	rows, err := w.querier.Conn(ctx).Query(ctx,
+		"SELECT id, delivery_id, subscription_id, event_type, attempts, last_error, created_at, replayed_at "+
+			"FROM webhook_dead_letters WHERE replayed_at IS NULL ORDER BY id LIMIT $1",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*domain.WebhookDeadLetter
+	for rows.Next() {
+		l := &domain.WebhookDeadLetter{}
+		err := rows.Scan(&l.ID, &l.DeliveryID, &l.SubscriptionID, &l.EventType, &l.Attempts, &l.LastError,
+			&l.CreatedAt, &l.ReplayedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+
+	return res, rows.Err()
+}
+
+func (w *Webhook) MarkDeadLetterReplayed(ctx context.Context, deliveryID int64, at time.Time) error {
+	_, err := w.querier.Conn(ctx).Exec(ctx,
+		"UPDATE webhook_dead_letters SET replayed_at = $1 WHERE delivery_id = $2 AND replayed_at IS NULL",
+		at, deliveryID,
+	)
+
+	return err
+}
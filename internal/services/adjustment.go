@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+const pendingAdjustmentsLimit = 1000
+
+// Adjustment runs the maker-checker workflow of manual balance adjustments. Adjustments up to the approval
+// threshold are applied when created, the others wait for a second operator.
+type Adjustment struct {
+	transactor        *db.Transactor
+	adjustmentRepo    *repositories.Adjustment
+	wallet            *Wallet
+	approvalThreshold int64
+}
+
+func NewAdjustment(transactor *db.Transactor, adjustmentRepo *repositories.Adjustment, wallet *Wallet, approvalThreshold int64) *Adjustment {
+	return &Adjustment{
+		transactor:        transactor,
+		adjustmentRepo:    adjustmentRepo,
+		wallet:            wallet,
+		approvalThreshold: approvalThreshold,
+	}
+}
+
+func (a *Adjustment) CreateAdjustment(ctx context.Context, adjustment *domain.Adjustment) error {
+	if err := adjustment.Validate(); err != nil {
+		return err
+	}
+
+	return a.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		wallet, err := a.wallet.walletRepo.GetWallet(tCtx, adjustment.PlayerName)
+		if err != nil {
+			return err
+		}
+
+		if adjustment.Currency != "" && !strings.EqualFold(adjustment.Currency, wallet.Currency) {
+			return domain.ErrIllegalCurrency
+		}
+
+		adjustment.Currency = wallet.Currency
+		adjustment.Status = domain.AdjustmentStatusPending
+		if err := a.adjustmentRepo.InsertAdjustment(tCtx, adjustment); err != nil {
+			return err
+		}
+
+		if err := a.insertEvent(tCtx, adjustment, adjustment.CreatedBy, adjustment.Comment); err != nil {
+			return err
+		}
+
+		if adjustment.NeedsApproval(a.approvalThreshold) {
+			return nil
+		}
+
+		adjustment.Status = domain.AdjustmentStatusApplied
+		return a.apply(tCtx, wallet, adjustment, adjustment.CreatedBy, "below approval threshold")
+	})
+}
+
+// ReviewAdjustment approves or rejects the pending adjustment. An approved adjustment is applied
+// to the wallet at once, it stays pending if that fails.
+func (a *Adjustment) ReviewAdjustment(ctx context.Context, id int64, reviewer, comment string, approve bool) (*domain.Adjustment, error) {
+	var adjustment *domain.Adjustment
+	err := a.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		var err error
+		adjustment, err = a.adjustmentRepo.GetAdjustment(tCtx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := adjustment.Review(reviewer, comment, approve, a.wallet.now()); err != nil {
+			return err
+		}
+
+		if !approve {
+			if err := a.adjustmentRepo.UpdateAdjustment(tCtx, adjustment); err != nil {
+				return err
+			}
+			return a.insertEvent(tCtx, adjustment, reviewer, comment)
+		}
+
+		wallet, err := a.wallet.walletRepo.GetWallet(tCtx, adjustment.PlayerName)
+		if err != nil {
+			return err
+		}
+
+		return a.apply(tCtx, wallet, adjustment, reviewer, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustment, nil
+}
+
+func (a *Adjustment) GetPendingAdjustments(ctx context.Context) ([]*domain.Adjustment, error) {
+	return a.adjustmentRepo.GetAdjustments(ctx, domain.AdjustmentStatusPending, pendingAdjustmentsLimit)
+}
+
+func (a *Adjustment) GetAdjustmentEvents(ctx context.Context, id int64) ([]*domain.AdjustmentEvent, error) {
+	return a.adjustmentRepo.GetEvents(ctx, id)
+}
+
+func (a *Adjustment) GetOperatorReport(ctx context.Context, from, to time.Time) ([]*domain.OperatorAdjustments, error) {
+	return a.adjustmentRepo.GetOperatorReport(db.PreferReplica(ctx), from, to)
+}
+
+// apply books the adjustment on the locked wallet and records who applied it.
+func (a *Adjustment) apply(ctx context.Context, wallet *domain.Wallet, adjustment *domain.Adjustment, actor, comment string) error {
+	tx, err := a.wallet.adjust(ctx, wallet, adjustment.Amount)
+	if err != nil {
+		return err
+	}
+
+	adjustment.TransactionID = tx.ID
+	if err := a.adjustmentRepo.UpdateAdjustment(ctx, adjustment); err != nil {
+		return err
+	}
+
+	return a.insertEvent(ctx, adjustment, actor, comment)
+}
+
+func (a *Adjustment) insertEvent(ctx context.Context, adjustment *domain.Adjustment, actor, comment string) error {
+	return a.adjustmentRepo.InsertEvent(ctx, &domain.AdjustmentEvent{
+		AdjustmentID: adjustment.ID,
+		Status:       adjustment.Status,
+		Actor:        actor,
+		Comment:      comment,
+	})
+}
+
+// adjust applies a manual adjustment to the real balance of the locked wallet. Like any credit a positive
+// adjustment recovers debt.
+func (w *Wallet) adjust(ctx context.Context, wallet *domain.Wallet, amount int64) (*domain.Transaction, error) {
+	if err := wallet.Adjust(amount); err != nil {
+		return nil, err
+	}
+
+	if err := w.currencyLimits(wallet.Currency).CheckBalance(wallet, amount > 0); err != nil {
+		return nil, err
+	}
+	recovered := wallet.RecoverDebt(amount)
+
+	var withdraw, deposit int64
+	if amount < 0 {
+		withdraw = -amount
+	} else {
+		deposit = amount
+	}
+
+	tx, err := w.insertSystemTransaction(ctx, wallet, domain.TransactionKindAdjustment, withdraw, deposit, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.insertDebtRecovery(ctx, wallet, recovered); err != nil {
+		return nil, err
+	}
+
+	return tx, w.updateBalance(ctx, wallet)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"mascot/internal/domain"
+)
+
+// Alerter is notified about reconciliation reports with discrepancies.
+type Alerter interface {
+	Alert(ctx context.Context, report *domain.ReconciliationReport) error
+}
+
+type AlerterFunc func(ctx context.Context, report *domain.ReconciliationReport) error
+
+func (f AlerterFunc) Alert(ctx context.Context, report *domain.ReconciliationReport) error {
+	return f(ctx, report)
+}
+
+// WebhookAlerter posts the mismatched wallets of a report as JSON to url.
+type WebhookAlerter struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookAlerter(url string, client *http.Client) *WebhookAlerter {
+	return &WebhookAlerter{url: url, client: client}
+}
+
+func (a *WebhookAlerter) Alert(ctx context.Context, report *domain.ReconciliationReport) error {
+	body, err := json.Marshal(newReconciliationReport(report, true))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected webhook status %d", resp.StatusCode)
+	}
+
+	return nil
+}
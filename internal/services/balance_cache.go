@@ -0,0 +1,133 @@
+package services
+
+import (
+	"container/list"
+	"expvar"
+	"sync"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+// balanceCacheMetrics counts the lookups and invalidations of balance caches, served as expvar "balance_cache".
+var balanceCacheMetrics = expvar.NewMap("balance_cache")
+
+// BalanceCache is a bounded LRU cache of wallets for balance reads. Entries live at most ttl and are
+// invalidated when a write to the wallet commits, by this instance through db.AfterCommit and by the others
+// through the notifications of repositories.WalletChangedChannel the write sends. The cache serves nothing while it doesn't
+// receive the notifications, as it could miss invalidations.
+type BalanceCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	listening bool
+	lru       *list.List
+	entries   map[string]*list.Element
+	// ticket identifies a miss, the wallet read for it is only stored if no invalidation came in between.
+	ticket uint64
+}
+
+type balanceCacheEntry struct {
+	playerName string
+	// wallet is nil while the miss which created the entry is being read.
+	wallet    *domain.Wallet
+	ticket    uint64
+	expiresAt time.Time
+}
+
+func NewBalanceCache(size int, ttl time.Duration) *BalanceCache {
+	return &BalanceCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// Get returns a copy of the cached wallet. On a miss it returns nil and the ticket to Fill the cache with.
+func (c *BalanceCache) Get(playerName string) (*domain.Wallet, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.listening {
+		balanceCacheMetrics.Add("misses", 1)
+		return nil, 0
+	}
+
+	now := c.now()
+	if elem, ok := c.entries[playerName]; ok {
+		entry := elem.Value.(*balanceCacheEntry)
+		if entry.wallet != nil && now.Before(entry.expiresAt) {
+			balanceCacheMetrics.Add("hits", 1)
+			c.lru.MoveToFront(elem)
+			wallet := *entry.wallet
+			return &wallet, 0
+		}
+		c.remove(elem)
+	}
+
+	balanceCacheMetrics.Add("misses", 1)
+	c.ticket++
+	c.entries[playerName] = c.lru.PushFront(&balanceCacheEntry{playerName: playerName, ticket: c.ticket})
+	for c.lru.Len() > c.size {
+		balanceCacheMetrics.Add("evictions", 1)
+		c.remove(c.lru.Back())
+	}
+
+	return nil, c.ticket
+}
+
+// Fill stores the wallet read for the miss of the ticket, unless the wallet was invalidated since.
+func (c *BalanceCache) Fill(wallet *domain.Wallet, ticket uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[wallet.UserName]
+	if !ok || ticket == 0 {
+		return
+	}
+
+	entry := elem.Value.(*balanceCacheEntry)
+	if entry.ticket != ticket {
+		return
+	}
+
+	stored := *wallet
+	entry.wallet = &stored
+	entry.expiresAt = c.now().Add(c.ttl)
+}
+
+// Invalidate drops the wallet of the player, including a miss being read for it.
+func (c *BalanceCache) Invalidate(playerName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[playerName]; ok {
+		balanceCacheMetrics.Add("invalidations", 1)
+		c.remove(elem)
+	}
+}
+
+// Listening implements db.NotificationHandler. Invalidations may have been missed on either change,
+// so the cache is emptied.
+func (c *BalanceCache) Listening(on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.listening = on
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element, c.size)
+}
+
+// Notify implements db.NotificationHandler, the payload is the name of the changed wallet.
+func (c *BalanceCache) Notify(playerName string) {
+	c.Invalidate(playerName)
+}
+
+func (c *BalanceCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*balanceCacheEntry).playerName)
+}
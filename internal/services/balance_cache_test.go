@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+func TestBalanceCache(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	cache := NewBalanceCache(2, time.Second)
+	cache.now = func() time.Time { return now }
+
+	wallet := func(name string, balance int64) *domain.Wallet {
+		return &domain.Wallet{UserName: name, Currency: "EUR", Balance: balance}
+	}
+	fill := func(w *domain.Wallet) {
+		t.Helper()
+		if cached, ticket := cache.Get(w.UserName); cached != nil {
+			t.Fatalf("Get(%s) hit before fill", w.UserName)
+		} else {
+			cache.Fill(w, ticket)
+		}
+	}
+	balance := func(name string) int64 {
+		t.Helper()
+		cached, _ := cache.Get(name)
+		if cached == nil {
+			return -1
+		}
+		return cached.Balance
+	}
+
+	fill(wallet("a", 1))
+	if got := balance("a"); got != -1 {
+		t.Errorf("balance before listening = %d, want miss", got)
+	}
+
+	cache.Listening(true)
+	fill(wallet("a", 1))
+	if got := balance("a"); got != 1 {
+		t.Errorf("balance = %d, want 1", got)
+	}
+
+	// a wallet invalidated while it is read is not stored
+	_, ticket := cache.Get("b")
+	cache.Notify("b")
+	cache.Fill(wallet("b", 2), ticket)
+	if got := balance("b"); got != -1 {
+		t.Errorf("balance invalidated during the read = %d, want miss", got)
+	}
+
+	// "a" is the least recently used of three
+	fill(wallet("b", 2))
+	fill(wallet("c", 3))
+	if got := balance("a"); got != -1 {
+		t.Errorf("evicted balance = %d, want miss", got)
+	}
+
+	now = now.Add(time.Second)
+	if got := balance("c"); got != -1 {
+		t.Errorf("expired balance = %d, want miss", got)
+	}
+
+	fill(wallet("c", 3))
+	cache.Listening(false)
+	if got := balance("c"); got != -1 {
+		t.Errorf("balance after listening stopped = %d, want miss", got)
+	}
+}
+
+// changeRecorder records the wallet change notifications of a store.
+type changeRecorder struct {
+	changed []string
+}
+
+func (r *changeRecorder) Listening(bool) {}
+
+func (r *changeRecorder) Notify(playerName string) {
+	r.changed = append(r.changed, playerName)
+}
+
+func TestWallet_BalanceCacheInvalidation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := newMemoryStore(t, "player", 100)
+	recorder := &changeRecorder{}
+	store.ListenWalletChanged(recorder)
+
+	// two instances of the engine on one storage, each with a cache of its own
+	instances := make([]*Wallet, 2)
+	for i := range instances {
+		cache := NewBalanceCache(10, time.Hour)
+		store.ListenWalletChanged(cache)
+		instances[i] = newStoreWallet(store, WithBalanceCache(cache))
+	}
+	balance := func(w *Wallet) int64 {
+		t.Helper()
+		b, err := w.GetBalance(ctx, "player", "USD")
+		if err != nil {
+			t.Fatalf("GetBalance() error = %v", err)
+		}
+		return b.Real
+	}
+	for _, w := range instances {
+		if got := balance(w); got != 100 {
+			t.Fatalf("balance = %d, want 100", got)
+		}
+	}
+
+	bet := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(30), Deposit: int64Ptr(0),
+	}
+	if err := instances[0].WithdrawAndDeposit(ctx, bet); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	for i, w := range instances {
+		if got := balance(w); got != 70 {
+			t.Errorf("balance on instance %d = %d, want 70", i, got)
+		}
+	}
+
+	win := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "win", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(0), Deposit: int64Ptr(50),
+	}
+	if err := instances[1].WithdrawAndDeposit(ctx, win); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if got := balance(instances[0]); got != 120 {
+		t.Errorf("balance after a win on the other instance = %d, want 120", got)
+	}
+
+	notified := len(recorder.changed)
+	if _, err := instances[1].ChangeStatus(ctx, "player", domain.WalletStatusSuspended, "alice", "check"); err != nil {
+		t.Fatalf("ChangeStatus() error = %v", err)
+	}
+	if len(recorder.changed) != notified+1 {
+		t.Errorf("notifications of a status change = %v, want the player", recorder.changed[notified:])
+	}
+
+	// without a cache in the deployment, writes notify nothing
+	notified = len(recorder.changed)
+	uncached := newStoreWallet(store)
+	if err := uncached.SetRollbackPolicy(ctx, "player", domain.RollbackPolicyDebt); err != nil {
+		t.Fatalf("SetRollbackPolicy() error = %v", err)
+	}
+	if len(recorder.changed) != notified {
+		t.Errorf("notifications without a cache = %v, want none", recorder.changed[notified:])
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+func (w *Wallet) GrantBonus(ctx context.Context, playerName string, amount, wageringRequirement int64, expiresAt *time.Time) (*domain.Transaction, error) {
+	var grant *domain.Transaction
+	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		wallet, err := w.walletRepo.GetWallet(tCtx, playerName)
+		if err != nil {
+			return err
+		}
+
+		if err := w.expireBonus(tCtx, wallet); err != nil {
+			return err
+		}
+
+		if err := wallet.GrantBonus(amount, wageringRequirement, expiresAt); err != nil {
+			return err
+		}
+
+		if err := w.currencyLimits(wallet.Currency).CheckBalance(wallet, true); err != nil {
+			return err
+		}
+
+		grant, err = w.insertSystemTransaction(tCtx, wallet, domain.TransactionKindBonusGrant, 0, amount, 0, amount)
+		if err != nil {
+			return err
+		}
+
+		return w.updateBalance(tCtx, wallet)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+// ExpireBonuses forfeits every bonus whose time ran out. It returns the number of expired bonuses.
+func (w *Wallet) ExpireBonuses(ctx context.Context) (int, error) {
+	playerNames, err := w.walletRepo.GetPlayersWithExpiredBonus(ctx, w.now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, playerName := range playerNames {
+		err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+			wallet, err := w.walletRepo.GetWallet(tCtx, playerName)
+			if err != nil {
+				return err
+			}
+
+			if err := w.expireBonus(tCtx, wallet); err != nil {
+				return err
+			}
+
+			return w.updateBalance(tCtx, wallet)
+		})
+		if err != nil {
+			return 0, fmt.Errorf("expire bonus of %s: %w", playerName, err)
+		}
+	}
+
+	return len(playerNames), nil
+}
+
+// expireBonus forfeits the expired bonus of the locked wallet and records it.
+func (w *Wallet) expireBonus(ctx context.Context, wallet *domain.Wallet) error {
+	forfeited := wallet.ExpireBonus(w.now())
+	if forfeited == 0 {
+		return nil
+	}
+
+	_, err := w.insertSystemTransaction(ctx, wallet, domain.TransactionKindBonusExpiry, forfeited, 0, forfeited, 0)
+	return err
+}
+
+// convertBonus converts the bonus of the locked wallet to real money once wagering is met. It returns
+// the converted bonus, nil if there is none, which the transaction meeting the wagering keeps for its rollback.
+func convertBonus(wallet *domain.Wallet) *domain.Bonus {
+	bonus := wallet.Bonus
+	if wallet.ConvertBonus() == 0 {
+		return nil
+	}
+
+	return &bonus
+}
+
+// insertBonusConversion records the conversion of the bonus, or its reversal by the rollback of the transaction
+// which met the wagering.
+func (w *Wallet) insertBonusConversion(ctx context.Context, wallet *domain.Wallet, converted *domain.Bonus, reversal bool) error {
+	if converted == nil {
+		return nil
+	}
+
+	amount := converted.Balance
+	if reversal {
+		_, err := w.insertSystemTransaction(ctx, wallet, domain.TransactionKindBonusConversionReversal, amount, amount, 0, amount)
+		return err
+	}
+
+	_, err := w.insertSystemTransaction(ctx, wallet, domain.TransactionKindBonusConversion, amount, amount, amount, 0)
+	return err
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+func TestWallet_Bonus(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	w := newMemoryWallet(t, "player", 100)
+	w.now = func() time.Time { return now }
+
+	balance := func() domain.Balance {
+		t.Helper()
+		b, err := w.GetBalance(ctx, "player", "USD")
+		if err != nil {
+			t.Fatalf("GetBalance() error = %v", err)
+		}
+		return b
+	}
+	bet := func(externalID string, withdraw, deposit int64) {
+		t.Helper()
+		tx := &domain.Transaction{
+			PlayerName: "player", Currency: "USD", ExternalID: externalID, GameID: "g", RoundRef: externalID,
+			Withdraw: int64Ptr(withdraw), Deposit: int64Ptr(deposit),
+		}
+		if err := w.WithdrawAndDeposit(ctx, tx); err != nil {
+			t.Fatalf("WithdrawAndDeposit(%s) error = %v", externalID, err)
+		}
+	}
+
+	expiresAt := now.Add(time.Hour)
+	if _, err := w.GrantBonus(ctx, "player", 50, 200, &expiresAt); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		externalID string
+		withdraw   int64
+		deposit    int64
+		want       domain.Balance
+	}{
+		{name: "real money first, the rest from the bonus", externalID: "bet-1", withdraw: 120, want: domain.Balance{Bonus: 30}},
+		{name: "a win goes to the real balance", externalID: "win-1", deposit: 100, want: domain.Balance{Real: 100, Bonus: 30}},
+		{name: "wagering met converts the bonus", externalID: "bet-2", withdraw: 80, want: domain.Balance{Real: 50}},
+	}
+	for _, tt := range tests {
+		bet(tt.externalID, tt.withdraw, tt.deposit)
+		if got := balance(); got != tt.want {
+			t.Errorf("%s: balance = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := w.GrantBonus(ctx, "player", 40, 400, &expiresAt); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+	if expired, err := w.ExpireBonuses(ctx); err != nil || expired != 0 {
+		t.Fatalf("ExpireBonuses() = %d, %v before the expiry, want 0", expired, err)
+	}
+
+	now = expiresAt
+	if expired, err := w.ExpireBonuses(ctx); err != nil || expired != 1 {
+		t.Fatalf("ExpireBonuses() = %d, %v, want 1", expired, err)
+	}
+	if got := balance(); got != (domain.Balance{Real: 50}) {
+		t.Errorf("balance after the expiry = %+v, want the real money only", got)
+	}
+
+	history, _, err := w.GetTransactionHistory(ctx, domain.TransactionFilter{PlayerName: "player", Limit: 20})
+	if err != nil {
+		t.Fatalf("GetTransactionHistory() error = %v", err)
+	}
+	kinds := make(map[domain.TransactionKind]int)
+	for _, tx := range history {
+		kinds[tx.Kind]++
+	}
+	if kinds[domain.TransactionKindBonusGrant] != 2 || kinds[domain.TransactionKindBonusConversion] != 1 ||
+		kinds[domain.TransactionKindBonusExpiry] != 1 {
+		t.Errorf("history kinds = %v, want 2 grants, a conversion and an expiry", kinds)
+	}
+}
+
+func TestWallet_RollbackRevertsBonusConversion(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	w := newMemoryWallet(t, "player", 100)
+
+	if _, err := w.GrantBonus(ctx, "player", 50, 100, nil); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+	bet := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(100), Deposit: int64Ptr(100),
+	}
+	if err := w.WithdrawAndDeposit(ctx, bet); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if b, err := w.GetBalance(ctx, "player", "USD"); err != nil || b != (domain.Balance{Real: 150}) {
+		t.Fatalf("GetBalance() = %+v, %v, want the bonus converted", b, err)
+	}
+
+	if err := w.RollbackTransaction(ctx, &domain.Transaction{PlayerName: "player", ExternalID: "bet"}); err != nil {
+		t.Fatalf("RollbackTransaction() error = %v", err)
+	}
+	wallet, err := w.GetWallet(ctx, "player")
+	if err != nil {
+		t.Fatalf("GetWallet() error = %v", err)
+	}
+	want := domain.Bonus{Balance: 50, WageringRequirement: 100}
+	if wallet.Balance != 100 || wallet.Bonus != want {
+		t.Errorf("after rollback got real %d, bonus %+v, want 100, %+v", wallet.Balance, wallet.Bonus, want)
+	}
+
+	history, _, err := w.GetTransactionHistory(ctx, domain.TransactionFilter{PlayerName: "player", Limit: 20})
+	if err != nil {
+		t.Fatalf("GetTransactionHistory() error = %v", err)
+	}
+	reversed := false
+	for _, tx := range history {
+		reversed = reversed || tx.Kind == domain.TransactionKindBonusConversionReversal
+	}
+	if !reversed {
+		t.Error("history has no bonus conversion reversal")
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+)
+
+// SetRollbackPolicy sets the rollback policy of the wallet, an empty policy falls back to the currency one.
+func (w *Wallet) SetRollbackPolicy(ctx context.Context, playerName string, policy domain.RollbackPolicy) error {
+	if policy != "" && !policy.Valid() {
+		return domain.ErrUnknownRollbackPolicy
+	}
+
+	return w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		wallet, err := w.walletRepo.GetWallet(tCtx, playerName)
+		if err != nil {
+			return err
+		}
+
+		wallet.RollbackPolicy = policy
+		if err := w.walletRepo.UpdateRollbackPolicy(tCtx, wallet); err != nil {
+			return err
+		}
+
+		return w.walletChanged(tCtx, wallet.UserName)
+	})
+}
+
+func (w *Wallet) GetDebtReport(ctx context.Context) ([]*domain.DebtReportEntry, error) {
+	return w.walletRepo.GetDebtReport(db.PreferReplica(ctx))
+}
+
+func (w *Wallet) walletRollbackPolicy(wallet *domain.Wallet) domain.RollbackPolicy {
+	if wallet.RollbackPolicy != "" {
+		return wallet.RollbackPolicy
+	}
+
+	if policy, ok := w.rollbackPolicies[strings.ToUpper(wallet.Currency)]; ok {
+		return policy
+	}
+
+	return w.rollbackPolicy
+}
+
+// insertDebtRecovery records the debt RecoverDebt took from a credit. Every credit of real money recovers
+// debt the same way: wins, incoming transfers and adjustments.
+func (w *Wallet) insertDebtRecovery(ctx context.Context, wallet *domain.Wallet, recovered int64) error {
+	if recovered == 0 {
+		return nil
+	}
+
+	_, err := w.insertSystemTransaction(ctx, wallet, domain.TransactionKindDebtRecovery, recovered, 0, 0, 0)
+	return err
+}
+
+// settleShortfall records what a rollback took below zero: a negative bonus is taken from the real balance
+// and, under the debt policy, a negative real balance becomes a debt.
+func (w *Wallet) settleShortfall(ctx context.Context, wallet *domain.Wallet, policy domain.RollbackPolicy) error {
+	if moved := wallet.MoveBonusShortfall(); moved > 0 {
+		_, err := w.insertSystemTransaction(ctx, wallet, domain.TransactionKindBonusShortfall, moved, moved, 0, moved)
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy != domain.RollbackPolicyDebt {
+		return nil
+	}
+
+	if taken := wallet.TakeDebt(); taken > 0 {
+		_, err := w.insertSystemTransaction(ctx, wallet, domain.TransactionKindDebt, 0, taken, 0, 0)
+		return err
+	}
+
+	return nil
+}
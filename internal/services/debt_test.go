@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mascot/internal/domain"
+)
+
+func TestWallet_Debt(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	w := newMemoryWallet(t, "player", 100)
+	if _, err := w.CreateWallet(ctx, "friend", "USD", "casino", 100); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	wallet := func() (int64, int64) {
+		t.Helper()
+		wallet, err := w.GetWallet(ctx, "player")
+		if err != nil {
+			t.Fatalf("GetWallet() error = %v", err)
+		}
+		return wallet.Balance, wallet.Debt
+	}
+	tx := func(externalID string, withdraw, deposit int64) *domain.Transaction {
+		return &domain.Transaction{
+			PlayerName: "player", Currency: "USD", ExternalID: externalID, GameID: "g", RoundRef: externalID,
+			Withdraw: int64Ptr(withdraw), Deposit: int64Ptr(deposit),
+		}
+	}
+
+	for _, bet := range []*domain.Transaction{tx("win-1", 0, 50), tx("bet-1", 120, 0)} {
+		if err := w.WithdrawAndDeposit(ctx, bet); err != nil {
+			t.Fatalf("WithdrawAndDeposit(%s) error = %v", bet.ExternalID, err)
+		}
+	}
+
+	rollback := &domain.Transaction{PlayerName: "player", ExternalID: "win-1"}
+	if err := w.RollbackTransaction(ctx, rollback); !errors.Is(err, domain.ErrNotEnoughMoney) {
+		t.Fatalf("RollbackTransaction() under the reject policy error = %v, want %v", err, domain.ErrNotEnoughMoney)
+	}
+	if err := w.SetRollbackPolicy(ctx, "player", domain.RollbackPolicyDebt); err != nil {
+		t.Fatalf("SetRollbackPolicy() error = %v", err)
+	}
+	if err := w.RollbackTransaction(ctx, rollback); err != nil {
+		t.Fatalf("RollbackTransaction() under the debt policy error = %v", err)
+	}
+	if balance, debt := wallet(); balance != 0 || debt != 20 {
+		t.Fatalf("balance, debt = %d, %d, want 0, 20", balance, debt)
+	}
+
+	report, err := w.GetDebtReport(ctx)
+	if err != nil {
+		t.Fatalf("GetDebtReport() error = %v", err)
+	}
+	if len(report) != 1 || report[0].PlayerName != "player" || report[0].Outstanding() != 20 {
+		t.Errorf("debt report = %+v, want 20 owed by player", report)
+	}
+
+	tests := []struct {
+		name        string
+		credit      func() error
+		wantBalance int64
+		wantDebt    int64
+	}{
+		{
+			name:     "a round without a win recovers nothing",
+			credit:   func() error { return w.WithdrawAndDeposit(ctx, tx("bet-2", 0, 0)) },
+			wantDebt: 20,
+		},
+		{
+			name:     "a win pays the debt",
+			credit:   func() error { return w.WithdrawAndDeposit(ctx, tx("win-2", 0, 5)) },
+			wantDebt: 15,
+		},
+		{
+			name: "an incoming transfer pays the debt",
+			credit: func() error {
+				return w.Transfer(ctx, &domain.Transfer{Ref: "t1", FromPlayer: "friend", ToPlayer: "player", Currency: "USD", Amount: 10})
+			},
+			wantDebt: 5,
+		},
+		{
+			name:        "only the debt is taken from a bigger win",
+			credit:      func() error { return w.WithdrawAndDeposit(ctx, tx("win-3", 0, 30)) },
+			wantBalance: 25,
+		},
+	}
+	for _, tt := range tests {
+		if err := tt.credit(); err != nil {
+			t.Fatalf("%s: error = %v", tt.name, err)
+		}
+		if balance, debt := wallet(); balance != tt.wantBalance || debt != tt.wantDebt {
+			t.Errorf("%s: balance, debt = %d, %d, want %d, %d", tt.name, balance, debt, tt.wantBalance, tt.wantDebt)
+		}
+	}
+
+	history, _, err := w.GetTransactionHistory(ctx, domain.TransactionFilter{PlayerName: "player", Limit: 20})
+	if err != nil {
+		t.Fatalf("GetTransactionHistory() error = %v", err)
+	}
+	var recovered int64
+	for _, tx := range history {
+		if tx.Kind == domain.TransactionKindDebtRecovery {
+			recovered += tx.WithdrawAmount()
+		}
+	}
+	if recovered != 20 {
+		t.Errorf("recorded recoveries = %d, want 20", recovered)
+	}
+}
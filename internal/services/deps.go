@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+)
+
+// Transactor runs functions in a storage transaction, see db.Transactor.
+type Transactor interface {
+	WithTx(ctx context.Context, txFunc func(ctx context.Context) error, options ...db.TxOption) error
+}
+
+// The repositories of the wallet engine. Methods named Get lock what they return until the end of
+// the transaction, see the repositories package for the Postgres implementation.
+
+type WalletRepository interface {
+	GetWallet(ctx context.Context, playerName string) (*domain.Wallet, error)
+	ReadWallet(ctx context.Context, playerName string) (*domain.Wallet, error)
+	InsertWallet(ctx context.Context, wallet *domain.Wallet) error
+	ListWallets(ctx context.Context, after string, limit int) ([]*domain.Wallet, error)
+	UpdateBalance(ctx context.Context, wallet *domain.Wallet) error
+	UpdateStatus(ctx context.Context, wallet *domain.Wallet) error
+	UpdateRollbackPolicy(ctx context.Context, wallet *domain.Wallet) error
+	NotifyWalletChanged(ctx context.Context, playerName string) error
+	GetPlayersWithExpiredBonus(ctx context.Context, now time.Time) ([]string, error)
+	GetDebtReport(ctx context.Context) ([]*domain.DebtReportEntry, error)
+	InsertStatusChange(ctx context.Context, change *domain.WalletStatusChange) error
+	GetStatusChanges(ctx context.Context, playerName string) ([]*domain.WalletStatusChange, error)
+
+	GetTransactionByExternalID(ctx context.Context, externalID string) (*domain.Transaction, error)
+	GetTransactionsByRound(ctx context.Context, playerName, gameID, roundRef string) ([]*domain.Transaction, error)
+	ListTransactions(ctx context.Context, filter domain.TransactionFilter) ([]*domain.Transaction, error)
+	InsertTransaction(ctx context.Context, tx *domain.Transaction) error
+	SetTransactionRolledBack(ctx context.Context, tx *domain.Transaction) error
+	LockTransactionRef(ctx context.Context, externalID string) error
+}
+
+type RoundRepository interface {
+	GetRound(ctx context.Context, playerName, gameID, roundRef string) (*domain.Round, error)
+	ReadRound(ctx context.Context, playerName, gameID, roundRef string) (*domain.Round, error)
+	SaveRound(ctx context.Context, round *domain.Round) error
+}
+
+type FreeRoundsRepository interface {
+	GetFreeRounds(ctx context.Context, ref string) (*domain.FreeRounds, error)
+	UpdateFreeRounds(ctx context.Context, f *domain.FreeRounds) error
+}
+
+type JackpotRepository interface {
+	GetPoolsByName(ctx context.Context, names []string) ([]*domain.JackpotPool, error)
+	UpdatePoolBalance(ctx context.Context, pool *domain.JackpotPool) error
+	GetContributions(ctx context.Context, currency, gameID string) ([]*domain.JackpotContribution, error)
+	InsertLedgerEntry(ctx context.Context, entry *domain.JackpotLedgerEntry) error
+	GetLedgerEntriesByTransaction(ctx context.Context, transactionID string) ([]*domain.JackpotLedgerEntry, error)
+}
+
+type LedgerRepository interface {
+	InsertEntrySet(ctx context.Context, set *domain.EntrySet) error
+}
+
+type ReservationRepository interface {
+	GetReservation(ctx context.Context, ref string) (*domain.Reservation, error)
+	GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*domain.Reservation, error)
+	InsertReservation(ctx context.Context, reservation *domain.Reservation) error
+	UpdateReservation(ctx context.Context, reservation *domain.Reservation) error
+}
+
+type OutboxRepository interface {
+	InsertEvent(ctx context.Context, event *domain.Event) error
+}
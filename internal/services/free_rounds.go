@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+type FreeRounds struct {
+	transactor     *db.Transactor
+	walletRepo     *repositories.Wallet
+	freeRoundsRepo *repositories.FreeRounds
+	now            func() time.Time
+}
+
+func NewFreeRounds(transactor *db.Transactor, walletRepo *repositories.Wallet, freeRoundsRepo *repositories.FreeRounds) *FreeRounds {
+	return &FreeRounds{
+		transactor:     transactor,
+		walletRepo:     walletRepo,
+		freeRoundsRepo: freeRoundsRepo,
+		now:            time.Now,
+	}
+}
+
+// Grant gives a player free rounds in a game. Currency is taken from the player's wallet.
+func (f *FreeRounds) Grant(ctx context.Context, freeRounds *domain.FreeRounds) error {
+	freeRounds.Remaining = freeRounds.Count
+	freeRounds.Status = domain.FreeRoundsStatusActive
+	if err := freeRounds.Validate(); err != nil {
+		return err
+	}
+
+	if !freeRounds.ExpiresAt.After(f.now()) {
+		return domain.ErrFreeRoundsExpired
+	}
+
+	return f.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		wallet, err := f.walletRepo.GetWallet(tCtx, freeRounds.PlayerName)
+		if err != nil {
+			return err
+		}
+
+		freeRounds.Currency = wallet.Currency
+		return f.freeRoundsRepo.InsertFreeRounds(tCtx, freeRounds)
+	})
+}
+
+func (f *FreeRounds) Cancel(ctx context.Context, ref string) (*domain.FreeRounds, error) {
+	var freeRounds *domain.FreeRounds
+	err := f.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		var err error
+		freeRounds, err = f.freeRoundsRepo.GetFreeRounds(tCtx, ref)
+		if err != nil {
+			return err
+		}
+
+		if freeRounds == nil {
+			return domain.ErrFreeRoundsNotFound
+		}
+
+		if err := freeRounds.Cancel(); err != nil {
+			return err
+		}
+
+		return f.freeRoundsRepo.UpdateFreeRounds(tCtx, freeRounds)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return freeRounds, nil
+}
+
+func (f *FreeRounds) GetPlayerFreeRounds(ctx context.Context, playerName string) ([]*domain.FreeRounds, error) {
+	return f.freeRoundsRepo.GetPlayerFreeRounds(ctx, playerName)
+}
+
+func (f *FreeRounds) GetCampaignReport(ctx context.Context, campaign string) (*domain.FreeRoundsCampaignReport, error) {
+	return f.freeRoundsRepo.GetCampaignReport(db.PreferReplica(ctx), campaign)
+}
+
+// spendFreeRound uses up a free round for the first bet of a game round and credits the win
+// to the configured sub-balance. Bets without a round reference each spend a free round.
+func (w *Wallet) spendFreeRound(ctx context.Context, wallet *domain.Wallet, tx *domain.Transaction) error {
+	if tx.FreeRoundsRef == "" {
+		return nil
+	}
+
+	if tx.WithdrawAmount() != 0 {
+		return domain.ErrFreeRoundWithdrawal
+	}
+
+	freeRounds, err := w.freeRoundsRepo.GetFreeRounds(ctx, tx.FreeRoundsRef)
+	if err != nil {
+		return err
+	}
+
+	if freeRounds == nil || freeRounds.PlayerName != wallet.UserName {
+		return domain.ErrFreeRoundsNotFound
+	}
+
+	round, err := w.getTxRound(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if round == nil {
+		if err := freeRounds.Spend(tx.GameID, w.now()); err != nil {
+			return err
+		}
+		tx.FreeRoundSpent = true
+	} else if err := w.checkFreeRound(ctx, freeRounds, tx); err != nil {
+		return err
+	}
+
+	if w.freeRoundWinBalance == domain.SubBalanceBonus {
+		tx.DepositBonus = tx.DepositAmount()
+		tx.WageringAdded = wallet.AddWageringRequirement(tx.DepositBonus, freeRounds.WageringMultiplier)
+	}
+
+	if freeRounds.TotalWin, err = domain.AddAmounts(freeRounds.TotalWin, tx.DepositAmount()); err != nil {
+		return err
+	}
+
+	return w.freeRoundsRepo.UpdateFreeRounds(ctx, freeRounds)
+}
+
+// checkFreeRound checks a later bet of a game round is still allowed by the free rounds which started the round.
+func (w *Wallet) checkFreeRound(ctx context.Context, freeRounds *domain.FreeRounds, tx *domain.Transaction) error {
+	if err := freeRounds.Check(tx.GameID, w.now()); err != nil {
+		return err
+	}
+
+	transactions, err := w.walletRepo.GetTransactionsByRound(ctx, tx.PlayerName, tx.GameID, tx.RoundRef)
+	if err != nil {
+		return err
+	}
+
+	for _, roundTx := range transactions {
+		if roundTx.FreeRoundSpent && !roundTx.RolledBack && roundTx.FreeRoundsRef == freeRounds.Ref {
+			return nil
+		}
+	}
+
+	return domain.ErrFreeRoundsRoundMismatch
+}
+
+func (w *Wallet) restoreFreeRound(ctx context.Context, tx *domain.Transaction) error {
+	if tx.FreeRoundsRef == "" {
+		return nil
+	}
+
+	freeRounds, err := w.freeRoundsRepo.GetFreeRounds(ctx, tx.FreeRoundsRef)
+	if err != nil || freeRounds == nil {
+		return err
+	}
+
+	if tx.FreeRoundSpent {
+		freeRounds.Restore()
+	}
+
+	freeRounds.TotalWin -= tx.DepositAmount()
+	return w.freeRoundsRepo.UpdateFreeRounds(ctx, freeRounds)
+}
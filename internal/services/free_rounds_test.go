@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mascot/internal/domain"
+	"mascot/internal/memory"
+)
+
+func TestWallet_FreeRounds(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := newMemoryStore(t, "player", 100)
+	freeRoundsRepo := memory.NewFreeRounds(store)
+	w := newStoreWallet(store, WithFreeRoundWinBalance(domain.SubBalanceBonus))
+
+	grant := &domain.FreeRounds{
+		Ref: "fr", PlayerName: "player", GameID: "g", Currency: "USD", Count: 1, Remaining: 1, BetValue: 10,
+		WageringMultiplier: 5, Status: domain.FreeRoundsStatusActive, ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := freeRoundsRepo.InsertFreeRounds(ctx, grant); err != nil {
+		t.Fatalf("InsertFreeRounds() error = %v", err)
+	}
+
+	freeRound := func(externalID, roundRef string, deposit int64) *domain.Transaction {
+		return &domain.Transaction{
+			PlayerName: "player", Currency: "USD", ExternalID: externalID, GameID: "g", RoundRef: roundRef,
+			FreeRoundsRef: "fr", Withdraw: int64Ptr(0), Deposit: int64Ptr(deposit),
+		}
+	}
+	bonus := func() domain.Bonus {
+		t.Helper()
+		wallet, err := w.GetWallet(ctx, "player")
+		if err != nil {
+			t.Fatalf("GetWallet() error = %v", err)
+		}
+		return wallet.Bonus
+	}
+
+	if err := w.WithdrawAndDeposit(ctx, freeRound("spin", "r1", 0)); err != nil {
+		t.Fatalf("WithdrawAndDeposit() of the free round error = %v", err)
+	}
+	if err := w.WithdrawAndDeposit(ctx, freeRound("win", "r1", 20)); err != nil {
+		t.Fatalf("WithdrawAndDeposit() of the win error = %v", err)
+	}
+	if got := bonus(); got.Balance != 20 || got.WageringRequirement != 100 {
+		t.Errorf("bonus = %+v, want 20 to wager 100 times", got)
+	}
+
+	paid := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "paid", GameID: "g", RoundRef: "r2",
+		Withdraw: int64Ptr(10), Deposit: int64Ptr(0),
+	}
+	if err := w.WithdrawAndDeposit(ctx, paid); err != nil {
+		t.Fatalf("WithdrawAndDeposit() of the paid round error = %v", err)
+	}
+	err := w.WithdrawAndDeposit(ctx, freeRound("paid-win", "r2", 50))
+	if !errors.Is(err, domain.ErrFreeRoundsRoundMismatch) {
+		t.Errorf("win of a paid round error = %v, want %v", err, domain.ErrFreeRoundsRoundMismatch)
+	}
+
+	if err := w.RollbackTransaction(ctx, &domain.Transaction{PlayerName: "player", ExternalID: "win"}); err != nil {
+		t.Fatalf("RollbackTransaction() error = %v", err)
+	}
+	if got := bonus(); got.Balance != 0 || got.WageringRequirement != 0 {
+		t.Errorf("bonus after rollback = %+v, want none left to wager", got)
+	}
+
+	grant.Status = domain.FreeRoundsStatusCancelled
+	if err := freeRoundsRepo.UpdateFreeRounds(ctx, grant); err != nil {
+		t.Fatalf("UpdateFreeRounds() error = %v", err)
+	}
+	err = w.WithdrawAndDeposit(ctx, freeRound("late-win", "r1", 20))
+	if !errors.Is(err, domain.ErrFreeRoundsCancelled) {
+		t.Errorf("win after cancellation error = %v, want %v", err, domain.ErrFreeRoundsCancelled)
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+)
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// GetTransactionHistory returns a page of transactions matching the filter, newest first,
+// and the cursor of the next page. The cursor is empty on the last page.
+func (w *Wallet) GetTransactionHistory(ctx context.Context, filter domain.TransactionFilter) ([]*domain.Transaction, string, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, "", domain.ErrInvalidFilter
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, "", domain.ErrInvalidFilter
+	}
+
+	switch {
+	case filter.Limit < 0 || filter.Limit > maxHistoryLimit:
+		return nil, "", domain.ErrInvalidFilter
+	case filter.Limit == 0:
+		filter.Limit = defaultHistoryLimit
+	}
+
+	limit := filter.Limit
+	// one extra row tells whether there is a next page
+	filter.Limit++
+	transactions, err := w.walletRepo.ListTransactions(db.PreferReplica(ctx), filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(transactions) <= limit {
+		return transactions, "", nil
+	}
+
+	transactions = transactions[:limit]
+	return transactions, domain.NewTransactionCursor(transactions[limit-1]).Encode(), nil
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+type Jackpot struct {
+	transactor  *db.Transactor
+	jackpotRepo *repositories.Jackpot
+	ledgerRepo  *repositories.Ledger
+}
+
+func NewJackpot(transactor *db.Transactor, jackpotRepo *repositories.Jackpot, ledgerRepo *repositories.Ledger) *Jackpot {
+	return &Jackpot{transactor: transactor, jackpotRepo: jackpotRepo, ledgerRepo: ledgerRepo}
+}
+
+// CreatePool creates a pool. A non zero pool balance is booked as the seed of the pool.
+func (j *Jackpot) CreatePool(ctx context.Context, pool *domain.JackpotPool) error {
+	if pool.Name == "" || pool.Currency == "" || pool.Balance < 0 {
+		return domain.ErrInvalidJackpotPool
+	}
+
+	return j.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		seed := pool.Balance
+		pool.Balance = 0
+		if err := j.jackpotRepo.InsertPool(tCtx, pool); err != nil {
+			return err
+		}
+
+		if seed == 0 {
+			return nil
+		}
+
+		entry, err := pool.Apply(domain.JackpotEntrySeed, "", seed)
+		if err != nil {
+			return err
+		}
+
+		if err := j.jackpotRepo.InsertLedgerEntry(tCtx, entry); err != nil {
+			return err
+		}
+
+		// the seed is paid by the house
+		set := domain.NewEntrySet("jackpot_seed:"+strconv.FormatInt(pool.ID, 10), "", "jackpot_seed", pool.Currency)
+		if err := set.Post(domain.JackpotAccount(pool.Name), seed); err != nil {
+			return err
+		}
+
+		if err := set.Settle(domain.HouseAccount(pool.Currency)); err != nil {
+			return err
+		}
+
+		if err := postEntrySet(tCtx, j.ledgerRepo, set); err != nil {
+			return err
+		}
+
+		return j.jackpotRepo.UpdatePoolBalance(tCtx, pool)
+	})
+}
+
+// SetContribution sets the contribution rate of a pool for a game, empty gameID sets it for all games.
+func (j *Jackpot) SetContribution(ctx context.Context, poolName, gameID string, rateBasisPoints int64) (*domain.JackpotContribution, error) {
+	if rateBasisPoints < 0 {
+		return nil, domain.ErrInvalidJackpotPool
+	}
+
+	var contribution *domain.JackpotContribution
+	err := j.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		pool, err := j.jackpotRepo.GetPool(tCtx, poolName)
+		if err != nil {
+			return err
+		}
+
+		contribution = &domain.JackpotContribution{
+			PoolID:          pool.ID,
+			PoolName:        pool.Name,
+			GameID:          gameID,
+			Currency:        pool.Currency,
+			RateBasisPoints: rateBasisPoints,
+		}
+
+		return j.jackpotRepo.SaveContribution(tCtx, contribution)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return contribution, nil
+}
+
+func (j *Jackpot) GetPools(ctx context.Context) ([]*domain.JackpotPool, error) {
+	return j.jackpotRepo.GetPools(ctx)
+}
+
+func (j *Jackpot) GetPoolLedger(ctx context.Context, poolName string, beforeID int64, limit int) ([]*domain.JackpotLedgerEntry, error) {
+	return j.jackpotRepo.GetPoolLedger(db.PreferReplica(ctx), poolName, beforeID, limit)
+}
+
+// applyJackpots books the contributions of the bet and the jackpot payouts of tx and returns the pool entries.
+func (w *Wallet) applyJackpots(ctx context.Context, tx *domain.Transaction) ([]*domain.JackpotLedgerEntry, error) {
+	if err := domain.ValidateJackpotPayouts(tx); err != nil {
+		return nil, err
+	}
+
+	var contributions []*domain.JackpotContribution
+	if tx.WithdrawAmount() > 0 {
+		rules, err := w.jackpotRepo.GetContributions(ctx, tx.Currency, tx.GameID)
+		if err != nil {
+			return nil, err
+		}
+		contributions = pickContributions(rules)
+	}
+
+	if len(contributions) == 0 && len(tx.JackpotPayouts) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(contributions)+len(tx.JackpotPayouts))
+	for _, c := range contributions {
+		names = append(names, c.PoolName)
+	}
+	for _, payout := range tx.JackpotPayouts {
+		names = append(names, payout.PoolName)
+	}
+
+	pools, err := w.lockPools(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*domain.JackpotLedgerEntry
+	for _, c := range contributions {
+		amount := c.Amount(tx.WithdrawAmount())
+		if amount == 0 {
+			continue
+		}
+
+		entry, err := pools[c.PoolName].Apply(domain.JackpotEntryContribution, tx.ID, amount)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, payout := range tx.JackpotPayouts {
+		pool := pools[payout.PoolName]
+		if pool.Currency != tx.Currency {
+			return nil, domain.ErrIllegalCurrency
+		}
+
+		entry, err := pool.Apply(domain.JackpotEntryPayout, tx.ID, -payout.Amount)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, w.saveJackpotEntries(ctx, pools, entries)
+}
+
+// reverseJackpots cancels every pool movement booked for the rolled back tx and returns the reversal entries.
+func (w *Wallet) reverseJackpots(ctx context.Context, tx *domain.Transaction) ([]*domain.JackpotLedgerEntry, error) {
+	booked, err := w.jackpotRepo.GetLedgerEntriesByTransaction(ctx, tx.ID)
+	if err != nil || len(booked) == 0 {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(booked))
+	for _, entry := range booked {
+		names = append(names, entry.PoolName)
+	}
+
+	pools, err := w.lockPools(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*domain.JackpotLedgerEntry, 0, len(booked))
+	for _, entry := range booked {
+		reversal, err := pools[entry.PoolName].Apply(entry.Kind.ReversalKind(), tx.ID, -entry.Amount)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, reversal)
+	}
+
+	return entries, w.saveJackpotEntries(ctx, pools, entries)
+}
+
+// jackpotPayouts returns the pool payouts booked for tx.
+func (w *Wallet) jackpotPayouts(ctx context.Context, tx *domain.Transaction) ([]domain.JackpotPayout, error) {
+	entries, err := w.jackpotRepo.GetLedgerEntriesByTransaction(ctx, tx.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payouts []domain.JackpotPayout
+	for _, entry := range entries {
+		if entry.Kind == domain.JackpotEntryPayout {
+			payouts = append(payouts, domain.JackpotPayout{PoolName: entry.PoolName, Amount: -entry.Amount})
+		}
+	}
+
+	return payouts, nil
+}
+
+func (w *Wallet) lockPools(ctx context.Context, names []string) (map[string]*domain.JackpotPool, error) {
+	locked, err := w.jackpotRepo.GetPoolsByName(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make(map[string]*domain.JackpotPool, len(locked))
+	for _, pool := range locked {
+		pools[pool.Name] = pool
+	}
+
+	for _, name := range names {
+		if _, ok := pools[name]; !ok {
+			return nil, domain.ErrJackpotPoolNotFound
+		}
+	}
+
+	return pools, nil
+}
+
+func (w *Wallet) saveJackpotEntries(ctx context.Context, pools map[string]*domain.JackpotPool, entries []*domain.JackpotLedgerEntry) error {
+	for _, entry := range entries {
+		if err := w.jackpotRepo.InsertLedgerEntry(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	for _, pool := range pools {
+		if err := w.jackpotRepo.UpdatePoolBalance(ctx, pool); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pickContributions keeps one rule per pool, rules are expected with game specific ones first.
+func pickContributions(rules []*domain.JackpotContribution) []*domain.JackpotContribution {
+	seen := make(map[int64]bool, len(rules))
+	res := make([]*domain.JackpotContribution, 0, len(rules))
+	for _, rule := range rules {
+		if seen[rule.PoolID] {
+			continue
+		}
+		seen[rule.PoolID] = true
+		if rule.RateBasisPoints > 0 {
+			res = append(res, rule)
+		}
+	}
+
+	return res
+}
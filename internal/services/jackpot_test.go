@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mascot/internal/domain"
+	"mascot/internal/memory"
+)
+
+func TestWallet_Jackpots(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := newMemoryStore(t, "player", 10000)
+	jackpotRepo := memory.NewJackpot(store)
+	w := newStoreWallet(store)
+
+	pool := &domain.JackpotPool{Name: "mega", Currency: "USD"}
+	if err := jackpotRepo.InsertPool(ctx, pool); err != nil {
+		t.Fatalf("InsertPool() error = %v", err)
+	}
+	pool.Balance = 1000
+	if err := jackpotRepo.UpdatePoolBalance(ctx, pool); err != nil {
+		t.Fatalf("UpdatePoolBalance() error = %v", err)
+	}
+	rules := []*domain.JackpotContribution{
+		{PoolID: pool.ID, PoolName: "mega", Currency: "USD", RateBasisPoints: 100},
+		{PoolID: pool.ID, PoolName: "mega", GameID: "g", Currency: "USD", RateBasisPoints: 150},
+	}
+	for _, rule := range rules {
+		if err := jackpotRepo.SaveContribution(ctx, rule); err != nil {
+			t.Fatalf("SaveContribution() error = %v", err)
+		}
+	}
+
+	poolBalance := func() int64 {
+		t.Helper()
+		pools, err := jackpotRepo.GetPoolsByName(ctx, []string{"mega"})
+		if err != nil || len(pools) != 1 {
+			t.Fatalf("GetPoolsByName() = %v, %v", pools, err)
+		}
+		return pools[0].Balance
+	}
+	tx := func(externalID, gameID string, withdraw, deposit int64, payouts ...domain.JackpotPayout) *domain.Transaction {
+		return &domain.Transaction{
+			PlayerName: "player", Currency: "USD", ExternalID: externalID, GameID: gameID,
+			Withdraw: int64Ptr(withdraw), Deposit: int64Ptr(deposit), JackpotPayouts: payouts,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		tx       *domain.Transaction
+		wantErr  error
+		wantPool int64
+	}{
+		{name: "game rule rounds down", tx: tx("bet-1", "g", 1001, 0), wantPool: 1015},
+		{name: "default rule", tx: tx("bet-2", "other", 1000, 0), wantPool: 1025},
+		{name: "contribution below one unit", tx: tx("bet-3", "g", 66, 0), wantPool: 1025},
+		{
+			name:     "payout above the pool",
+			tx:       tx("win-1", "g", 0, 2000, domain.JackpotPayout{PoolName: "mega", Amount: 2000}),
+			wantErr:  domain.ErrJackpotPoolInsufficient,
+			wantPool: 1025,
+		},
+		{
+			name:     "payout",
+			tx:       tx("win-2", "g", 0, 1000, domain.JackpotPayout{PoolName: "mega", Amount: 1000}),
+			wantPool: 25,
+		},
+	}
+	for _, tt := range tests {
+		if err := w.WithdrawAndDeposit(ctx, tt.tx); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: WithdrawAndDeposit() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if got := poolBalance(); got != tt.wantPool {
+			t.Errorf("%s: pool = %d, want %d", tt.name, got, tt.wantPool)
+		}
+	}
+
+	replay := tx("win-2", "g", 0, 1000, domain.JackpotPayout{PoolName: "mega", Amount: 1000})
+	if err := w.WithdrawAndDeposit(ctx, replay); err != nil {
+		t.Fatalf("replayed WithdrawAndDeposit() error = %v", err)
+	}
+	if len(replay.JackpotPayouts) != 1 || replay.JackpotPayouts[0].Amount != 1000 || poolBalance() != 25 {
+		t.Errorf("replay payouts = %+v with pool %d, want the booked payout with pool 25", replay.JackpotPayouts, poolBalance())
+	}
+
+	for _, ref := range []string{"win-2", "bet-1"} {
+		if err := w.RollbackTransaction(ctx, &domain.Transaction{PlayerName: "player", ExternalID: ref}); err != nil {
+			t.Fatalf("RollbackTransaction(%s) error = %v", ref, err)
+		}
+	}
+	if got := poolBalance(); got != 1010 {
+		t.Errorf("pool after rollbacks = %d, want 1010", got)
+	}
+
+	balance, err := w.GetBalance(ctx, "player", "USD")
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	if balance.Real != 10000-1000-66 {
+		t.Errorf("balance = %d, want %d", balance.Real, 10000-1000-66)
+	}
+}
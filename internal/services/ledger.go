@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+type Ledger struct {
+	transactor *db.Transactor
+	ledgerRepo *repositories.Ledger
+	now        func() time.Time
+}
+
+func NewLedger(transactor *db.Transactor, ledgerRepo *repositories.Ledger) *Ledger {
+	return &Ledger{transactor: transactor, ledgerRepo: ledgerRepo, now: time.Now}
+}
+
+// Check verifies that every entry set sums to zero and that stored balances match their ledger accounts.
+// All checks read the same snapshot, so that bookings made meanwhile don't show up as mismatches.
+func (l *Ledger) Check(ctx context.Context) (*domain.LedgerCheck, error) {
+	var check *domain.LedgerCheck
+	err := l.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		check = &domain.LedgerCheck{CheckedAt: l.now()}
+
+		var err error
+		if check.EntrySets, err = l.ledgerRepo.CountEntrySets(tCtx); err != nil {
+			return err
+		}
+
+		if check.Unbalanced, err = l.ledgerRepo.GetUnbalancedSets(tCtx); err != nil {
+			return err
+		}
+
+		check.Mismatches, err = l.ledgerRepo.GetProjectionMismatches(tCtx)
+		return err
+	}, db.WithIsoLevel(pgx.RepeatableRead), db.WithAccessMode(pgx.ReadOnly))
+	if err != nil {
+		return nil, err
+	}
+
+	return check, nil
+}
+
+func (l *Ledger) GetAccountEntries(ctx context.Context, account string, beforeID int64, limit int) ([]*domain.LedgerEntry, error) {
+	return l.ledgerRepo.GetAccountEntries(db.PreferReplica(ctx), account, beforeID, limit)
+}
+
+// postEntrySet books a balanced entry set, an unbalanced one fails the surrounding db transaction.
+func postEntrySet(ctx context.Context, ledgerRepo LedgerRepository, set *domain.EntrySet) error {
+	if err := set.Validate(); err != nil {
+		return err
+	}
+
+	return ledgerRepo.InsertEntrySet(ctx, set)
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+const defaultOutboxBatchSize = 100
+
+// A wallet whose event failed is skipped by the dispatches for a delay doubling with the attempts
+// of the event from outboxRetryBaseDelay up to outboxRetryMaxDelay.
+const (
+	outboxRetryBaseDelay = time.Second
+	outboxRetryMaxDelay  = 5 * time.Minute
+)
+
+// EventSink receives the wallet events of the outbox. An error leaves the event and the later
+// events of its wallet in the outbox until a later dispatch.
+type EventSink interface {
+	Publish(ctx context.Context, event *domain.Event) error
+}
+
+type EventSinkFunc func(ctx context.Context, event *domain.Event) error
+
+func (f EventSinkFunc) Publish(ctx context.Context, event *domain.Event) error {
+	return f(ctx, event)
+}
+
+// MultiSink publishes every event to each of the sinks in turn. When a sink fails, the sinks before it see
+// the event again on the next dispatch, so they must skip the events they already published.
+type MultiSink []EventSink
+
+func (m MultiSink) Publish(ctx context.Context, event *domain.Event) error {
+	for _, sink := range m {
+		if err := sink.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Outbox delivers the events written by wallet operations to the sink.
+type Outbox struct {
+	transactor *db.Transactor
+	outboxRepo *repositories.Outbox
+	sink       EventSink
+	batchSize  int
+	now        func() time.Time
+}
+
+func NewOutbox(transactor *db.Transactor, outboxRepo *repositories.Outbox, sink EventSink) *Outbox {
+	return &Outbox{
+		transactor: transactor,
+		outboxRepo: outboxRepo,
+		sink:       sink,
+		batchSize:  defaultOutboxBatchSize,
+		now:        time.Now,
+	}
+}
+
+// Dispatch delivers pending events in ID order until the outbox has no event due.
+// A failed event holds back the later events of its wallet for a growing delay, the other wallets go on.
+// Only one dispatcher runs at a time, the others return 0. It returns the number of delivered events.
+func (o *Outbox) Dispatch(ctx context.Context) (int, error) {
+	var total int
+	for {
+		var delivered, held int
+		var batchFull bool
+		err := o.transactor.WithTx(ctx, func(tCtx context.Context) error {
+			delivered, held, batchFull = 0, 0, false
+
+			locked, err := o.outboxRepo.TryLockDispatch(tCtx)
+			if err != nil || !locked {
+				return err
+			}
+
+			events, err := o.outboxRepo.GetPendingEvents(tCtx, o.now(), o.batchSize)
+			if err != nil {
+				return err
+			}
+			batchFull = len(events) == o.batchSize
+
+			failed := make(map[string]bool)
+			for _, event := range events {
+				if failed[event.PlayerName] {
+					continue
+				}
+
+				if err := o.sink.Publish(tCtx, event); err != nil {
+					failed[event.PlayerName] = true
+					held++
+					nextAttemptAt := o.now().Add(outboxBackoff(event.Attempts + 1))
+					if err := o.outboxRepo.MarkFailed(tCtx, event.ID, err, nextAttemptAt); err != nil {
+						return err
+					}
+					continue
+				}
+
+				if err := o.outboxRepo.MarkDispatched(tCtx, event.ID, o.now()); err != nil {
+					return err
+				}
+				delivered++
+			}
+
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+
+		// the next batch skips the wallets held back by this one
+		total += delivered
+		if !batchFull || delivered+held == 0 {
+			return total, nil
+		}
+	}
+}
+
+// outboxBackoff is the delay after the failed attempt of an event.
+func outboxBackoff(attempt int) time.Duration {
+	delay := outboxRetryBaseDelay
+	for i := 1; i < attempt && delay < outboxRetryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > outboxRetryMaxDelay {
+		return outboxRetryMaxDelay
+	}
+	return delay
+}
+
+// MemorySink keeps the published events, it is meant for tests.
+type MemorySink struct {
+	mu     sync.Mutex
+	events []*domain.Event
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Publish(_ context.Context, event *domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *MemorySink) Events() []*domain.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*domain.Event(nil), s.events...)
+}
+
+// FileSink appends the published events to a file as JSON lines.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(name string) (*FileSink, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{file: f}, nil
+}
+
+func (s *FileSink) Publish(_ context.Context, event *domain.Event) error {
+	line, err := json.Marshal(newEventMessage(event))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// eventMessage is the JSON form of an event delivered to sinks.
+type eventMessage struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	PlayerName string          `json:"playerName"`
+	Payload    json.RawMessage `json:"payload"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+func newEventMessage(event *domain.Event) *eventMessage {
+	return &eventMessage{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		PlayerName: event.PlayerName,
+		Payload:    event.Payload,
+		CreatedAt:  event.CreatedAt,
+	}
+}
+
+type balanceChangedPayload struct {
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	BonusBalance int64  `json:"bonusBalance"`
+	HeldBalance  int64  `json:"heldBalance"`
+	Debt         int64  `json:"debt"`
+}
+
+type transactionPayload struct {
+	TransactionID      string `json:"transactionId"`
+	Kind               string `json:"kind"`
+	ExternalID         string `json:"externalId"`
+	Currency           string `json:"currency"`
+	Withdraw           int64  `json:"withdraw"`
+	Deposit            int64  `json:"deposit"`
+	WithdrawBonus      int64  `json:"withdrawBonus"`
+	DepositBonus       int64  `json:"depositBonus"`
+	GameID             string `json:"gameId,omitempty"`
+	RoundRef           string `json:"roundRef,omitempty"`
+	BalanceAfterCommit *int64 `json:"balanceAfterCommit,omitempty"`
+	RollbackShortfall  int64  `json:"rollbackShortfall,omitempty"`
+	TransferRef        string `json:"transferRef,omitempty"`
+}
+
+func newTransactionPayload(tx *domain.Transaction) *transactionPayload {
+	return &transactionPayload{
+		TransactionID:      tx.ID,
+		Kind:               string(tx.Kind),
+		ExternalID:         tx.ExternalID,
+		Currency:           tx.Currency,
+		Withdraw:           tx.WithdrawAmount(),
+		Deposit:            tx.DepositAmount(),
+		WithdrawBonus:      tx.WithdrawBonus,
+		DepositBonus:       tx.DepositBonus,
+		GameID:             tx.GameID,
+		RoundRef:           tx.RoundRef,
+		BalanceAfterCommit: tx.BalanceAfterCommit,
+		RollbackShortfall:  tx.RollbackShortfall,
+		TransferRef:        tx.TransferRef,
+	}
+}
+
+// updateBalance stores the balances of the locked wallet and writes the change to the outbox.
+func (w *Wallet) updateBalance(ctx context.Context, wallet *domain.Wallet) error {
+	if err := w.walletRepo.UpdateBalance(ctx, wallet); err != nil {
+		return err
+	}
+
+	if err := w.walletChanged(ctx, wallet.UserName); err != nil {
+		return err
+	}
+
+	return w.writeEvent(ctx, domain.EventTypeBalanceChanged, wallet.UserName, &balanceChangedPayload{
+		Currency:     wallet.Currency,
+		Balance:      wallet.Balance,
+		BonusBalance: wallet.Bonus.Balance,
+		HeldBalance:  wallet.Held,
+		Debt:         wallet.Debt,
+	})
+}
+
+// walletChanged drops the cached wallet once the change commits, on this instance at once and on the others
+// through the notification it sends in the transaction. Without balance caches nothing is notified.
+func (w *Wallet) walletChanged(ctx context.Context, playerName string) error {
+	if w.balanceCache != nil {
+		db.AfterCommit(ctx, func() {
+			w.balanceCache.Invalidate(playerName)
+		})
+	}
+
+	if !w.notifyChanges {
+		return nil
+	}
+
+	return w.walletRepo.NotifyWalletChanged(ctx, playerName)
+}
+
+// insertTransaction stores tx and writes it to the outbox. A rollback stored before its
+// transaction is written as rolled back.
+func (w *Wallet) insertTransaction(ctx context.Context, tx *domain.Transaction) error {
+	if err := w.walletRepo.InsertTransaction(ctx, tx); err != nil {
+		return err
+	}
+
+	eventType := domain.EventTypeTransactionCommitted
+	if tx.RolledBack {
+		eventType = domain.EventTypeTransactionRolledBack
+	}
+
+	return w.writeEvent(ctx, eventType, tx.PlayerName, newTransactionPayload(tx))
+}
+
+func (w *Wallet) setTransactionRolledBack(ctx context.Context, tx *domain.Transaction) error {
+	if err := w.walletRepo.SetTransactionRolledBack(ctx, tx); err != nil {
+		return err
+	}
+
+	return w.writeEvent(ctx, domain.EventTypeTransactionRolledBack, tx.PlayerName, newTransactionPayload(tx))
+}
+
+func (w *Wallet) writeEvent(ctx context.Context, eventType domain.EventType, playerName string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+
+	return w.outboxRepo.InsertEvent(ctx, &domain.Event{Type: eventType, PlayerName: playerName, Payload: body})
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+func TestFileSink_Publish(t *testing.T) {
+	t.Parallel()
+	name := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := NewFileSink(name)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	for i := int64(1); i <= 2; i++ {
+		event := &domain.Event{ID: i, Type: domain.EventTypeBalanceChanged, PlayerName: "p", Payload: []byte(`{"balance":1}`)}
+		if err := sink.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	var ids []int64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg eventMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		if string(msg.Payload) != `{"balance":1}` || msg.Type != string(domain.EventTypeBalanceChanged) {
+			t.Errorf("message = %+v", msg)
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("ids = %v, want [1 2]", ids)
+	}
+}
+
+func TestOutbox_Dispatch(t *testing.T) {
+	s := newConcurrencySuite(t)
+	ctx := context.Background()
+	transactor := db.NewTransactor(s.conn, zap.NewNop())
+	outbox := NewOutbox(transactor, repositories.NewOutbox(transactor), NewMemorySink())
+
+	// deliver what earlier tests left behind
+	if _, err := outbox.Dispatch(ctx); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+
+	failing, playerName := s.newPlayer(t), s.newPlayer(t)
+	for i, player := range []string{failing, playerName, failing, playerName} {
+		ref := player + ":bet:" + string(rune('a'+i))
+		if err := s.wallet.WithdrawAndDeposit(ctx, withdrawTx(player, ref, 10, 0)); err != nil {
+			t.Fatalf("WithdrawAndDeposit() error = %v", err)
+		}
+	}
+
+	// the events of the failing wallet fill the first batch, the next ones go on without them
+	outbox.batchSize = 2
+	sink := NewMemorySink()
+	outbox.sink = EventSinkFunc(func(ctx context.Context, event *domain.Event) error {
+		if event.PlayerName == failing {
+			return errors.New("sink is down")
+		}
+		return sink.Publish(ctx, event)
+	})
+	if _, err := outbox.Dispatch(ctx); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+
+	// a transaction and a balance change per bet, in commit order
+	wantTypes := []domain.EventType{
+		domain.EventTypeTransactionCommitted, domain.EventTypeBalanceChanged,
+		domain.EventTypeTransactionCommitted, domain.EventTypeBalanceChanged,
+	}
+	events := sink.Events()
+	if len(events) != len(wantTypes) {
+		t.Fatalf("delivered %d events, want %d", len(events), len(wantTypes))
+	}
+	for i, event := range events {
+		if event.PlayerName != playerName || event.Type != wantTypes[i] {
+			t.Errorf("event #%d = %s of %s, want %s of %s", i, event.Type, event.PlayerName, wantTypes[i], playerName)
+		}
+		if i > 0 && event.ID <= events[i-1].ID {
+			t.Errorf("event #%d is out of order", i)
+		}
+	}
+
+	outbox.sink = sink
+	if _, err := outbox.Dispatch(ctx); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if got := len(sink.Events()); got != len(wantTypes) {
+		t.Errorf("delivered %d events before the backoff elapsed, want %d", got, len(wantTypes))
+	}
+
+	outbox.now = func() time.Time { return time.Now().Add(outboxRetryBaseDelay) }
+	if _, err := outbox.Dispatch(ctx); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if got := len(sink.Events()); got != 2*len(wantTypes) {
+		t.Errorf("delivered %d events after recovery, want %d", got, 2*len(wantTypes))
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+type Reconciliation struct {
+	walletRepo *repositories.Wallet
+	reportDir  string
+	alerter    Alerter
+	now        func() time.Time
+}
+
+// NewReconciliation creates the service. Reports are written to reportDir, alerter may be nil.
+func NewReconciliation(walletRepo *repositories.Wallet, reportDir string, alerter Alerter) *Reconciliation {
+	return &Reconciliation{walletRepo: walletRepo, reportDir: reportDir, alerter: alerter, now: time.Now}
+}
+
+// Run recomputes every wallet balance from its opening balance and committed transactions,
+// writes the JSON and CSV reports and alerts when a balance doesn't match.
+func (r *Reconciliation) Run(ctx context.Context) (*domain.ReconciliationReport, error) {
+	startedAt := r.now()
+	wallets, err := r.walletRepo.GetWalletReconciliations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := domain.NewReconciliationReport(startedAt, r.now(), wallets)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.writeReports(report); err != nil {
+		return nil, err
+	}
+
+	if report.Discrepancies > 0 && r.alerter != nil {
+		if err := r.alerter.Alert(ctx, report); err != nil {
+			return nil, fmt.Errorf("alert: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+func (r *Reconciliation) writeReports(report *domain.ReconciliationReport) error {
+	if err := os.MkdirAll(r.reportDir, 0o755); err != nil {
+		return err
+	}
+
+	name := filepath.Join(r.reportDir, "reconciliation-"+report.StartedAt.UTC().Format("20060102T150405Z"))
+	if err := writeReportFile(name+".json", report, WriteReconciliationJSON); err != nil {
+		return err
+	}
+
+	return writeReportFile(name+".csv", report, WriteReconciliationCSV)
+}
+
+func writeReportFile(name string, report *domain.ReconciliationReport, write func(io.Writer, *domain.ReconciliationReport) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f, report); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+
+	return f.Close()
+}
+
+type reconciliationReport struct {
+	StartedAt     time.Time               `json:"startedAt"`
+	FinishedAt    time.Time               `json:"finishedAt"`
+	Wallets       int                     `json:"wallets"`
+	Discrepancies int                     `json:"discrepancies"`
+	Entries       []*walletReconciliation `json:"entries"`
+}
+
+type walletReconciliation struct {
+	PlayerName        string `json:"playerName"`
+	Currency          string `json:"currency"`
+	Transactions      int64  `json:"transactions"`
+	ExpectedBalance   int64  `json:"expectedBalance"`
+	ActualBalance     int64  `json:"actualBalance"`
+	BalanceDifference int64  `json:"balanceDifference"`
+	ExpectedBonus     int64  `json:"expectedBonusBalance"`
+	ActualBonus       int64  `json:"actualBonusBalance"`
+	BonusDifference   int64  `json:"bonusBalanceDifference"`
+}
+
+func newReconciliationReport(report *domain.ReconciliationReport, onlyDiscrepancies bool) *reconciliationReport {
+	res := &reconciliationReport{
+		StartedAt:     report.StartedAt,
+		FinishedAt:    report.FinishedAt,
+		Wallets:       len(report.Wallets),
+		Discrepancies: report.Discrepancies,
+		Entries:       make([]*walletReconciliation, 0, len(report.Wallets)),
+	}
+	for _, w := range report.Wallets {
+		if onlyDiscrepancies && w.Matches() {
+			continue
+		}
+		res.Entries = append(res.Entries, &walletReconciliation{
+			PlayerName:        w.PlayerName,
+			Currency:          w.Currency,
+			Transactions:      w.Transactions,
+			ExpectedBalance:   w.Expected.Real,
+			ActualBalance:     w.Actual.Real,
+			BalanceDifference: w.Difference.Real,
+			ExpectedBonus:     w.Expected.Bonus,
+			ActualBonus:       w.Actual.Bonus,
+			BonusDifference:   w.Difference.Bonus,
+		})
+	}
+
+	return res
+}
+
+// WriteReconciliationJSON writes the report with an entry per wallet.
+func WriteReconciliationJSON(w io.Writer, report *domain.ReconciliationReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newReconciliationReport(report, false))
+}
+
+// WriteReconciliationCSV writes the report with a row per wallet.
+func WriteReconciliationCSV(w io.Writer, report *domain.ReconciliationReport) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{
+		"player_name", "currency", "transactions",
+		"expected_balance", "actual_balance", "balance_difference",
+		"expected_bonus_balance", "actual_bonus_balance", "bonus_balance_difference",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range newReconciliationReport(report, false).Entries {
+		err := cw.Write([]string{
+			e.PlayerName, e.Currency, strconv.FormatInt(e.Transactions, 10),
+			strconv.FormatInt(e.ExpectedBalance, 10),
+			strconv.FormatInt(e.ActualBalance, 10),
+			strconv.FormatInt(e.BalanceDifference, 10),
+			strconv.FormatInt(e.ExpectedBonus, 10),
+			strconv.FormatInt(e.ActualBonus, 10),
+			strconv.FormatInt(e.BonusDifference, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
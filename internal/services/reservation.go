@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"mascot/internal/domain"
+)
+
+const expiredReservationsBatch = 1000
+
+// ReserveFunds holds the reservation amount of the player balance until the reservation is committed,
+// released or expires. A repeated request returns the existing reservation.
+func (w *Wallet) ReserveFunds(ctx context.Context, reservation *domain.Reservation) (domain.Balance, error) {
+	var balance domain.Balance
+	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		wallet, err := w.walletRepo.GetWallet(tCtx, reservation.PlayerName)
+		if err != nil {
+			return err
+		}
+
+		if err := validateWallet(wallet, reservation.Currency); err != nil {
+			return err
+		}
+
+		existing, err := w.reservationRepo.GetReservation(tCtx, reservation.Ref)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			if err := existing.CheckReplay(reservation); err != nil {
+				return err
+			}
+			*reservation = *existing
+			balance = wallet.Balances()
+			return nil
+		}
+
+		zero := int64(0)
+		bet := &domain.Transaction{Withdraw: &reservation.Amount, Deposit: &zero}
+		if err := w.currencyLimits(wallet.Currency).CheckTransaction(bet); err != nil {
+			return err
+		}
+
+		if err := w.expireBonus(tCtx, wallet); err != nil {
+			return err
+		}
+
+		reservation.Currency = wallet.Currency
+		reservation.ExpiresAt = w.now().Add(w.reservationTTL)
+		if err := wallet.Reserve(reservation); err != nil {
+			return err
+		}
+
+		if err := w.reservationRepo.InsertReservation(tCtx, reservation); err != nil {
+			return err
+		}
+
+		balance = wallet.Balances()
+		return w.updateBalance(tCtx, wallet)
+	})
+
+	return balance, err
+}
+
+// CommitReservation withdraws amount of the reserved money and releases the rest.
+// A repeated commit returns the transaction booked by the first one.
+func (w *Wallet) CommitReservation(ctx context.Context, playerName, ref string, amount int64) (*domain.Transaction, error) {
+	var tx *domain.Transaction
+	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		if err := w.walletRepo.LockTransactionRef(tCtx, (&domain.Reservation{Ref: ref}).TransactionRef()); err != nil {
+			return err
+		}
+
+		wallet, reservation, err := w.getReservation(tCtx, playerName, ref)
+		if err != nil {
+			return err
+		}
+
+		if reservation.Status == domain.ReservationStatusCommitted {
+			if amount != reservation.CommittedAmount {
+				return &domain.IdempotencyConflict{Fields: []string{"amount"}}
+			}
+			tx, err = w.walletRepo.GetTransactionByExternalID(tCtx, reservation.TransactionRef())
+			return err
+		}
+
+		if err := wallet.Commit(reservation, amount, w.now()); err != nil {
+			return err
+		}
+
+		deposit := int64(0)
+		tx = &domain.Transaction{
+			PlayerName: wallet.UserName,
+			Withdraw:   &reservation.CommittedAmount,
+			Deposit:    &deposit,
+			Currency:   wallet.Currency,
+			ExternalID: reservation.TransactionRef(),
+			GameID:     reservation.GameID,
+			RoundRef:   reservation.RoundRef,
+		}
+		if err := w.applyTransaction(tCtx, wallet, tx); err != nil {
+			return err
+		}
+
+		reservation.TransactionID = tx.ID
+		return w.reservationRepo.UpdateReservation(tCtx, reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// ReleaseReservation gives the reserved money back. Releasing a released or expired reservation does nothing.
+func (w *Wallet) ReleaseReservation(ctx context.Context, playerName, ref string) (domain.Balance, error) {
+	var balance domain.Balance
+	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		wallet, reservation, err := w.getReservation(tCtx, playerName, ref)
+		if err != nil {
+			return err
+		}
+
+		balance = wallet.Balances()
+		switch {
+		case reservation.Status == domain.ReservationStatusReleased,
+			reservation.Status == domain.ReservationStatusExpired:
+			return nil
+		case wallet.Expire(reservation, w.now()):
+		default:
+			if err := wallet.Release(reservation, w.now()); err != nil {
+				return err
+			}
+		}
+
+		if err := w.reservationRepo.UpdateReservation(tCtx, reservation); err != nil {
+			return err
+		}
+
+		balance = wallet.Balances()
+		return w.updateBalance(tCtx, wallet)
+	})
+
+	return balance, err
+}
+
+// ExpireReservations releases held reservations which ran out of time. It returns the number of expired ones.
+func (w *Wallet) ExpireReservations(ctx context.Context) (int, error) {
+	reservations, err := w.reservationRepo.GetExpiredReservations(ctx, w.now(), expiredReservationsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, r := range reservations {
+		err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+			wallet, reservation, err := w.getReservation(tCtx, r.PlayerName, r.Ref)
+			if err != nil {
+				return err
+			}
+
+			// the reservation may be committed or released since it was listed
+			if !wallet.Expire(reservation, w.now()) {
+				return nil
+			}
+			expired++
+
+			if err := w.reservationRepo.UpdateReservation(tCtx, reservation); err != nil {
+				return err
+			}
+
+			return w.updateBalance(tCtx, wallet)
+		})
+		if err != nil {
+			return expired, fmt.Errorf("expire reservation %s: %w", r.Ref, err)
+		}
+	}
+
+	return expired, nil
+}
+
+// getReservation locks the wallet and the reservation of the player.
+func (w *Wallet) getReservation(ctx context.Context, playerName, ref string) (*domain.Wallet, *domain.Reservation, error) {
+	wallet, err := w.walletRepo.GetWallet(ctx, playerName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reservation, err := w.reservationRepo.GetReservation(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reservation == nil || reservation.PlayerName != wallet.UserName {
+		return nil, nil, domain.ErrReservationNotFound
+	}
+
+	return wallet, reservation, nil
+}
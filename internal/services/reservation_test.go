@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mascot/internal/domain"
+)
+
+func TestWallet_Reservations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	w := newStoreWallet(newMemoryStore(t, "player", 100), WithReservationTTL(time.Minute))
+	w.now = func() time.Time { return now }
+
+	balance := func() domain.Balance {
+		t.Helper()
+		b, err := w.GetBalance(ctx, "player", "USD")
+		if err != nil {
+			t.Fatalf("GetBalance() error = %v", err)
+		}
+		return b
+	}
+	reserve := func(ref string, amount int64) error {
+		_, err := w.ReserveFunds(ctx, &domain.Reservation{Ref: ref, PlayerName: "player", Currency: "USD", Amount: amount, GameID: "g"})
+		return err
+	}
+
+	if err := reserve("r1", 60); err != nil {
+		t.Fatalf("ReserveFunds() error = %v", err)
+	}
+	if err := reserve("r1", 60); err != nil {
+		t.Fatalf("replayed ReserveFunds() error = %v", err)
+	}
+	var conflict *domain.IdempotencyConflict
+	if err := reserve("r1", 70); !errors.As(err, &conflict) {
+		t.Errorf("ReserveFunds() of another amount error = %v, want an idempotency conflict", err)
+	}
+	if got := balance(); got != (domain.Balance{Real: 100, Held: 60}) {
+		t.Errorf("balance = %+v, want 60 of 100 held", got)
+	}
+
+	bet := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(50), Deposit: int64Ptr(0),
+	}
+	if err := w.WithdrawAndDeposit(ctx, bet); !errors.Is(err, domain.ErrNotEnoughMoney) {
+		t.Errorf("bet of held money error = %v, want %v", err, domain.ErrNotEnoughMoney)
+	}
+
+	tx, err := w.CommitReservation(ctx, "player", "r1", 40)
+	if err != nil {
+		t.Fatalf("CommitReservation() error = %v", err)
+	}
+	replay, err := w.CommitReservation(ctx, "player", "r1", 40)
+	if err != nil || replay.ID != tx.ID {
+		t.Errorf("replayed CommitReservation() = %v, %v, want the transaction %s", replay, err, tx.ID)
+	}
+	if _, err := w.CommitReservation(ctx, "player", "r1", 30); !errors.As(err, &conflict) {
+		t.Errorf("CommitReservation() of another amount error = %v, want an idempotency conflict", err)
+	}
+	if got := balance(); got != (domain.Balance{Real: 60}) {
+		t.Errorf("balance after the commit = %+v, want 60 and the rest released", got)
+	}
+
+	if err := reserve("r2", 30); err != nil {
+		t.Fatalf("ReserveFunds() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if b, err := w.ReleaseReservation(ctx, "player", "r2"); err != nil || b.Held != 0 {
+			t.Errorf("ReleaseReservation() = %+v, %v, want nothing held", b, err)
+		}
+	}
+	if _, err := w.CommitReservation(ctx, "player", "r2", 10); !errors.Is(err, domain.ErrReservationReleased) {
+		t.Errorf("CommitReservation() of a released reservation error = %v, want %v", err, domain.ErrReservationReleased)
+	}
+
+	if err := reserve("r3", 30); err != nil {
+		t.Fatalf("ReserveFunds() error = %v", err)
+	}
+	now = now.Add(time.Minute)
+	if expired, err := w.ExpireReservations(ctx); err != nil || expired != 1 {
+		t.Fatalf("ExpireReservations() = %d, %v, want 1", expired, err)
+	}
+	if _, err := w.CommitReservation(ctx, "player", "r3", 10); !errors.Is(err, domain.ErrReservationExpired) {
+		t.Errorf("CommitReservation() of an expired reservation error = %v, want %v", err, domain.ErrReservationExpired)
+	}
+	if got := balance(); got != (domain.Balance{Real: 60}) {
+		t.Errorf("balance after the expiry = %+v, want 60 with nothing held", got)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+
+	"mascot/internal/domain"
+)
+
+// GetRound returns the round with every transaction made within it. The round is not locked, reading it
+// doesn't hold up bets.
+func (w *Wallet) GetRound(ctx context.Context, playerName, gameID, roundRef string) (*domain.Round, []*domain.Transaction, error) {
+	round, err := w.roundRepo.ReadRound(ctx, playerName, gameID, roundRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if round == nil {
+		return nil, nil, domain.ErrRoundNotFound
+	}
+
+	transactions, err := w.walletRepo.GetTransactionsByRound(ctx, playerName, gameID, roundRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return round, transactions, nil
+}
+
+// getTxRound returns the round tx belongs to or nil if tx has no round or the round is new.
+func (w *Wallet) getTxRound(ctx context.Context, tx *domain.Transaction) (*domain.Round, error) {
+	if tx.RoundRef == "" {
+		return nil, nil
+	}
+
+	return w.roundRepo.GetRound(ctx, tx.PlayerName, tx.GameID, tx.RoundRef)
+}
+
+func (w *Wallet) applyToRound(ctx context.Context, tx *domain.Transaction) error {
+	if tx.RoundRef == "" {
+		return nil
+	}
+
+	round, err := w.getTxRound(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if round == nil {
+		round = domain.NewRound(tx)
+	}
+
+	if err := round.Apply(tx, w.now()); err != nil {
+		return err
+	}
+
+	return w.roundRepo.SaveRound(ctx, round)
+}
+
+func (w *Wallet) revertFromRound(ctx context.Context, tx *domain.Transaction) error {
+	round, err := w.getTxRound(ctx, tx)
+	if err != nil || round == nil {
+		return err
+	}
+
+	round.Revert(tx)
+	return w.roundRepo.SaveRound(ctx, round)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"mascot/internal/domain"
+)
+
+const entrySetKindTransfer = "transfer"
+
+// Transfer moves real money between two wallets of the same currency in one db transaction.
+// A repeated transfer returns the transactions booked by the first one.
+func (w *Wallet) Transfer(ctx context.Context, transfer *domain.Transfer) error {
+	if err := transfer.Validate(); err != nil {
+		return err
+	}
+
+	return w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		if err := w.walletRepo.LockTransactionRef(tCtx, transfer.OutRef()); err != nil {
+			return err
+		}
+
+		out, err := w.walletRepo.GetTransactionByExternalID(tCtx, transfer.OutRef())
+		if err != nil {
+			return err
+		}
+
+		if out != nil {
+			in, err := w.walletRepo.GetTransactionByExternalID(tCtx, transfer.InRef())
+			if err != nil {
+				return err
+			}
+			if err := transfer.CheckReplay(out, in); err != nil {
+				return err
+			}
+			transfer.Out, transfer.In = out, in
+			return nil
+		}
+
+		wallets, err := w.lockWallets(tCtx, transfer.FromPlayer, transfer.ToPlayer)
+		if err != nil {
+			return err
+		}
+		from, to := wallets[transfer.FromPlayer], wallets[transfer.ToPlayer]
+
+		if err := validateWallet(from, transfer.Currency); err != nil {
+			return err
+		}
+
+		if err := from.Transfer(to, transfer.Amount); err != nil {
+			return err
+		}
+
+		if err := w.currencyLimits(to.Currency).CheckBalance(to, true); err != nil {
+			return err
+		}
+		recovered := to.RecoverDebt(transfer.Amount)
+
+		transfer.Out, err = w.insertTransferTransaction(tCtx, from, transfer, domain.TransactionKindTransferOut)
+		if err != nil {
+			return err
+		}
+
+		transfer.In, err = w.insertTransferTransaction(tCtx, to, transfer, domain.TransactionKindTransferIn)
+		if err != nil {
+			return err
+		}
+
+		if err := w.insertDebtRecovery(tCtx, to, recovered); err != nil {
+			return err
+		}
+
+		// both legs in one set, the money never passes through the house
+		set := domain.NewEntrySet(entrySetKindTransfer+":"+transfer.Ref, transfer.Out.ID, entrySetKindTransfer, from.Currency)
+		if err := set.Post(domain.PlayerAccount(from.UserName), -transfer.Amount); err != nil {
+			return err
+		}
+		if err := set.Post(domain.PlayerAccount(to.UserName), transfer.Amount); err != nil {
+			return err
+		}
+		if err := postEntrySet(tCtx, w.ledgerRepo, set); err != nil {
+			return err
+		}
+
+		if err := w.updateBalance(tCtx, from); err != nil {
+			return err
+		}
+
+		return w.updateBalance(tCtx, to)
+	})
+}
+
+// lockWallets locks the wallets of the players ordered by name, so that concurrent
+// multi-wallet operations can't deadlock.
+func (w *Wallet) lockWallets(ctx context.Context, playerNames ...string) (map[string]*domain.Wallet, error) {
+	sorted := append([]string(nil), playerNames...)
+	sort.Strings(sorted)
+
+	wallets := make(map[string]*domain.Wallet, len(sorted))
+	for _, playerName := range sorted {
+		if _, ok := wallets[playerName]; ok {
+			continue
+		}
+
+		wallet, err := w.walletRepo.GetWallet(ctx, playerName)
+		if err != nil {
+			return nil, err
+		}
+		wallets[playerName] = wallet
+	}
+
+	return wallets, nil
+}
+
+func (w *Wallet) insertTransferTransaction(
+	ctx context.Context,
+	wallet *domain.Wallet,
+	transfer *domain.Transfer,
+	kind domain.TransactionKind,
+) (*domain.Transaction, error) {
+	id, err := generateTxID()
+	if err != nil {
+		return nil, err
+	}
+
+	var withdraw, deposit int64
+	externalID := transfer.InRef()
+	if kind == domain.TransactionKindTransferOut {
+		withdraw, externalID = transfer.Amount, transfer.OutRef()
+	} else {
+		deposit = transfer.Amount
+	}
+
+	balance := wallet.TotalBalance()
+	tx := &domain.Transaction{
+		ID:                 id,
+		Kind:               kind,
+		PlayerName:         wallet.UserName,
+		Withdraw:           &withdraw,
+		Deposit:            &deposit,
+		Currency:           wallet.Currency,
+		ExternalID:         externalID,
+		BalanceAfterCommit: &balance,
+		TransferRef:        transfer.Ref,
+	}
+
+	return tx, w.insertTransaction(ctx, tx)
+}
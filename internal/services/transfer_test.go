@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mascot/internal/domain"
+)
+
+func TestWallet_Transfer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	w := newMemoryWallet(t, "sender", 100)
+	if _, err := w.CreateWallet(ctx, "receiver", "USD", "casino", 0); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	balances := func() (int64, int64) {
+		t.Helper()
+		var res []int64
+		for _, player := range []string{"sender", "receiver"} {
+			wallet, err := w.GetWallet(ctx, player)
+			if err != nil {
+				t.Fatalf("GetWallet() error = %v", err)
+			}
+			res = append(res, wallet.Balance)
+		}
+		return res[0], res[1]
+	}
+
+	transfer := &domain.Transfer{Ref: "t1", FromPlayer: "sender", ToPlayer: "receiver", Currency: "USD", Amount: 60}
+	if err := w.Transfer(ctx, transfer); err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if sender, receiver := balances(); sender != 40 || receiver != 60 {
+		t.Errorf("balances = %d, %d, want 40, 60", sender, receiver)
+	}
+
+	replay := &domain.Transfer{Ref: "t1", FromPlayer: "sender", ToPlayer: "receiver", Currency: "USD", Amount: 60}
+	if err := w.Transfer(ctx, replay); err != nil {
+		t.Fatalf("replayed Transfer() error = %v", err)
+	}
+	if replay.Out.ID != transfer.Out.ID || replay.In.ID != transfer.In.ID {
+		t.Errorf("replay booked new transactions")
+	}
+
+	tests := []struct {
+		name    string
+		player  string
+		ref     string
+		wantErr error
+	}{
+		{name: "out leg", player: "sender", ref: transfer.OutRef(), wantErr: domain.ErrRollbackNotAllowed},
+		{name: "in leg", player: "receiver", ref: transfer.InRef(), wantErr: domain.ErrRollbackNotAllowed},
+		{name: "in leg by the sender", player: "sender", ref: transfer.InRef(), wantErr: domain.ErrRollbackNotAllowed},
+	}
+	for _, tt := range tests {
+		err := w.RollbackTransaction(ctx, &domain.Transaction{PlayerName: tt.player, ExternalID: tt.ref})
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: RollbackTransaction() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if sender, receiver := balances(); sender != 40 || receiver != 60 {
+		t.Errorf("balances after rollbacks = %d, %d, want 40, 60", sender, receiver)
+	}
+}
+
+func TestWallet_TransferWithReservation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	w := newMemoryWallet(t, "sender", 100)
+	if _, err := w.CreateWallet(ctx, "receiver", "USD", "casino", 0); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	if _, err := w.GrantBonus(ctx, "sender", 50, 0, nil); err != nil {
+		t.Fatalf("GrantBonus() error = %v", err)
+	}
+	reservation := &domain.Reservation{Ref: "r1", PlayerName: "sender", Currency: "USD", Amount: 60, GameID: "g"}
+	if _, err := w.ReserveFunds(ctx, reservation); err != nil {
+		t.Fatalf("ReserveFunds() error = %v", err)
+	}
+
+	tooBig := &domain.Transfer{Ref: "t1", FromPlayer: "sender", ToPlayer: "receiver", Currency: "USD", Amount: 60}
+	if err := w.Transfer(ctx, tooBig); !errors.Is(err, domain.ErrNotEnoughMoney) {
+		t.Errorf("Transfer() of held money error = %v, want %v", err, domain.ErrNotEnoughMoney)
+	}
+
+	free := &domain.Transfer{Ref: "t2", FromPlayer: "sender", ToPlayer: "receiver", Currency: "USD", Amount: 40}
+	if err := w.Transfer(ctx, free); err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if b, err := w.GetBalance(ctx, "sender", "USD"); err != nil || b != (domain.Balance{Real: 60, Bonus: 50, Held: 60}) {
+		t.Errorf("GetBalance() = %+v, %v, want the held money and the bonus left", b, err)
+	}
+}
+
+func TestWallet_RollbackOfAnotherPlayer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	w := newMemoryWallet(t, "player", 100)
+	if _, err := w.CreateWallet(ctx, "other", "USD", "casino", 0); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	bet := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet-1", Withdraw: int64Ptr(30), Deposit: int64Ptr(0),
+	}
+	if err := w.WithdrawAndDeposit(ctx, bet); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+
+	err := w.RollbackTransaction(ctx, &domain.Transaction{PlayerName: "other", ExternalID: "bet-1"})
+	if !errors.Is(err, domain.ErrRollbackPlayerMismatch) {
+		t.Errorf("RollbackTransaction() error = %v, want %v", err, domain.ErrRollbackPlayerMismatch)
+	}
+
+	for _, player := range []string{"player", "other"} {
+		wallet, err := w.GetWallet(ctx, player)
+		if err != nil {
+			t.Fatalf("GetWallet() error = %v", err)
+		}
+		if want := map[string]int64{"player": 70, "other": 0}[player]; wallet.Balance != want {
+			t.Errorf("%s balance = %d, want %d", player, wallet.Balance, want)
+		}
+	}
+}
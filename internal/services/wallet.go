@@ -4,91 +4,316 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"mascot/internal/db"
 	"mascot/internal/domain"
-	"mascot/internal/repositories"
 )
 
+const defaultReservationTTL = 5 * time.Minute
+
+type WalletOption func(wallet *Wallet)
+
 type Wallet struct {
-	transactor *db.Transactor
-	walletRepo *repositories.Wallet
+	transactor      Transactor
+	walletRepo      WalletRepository
+	roundRepo       RoundRepository
+	freeRoundsRepo  FreeRoundsRepository
+	jackpotRepo     JackpotRepository
+	ledgerRepo      LedgerRepository
+	reservationRepo ReservationRepository
+	outboxRepo      OutboxRepository
+
+	spendOrder          domain.SpendOrder
+	freeRoundWinBalance domain.SubBalance
+	limits              map[string]domain.Limits
+	rollbackPolicy      domain.RollbackPolicy
+	rollbackPolicies    map[string]domain.RollbackPolicy
+	reservationTTL      time.Duration
+	balanceCache        *BalanceCache
+	notifyChanges       bool
+	now                 func() time.Time
+}
+
+func NewWallet(
+	transactor Transactor,
+	walletRepo WalletRepository,
+	roundRepo RoundRepository,
+	freeRoundsRepo FreeRoundsRepository,
+	jackpotRepo JackpotRepository,
+	ledgerRepo LedgerRepository,
+	reservationRepo ReservationRepository,
+	outboxRepo OutboxRepository,
+	options ...WalletOption,
+) *Wallet {
+	w := &Wallet{
+		transactor:          transactor,
+		walletRepo:          walletRepo,
+		roundRepo:           roundRepo,
+		freeRoundsRepo:      freeRoundsRepo,
+		jackpotRepo:         jackpotRepo,
+		ledgerRepo:          ledgerRepo,
+		reservationRepo:     reservationRepo,
+		outboxRepo:          outboxRepo,
+		spendOrder:          domain.SpendRealFirst,
+		freeRoundWinBalance: domain.SubBalanceReal,
+		rollbackPolicy:      domain.RollbackPolicyReject,
+		reservationTTL:      defaultReservationTTL,
+		now:                 time.Now,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
+// WithSpendOrder sets the order in which bets are taken from the real and bonus sub-balances.
+func WithSpendOrder(order domain.SpendOrder) WalletOption {
+	return func(wallet *Wallet) {
+		wallet.spendOrder = order
+	}
 }
 
-func NewWallet(transactor *db.Transactor, walletRepo *repositories.Wallet) *Wallet {
-	return &Wallet{transactor: transactor, walletRepo: walletRepo}
+// WithFreeRoundWinBalance sets the sub-balance free-round winnings are credited to.
+func WithFreeRoundWinBalance(balance domain.SubBalance) WalletOption {
+	return func(wallet *Wallet) {
+		wallet.freeRoundWinBalance = balance
+	}
+}
+
+// WithLimits sets bet, win and balance caps by currency.
+func WithLimits(limits map[string]domain.Limits) WalletOption {
+	return func(wallet *Wallet) {
+		wallet.limits = make(map[string]domain.Limits, len(limits))
+		for currency, l := range limits {
+			wallet.limits[strings.ToUpper(currency)] = l
+		}
+	}
+}
+
+// WithRollbackPolicies sets the rollback policy of wallets without their own, byCurrency overrides the default.
+func WithRollbackPolicies(defaultPolicy domain.RollbackPolicy, byCurrency map[string]domain.RollbackPolicy) WalletOption {
+	return func(wallet *Wallet) {
+		wallet.rollbackPolicy = defaultPolicy
+		wallet.rollbackPolicies = make(map[string]domain.RollbackPolicy, len(byCurrency))
+		for currency, policy := range byCurrency {
+			wallet.rollbackPolicies[strings.ToUpper(currency)] = policy
+		}
+	}
+}
+
+// WithReservationTTL sets how long reserved funds are held before they are released automatically.
+func WithReservationTTL(ttl time.Duration) WalletOption {
+	return func(wallet *Wallet) {
+		wallet.reservationTTL = ttl
+	}
+}
+
+// WithBalanceCache serves balance reads from the cache, see WithChangeNotifications.
+func WithBalanceCache(cache *BalanceCache) WalletOption {
+	return func(wallet *Wallet) {
+		wallet.balanceCache = cache
+		wallet.notifyChanges = true
+	}
+}
+
+// WithChangeNotifications notifies every change of a wallet, so that the balance caches of all instances
+// drop the wallet. Any process writing wallets of a deployment which caches balances needs it.
+func WithChangeNotifications() WalletOption {
+	return func(wallet *Wallet) {
+		wallet.notifyChanges = true
+	}
 }
 
-func (w *Wallet) GetBalance(ctx context.Context, playerName, currency string) (int64, error) {
-	wallet, err := w.walletRepo.GetWallet(ctx, playerName)
+// GetBalance reads the balance without locking the wallet, from the balance cache when there is one
+// or else from a replica when one is close enough.
+func (w *Wallet) GetBalance(ctx context.Context, playerName, currency string) (domain.Balance, error) {
+	wallet, err := w.readWallet(ctx, playerName)
 	if err != nil {
-		return 0, err
+		return domain.Balance{}, err
 	}
 
 	if err := validateWallet(wallet, currency); err != nil {
-		return 0, err
+		return domain.Balance{}, err
 	}
 
-	return wallet.Balance, nil
+	// an expired bonus is forfeited by the next write, it must not be shown as spendable meanwhile
+	wallet.ExpireBonus(w.now())
+
+	return wallet.Balances(), nil
 }
 
-func (w *Wallet) WithdrawAndDeposit(ctx context.Context, transaction *domain.Transaction) error {
-	handledTx, err := w.walletRepo.GetTransactionByExternalID(ctx, transaction.ExternalID)
-	if err != nil {
-		return err
+// readWallet reads the wallet through the balance cache. Misses are read from the primary, a lagging replica
+// could fill the cache with a balance older than the last invalidation.
+func (w *Wallet) readWallet(ctx context.Context, playerName string) (*domain.Wallet, error) {
+	if w.balanceCache == nil {
+		return w.walletRepo.ReadWallet(db.PreferReplica(ctx), playerName)
 	}
 
-	if handledTx != nil && handledTx.RolledBack {
-		return domain.ErrTransactionIsRolledBack
+	wallet, ticket := w.balanceCache.Get(playerName)
+	if wallet != nil {
+		return wallet, nil
 	}
 
-	if handledTx != nil {
-		*transaction = *handledTx
-		return nil
+	wallet, err := w.walletRepo.ReadWallet(ctx, playerName)
+	if err != nil {
+		return nil, err
 	}
 
-	var wallet *domain.Wallet
-	err = w.transactor.WithTx(ctx, func(tCtx context.Context) error {
-		var err error
-		wallet, err = w.walletRepo.GetWallet(tCtx, transaction.PlayerName)
-		if err != nil {
-			return err
-		}
+	w.balanceCache.Fill(wallet, ticket)
+	return wallet, nil
+}
 
-		if err := validateTransaction(transaction); err != nil {
-			return err
-		}
+func (w *Wallet) WithdrawAndDeposit(ctx context.Context, transaction *domain.Transaction) error {
+	request := *transaction
+	err := w.withdrawAndDeposit(ctx, transaction)
+	if errors.Is(err, domain.ErrTransactionExists) {
+		// the ref was stored by a concurrent writer which didn't take the ref lock, a second attempt replays it
+		*transaction = request
+		return w.withdrawAndDeposit(ctx, transaction)
+	}
 
-		if err := validateWallet(wallet, transaction.Currency); err != nil {
+	return err
+}
+
+func (w *Wallet) withdrawAndDeposit(ctx context.Context, transaction *domain.Transaction) error {
+	return w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		// duplicates wait here until the first request commits and then replay its result
+		if err := w.walletRepo.LockTransactionRef(tCtx, transaction.ExternalID); err != nil {
 			return err
 		}
 
-		if err := wallet.WithdrawAndDeposit(*transaction.Deposit, *transaction.Withdraw); err != nil {
+		handledTx, err := w.walletRepo.GetTransactionByExternalID(tCtx, transaction.ExternalID)
+		if err != nil {
 			return err
 		}
 
-		if transaction.ID, err = generateTxID(); err != nil {
-			return err
+		if handledTx != nil && handledTx.RolledBack {
+			return domain.ErrTransactionIsRolledBack
 		}
 
-		if err := w.walletRepo.InsertTransaction(tCtx, transaction); err != nil {
-			return err
+		if handledTx != nil {
+			return w.replay(tCtx, transaction, handledTx)
 		}
 
-		if err := w.walletRepo.UpdateBalance(tCtx, wallet); err != nil {
+		wallet, err := w.walletRepo.GetWallet(tCtx, transaction.PlayerName)
+		if err != nil {
 			return err
 		}
 
-		return nil
+		return w.applyTransaction(tCtx, wallet, transaction)
 	})
+}
 
-	return err
+// applyTransaction books a new withdrawal and deposit on the locked wallet.
+func (w *Wallet) applyTransaction(ctx context.Context, wallet *domain.Wallet, transaction *domain.Transaction) error {
+	if err := validateTransaction(transaction); err != nil {
+		return err
+	}
+
+	if err := validateWallet(wallet, transaction.Currency); err != nil {
+		return err
+	}
+
+	limits := w.currencyLimits(wallet.Currency)
+	if err := limits.CheckTransaction(transaction); err != nil {
+		return err
+	}
+
+	if err := w.expireBonus(ctx, wallet); err != nil {
+		return err
+	}
+
+	if err := w.spendFreeRound(ctx, wallet, transaction); err != nil {
+		return err
+	}
+
+	transaction.Kind = domain.TransactionKindWithdrawAndDeposit
+	if err := wallet.WithdrawAndDeposit(transaction, w.spendOrder); err != nil {
+		return err
+	}
+
+	credited := transaction.DepositAmount() > transaction.WithdrawAmount()
+	if err := limits.CheckBalance(wallet, credited); err != nil {
+		return err
+	}
+
+	// only the real money the player won pays the debt, a push or a bonus win recovers nothing
+	recovered := wallet.RecoverDebt(transaction.RealDelta())
+	transaction.ConvertedBonus = convertBonus(wallet)
+
+	// the bonus conversion doesn't change the total, so the balance is final here
+	balance := wallet.TotalBalance()
+	transaction.BalanceAfterCommit = &balance
+
+	if err := w.applyToRound(ctx, transaction); err != nil {
+		return err
+	}
+
+	var err error
+	if transaction.ID, err = generateTxID(); err != nil {
+		return err
+	}
+
+	jackpotEntries, err := w.applyJackpots(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	if err := w.insertTransaction(ctx, transaction); err != nil {
+		return err
+	}
+
+	set, err := domain.NewTransactionEntrySet(transaction, jackpotEntries)
+	if err != nil {
+		return err
+	}
+
+	if err := postEntrySet(ctx, w.ledgerRepo, set); err != nil {
+		return err
+	}
+
+	if err := w.insertDebtRecovery(ctx, wallet, recovered); err != nil {
+		return err
+	}
+
+	if err := w.insertBonusConversion(ctx, wallet, transaction.ConvertedBonus, false); err != nil {
+		return err
+	}
+
+	return w.updateBalance(ctx, wallet)
+}
+
+// replay answers a repeated request with the stored result of the handled transaction.
+func (w *Wallet) replay(ctx context.Context, transaction, handledTx *domain.Transaction) error {
+	payouts, err := w.jackpotPayouts(ctx, handledTx)
+	if err != nil {
+		return err
+	}
+	handledTx.JackpotPayouts = payouts
+
+	if err := transaction.CheckReplay(handledTx); err != nil {
+		return err
+	}
+
+	// the current balance isn't the result of a transaction stored before the result was recorded
+	if handledTx.BalanceAfterCommit == nil {
+		return domain.ErrReplayUnavailable
+	}
+
+	*transaction = *handledTx
+	return nil
 }
 
 func (w *Wallet) RollbackTransaction(ctx context.Context, transaction *domain.Transaction) error {
 	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		if err := w.walletRepo.LockTransactionRef(tCtx, transaction.ExternalID); err != nil {
+			return err
+		}
+
 		wallet, err := w.walletRepo.GetWallet(tCtx, transaction.PlayerName)
 		if err != nil {
 			return err
@@ -100,32 +325,214 @@ func (w *Wallet) RollbackTransaction(ctx context.Context, transaction *domain.Tr
 		}
 
 		if handledTx == nil {
+			transaction.Kind = domain.TransactionKindWithdrawAndDeposit
 			transaction.Currency = wallet.Currency
 			transaction.ID, err = generateTxID()
 			if err != nil {
 				return err
 			}
-			return w.walletRepo.InsertTransaction(tCtx, transaction)
+			return w.insertTransaction(tCtx, transaction)
+		}
+
+		if err := handledTx.CheckRollback(transaction.PlayerName); err != nil {
+			return err
 		}
 
 		if handledTx.RolledBack {
 			return nil
 		}
 
-		if err := wallet.Rollback(*handledTx.Deposit, *handledTx.Withdraw); err != nil {
+		policy := w.walletRollbackPolicy(wallet)
+		if handledTx.RollbackShortfall, err = wallet.Rollback(handledTx, policy); err != nil {
+			return err
+		}
+
+		if err := w.revertFromRound(tCtx, handledTx); err != nil {
+			return err
+		}
+
+		if err := w.restoreFreeRound(tCtx, handledTx); err != nil {
+			return err
+		}
+
+		jackpotEntries, err := w.reverseJackpots(tCtx, handledTx)
+		if err != nil {
+			return err
+		}
+
+		set, err := domain.NewRollbackEntrySet(handledTx, jackpotEntries)
+		if err != nil {
 			return err
 		}
 
-		if err := w.walletRepo.UpdateBalance(tCtx, wallet); err != nil {
+		if err := postEntrySet(tCtx, w.ledgerRepo, set); err != nil {
 			return err
 		}
 
-		return w.walletRepo.SetTransactionRolledBack(tCtx, handledTx.ID)
+		if err := w.insertBonusConversion(tCtx, wallet, handledTx.ConvertedBonus, true); err != nil {
+			return err
+		}
+
+		if err := w.settleShortfall(tCtx, wallet, policy); err != nil {
+			return err
+		}
+
+		if err := w.updateBalance(tCtx, wallet); err != nil {
+			return err
+		}
+
+		return w.setTransactionRolledBack(tCtx, handledTx)
 	})
 
 	return err
 }
 
+func (w *Wallet) ChangeStatus(ctx context.Context, playerName string, status domain.WalletStatus, actor, reason string) (*domain.WalletStatusChange, error) {
+	var change *domain.WalletStatusChange
+	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		wallet, err := w.walletRepo.GetWallet(tCtx, playerName)
+		if err != nil {
+			return err
+		}
+
+		change = &domain.WalletStatusChange{
+			WalletID:   wallet.ID,
+			PlayerName: wallet.UserName,
+			From:       wallet.Status,
+			To:         status,
+			Actor:      actor,
+			Reason:     reason,
+		}
+
+		if err := wallet.ChangeStatus(status); err != nil {
+			return err
+		}
+
+		if err := w.walletRepo.UpdateStatus(tCtx, wallet); err != nil {
+			return err
+		}
+
+		if err := w.walletChanged(tCtx, wallet.UserName); err != nil {
+			return err
+		}
+
+		return w.walletRepo.InsertStatusChange(tCtx, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (w *Wallet) GetStatusHistory(ctx context.Context, playerName string) ([]*domain.WalletStatusChange, error) {
+	return w.walletRepo.GetStatusChanges(db.PreferReplica(ctx), playerName)
+}
+
+// CreateWallet creates an active wallet of the operator and books its balance in the ledger against the house.
+func (w *Wallet) CreateWallet(ctx context.Context, playerName, currency, operator string, balance int64) (*domain.Wallet, error) {
+	wallet := &domain.Wallet{
+		UserName: playerName,
+		Currency: strings.ToUpper(currency),
+		Balance:  balance,
+		Status:   domain.WalletStatusActive,
+		Operator: operator,
+	}
+	if err := wallet.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		if err := w.walletRepo.InsertWallet(tCtx, wallet); err != nil {
+			return err
+		}
+
+		set := domain.NewEntrySet(domain.OpeningEntrySetID(wallet.ID), "", domain.EntrySetKindOpening, wallet.Currency)
+		if err := set.Post(domain.PlayerAccount(wallet.UserName), wallet.Balance); err != nil {
+			return err
+		}
+		if err := set.Settle(domain.HouseAccount(wallet.Currency)); err != nil {
+			return err
+		}
+		if len(set.Entries) == 0 {
+			return nil
+		}
+
+		return postEntrySet(tCtx, w.ledgerRepo, set)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// GetWallet returns the wallet as it is, unlike GetBalance it doesn't check the status or expire the bonus.
+func (w *Wallet) GetWallet(ctx context.Context, playerName string) (*domain.Wallet, error) {
+	return w.walletRepo.ReadWallet(db.PreferReplica(ctx), playerName)
+}
+
+// ListWallets returns up to limit wallets ordered by player name, starting after the given name.
+func (w *Wallet) ListWallets(ctx context.Context, after string, limit int) ([]*domain.Wallet, error) {
+	return w.walletRepo.ListWallets(db.PreferReplica(ctx), after, limit)
+}
+
+// GetTransaction returns the transaction with the external id.
+func (w *Wallet) GetTransaction(ctx context.Context, externalID string) (*domain.Transaction, error) {
+	tx, err := w.walletRepo.GetTransactionByExternalID(db.PreferReplica(ctx), externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx == nil {
+		return nil, domain.ErrTransactionNotFound
+	}
+
+	return tx, nil
+}
+
+func (w *Wallet) currencyLimits(currency string) domain.Limits {
+	return w.limits[strings.ToUpper(currency)]
+}
+
+// insertSystemTransaction records a balance movement the wallet made on its own, like a bonus conversion.
+func (w *Wallet) insertSystemTransaction(
+	ctx context.Context,
+	wallet *domain.Wallet,
+	kind domain.TransactionKind,
+	withdraw, deposit, withdrawBonus, depositBonus int64,
+) (*domain.Transaction, error) {
+	id, err := generateTxID()
+	if err != nil {
+		return nil, err
+	}
+
+	balance := wallet.TotalBalance()
+	tx := &domain.Transaction{
+		ID:                 id,
+		Kind:               kind,
+		PlayerName:         wallet.UserName,
+		Withdraw:           &withdraw,
+		Deposit:            &deposit,
+		WithdrawBonus:      withdrawBonus,
+		DepositBonus:       depositBonus,
+		Currency:           wallet.Currency,
+		ExternalID:         string(kind) + ":" + id,
+		BalanceAfterCommit: &balance,
+	}
+
+	if err := w.insertTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	set, err := domain.NewTransactionEntrySet(tx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, postEntrySet(ctx, w.ledgerRepo, set)
+}
+
 func validateTransaction(transaction *domain.Transaction) error {
 	if *transaction.Withdraw < 0 {
 		return domain.ErrNegativeWithdrawal
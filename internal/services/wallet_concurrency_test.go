@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+// testDSNEnv points the concurrency tests to a migrated database, they are skipped without it.
+const testDSNEnv = "MASCOT_TEST_POSTGRES_DSN"
+
+const (
+	duplicates     = 300
+	openingBalance = 1000000
+)
+
+type concurrencySuite struct {
+	conn   *pgxpool.Pool
+	wallet *Wallet
+}
+
+func newConcurrencySuite(t *testing.T) *concurrencySuite {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	conn, err := pgxpool.Connect(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	transactor := db.NewTransactor(conn, zap.NewNop())
+	wallet := NewWallet(
+		transactor,
+		repositories.NewWallet(transactor),
+		repositories.NewRound(transactor),
+		repositories.NewFreeRounds(transactor),
+		repositories.NewJackpot(transactor),
+		repositories.NewLedger(transactor),
+		repositories.NewReservation(transactor),
+		repositories.NewOutbox(transactor),
+	)
+
+	return &concurrencySuite{conn: conn, wallet: wallet}
+}
+
+// newPlayer creates a wallet booked in the ledger like the wallets opened by migrations.
+func (s *concurrencySuite) newPlayer(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	playerName := "concurrency-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	_, err := s.conn.Exec(ctx, "INSERT INTO wallets (player_name, currency, balance, opening_balance) "+
+		"VALUES ($1, 'USD', $2, $2)", playerName, openingBalance)
+	if err != nil {
+		t.Fatalf("insert wallet: %v", err)
+	}
+
+	set := domain.NewEntrySet("opening:"+playerName, "", "opening", "USD")
+	_ = set.Post(domain.PlayerAccount(playerName), openingBalance)
+	_ = set.Settle(domain.HouseAccount("USD"))
+	if err := repositories.NewLedger(db.NewTransactor(s.conn, zap.NewNop())).InsertEntrySet(ctx, set); err != nil {
+		t.Fatalf("insert opening entry set: %v", err)
+	}
+
+	return playerName
+}
+
+func (s *concurrencySuite) balance(t *testing.T, playerName string) int64 {
+	t.Helper()
+	balance, err := s.wallet.GetBalance(context.Background(), playerName, "USD")
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	return balance.Total()
+}
+
+func (s *concurrencySuite) transactions(t *testing.T, ref string) int {
+	t.Helper()
+	var count int
+	err := s.conn.QueryRow(context.Background(), "SELECT count(*) FROM transactions WHERE external_id = $1", ref).Scan(&count)
+	if err != nil {
+		t.Fatalf("count transactions: %v", err)
+	}
+	return count
+}
+
+// parallel runs fn n times at once and returns the errors in call order.
+func parallel(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	return errs
+}
+
+func withdrawTx(playerName, ref string, withdraw, deposit int64) *domain.Transaction {
+	return &domain.Transaction{
+		PlayerName: playerName,
+		Withdraw:   &withdraw,
+		Deposit:    &deposit,
+		Currency:   "USD",
+		ExternalID: ref,
+	}
+}
+
+func TestWallet_WithdrawAndDeposit_ConcurrentDuplicates(t *testing.T) {
+	s := newConcurrencySuite(t)
+	playerName := s.newPlayer(t)
+	ref := playerName + ":bet"
+
+	results := make([]*domain.Transaction, duplicates)
+	errs := parallel(duplicates, func(i int) error {
+		results[i] = withdrawTx(playerName, ref, 10, 3)
+		return s.wallet.WithdrawAndDeposit(context.Background(), results[i])
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("WithdrawAndDeposit() #%d error = %v", i, err)
+		}
+		if results[i].ID != results[0].ID || *results[i].BalanceAfterCommit != *results[0].BalanceAfterCommit {
+			t.Fatalf("WithdrawAndDeposit() #%d = %s/%d, want %s/%d", i,
+				results[i].ID, *results[i].BalanceAfterCommit, results[0].ID, *results[0].BalanceAfterCommit)
+		}
+	}
+
+	if got, want := s.balance(t, playerName), int64(openingBalance-7); got != want {
+		t.Errorf("balance = %d, want %d", got, want)
+	}
+	if got := s.transactions(t, ref); got != 1 {
+		t.Errorf("stored transactions = %d, want 1", got)
+	}
+}
+
+func TestWallet_WithdrawAndDeposit_ConcurrentConflicts(t *testing.T) {
+	s := newConcurrencySuite(t)
+	playerName := s.newPlayer(t)
+	ref := playerName + ":bet"
+
+	// every request reuses the ref with its own amount, only the first one may be applied
+	results := make([]*domain.Transaction, duplicates)
+	errs := parallel(duplicates, func(i int) error {
+		results[i] = withdrawTx(playerName, ref, int64(i+1), 0)
+		return s.wallet.WithdrawAndDeposit(context.Background(), results[i])
+	})
+
+	var applied int64
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if applied != 0 {
+				t.Fatalf("WithdrawAndDeposit() #%d applied, withdrawal of %d is already applied", i, applied)
+			}
+			applied = results[i].WithdrawAmount()
+		case !errors.Is(err, domain.ErrIdempotencyConflict):
+			t.Fatalf("WithdrawAndDeposit() #%d error = %v, want %v", i, err, domain.ErrIdempotencyConflict)
+		}
+	}
+
+	if got, want := s.balance(t, playerName), openingBalance-applied; got != want {
+		t.Errorf("balance = %d, want %d", got, want)
+	}
+	if got := s.transactions(t, ref); got != 1 {
+		t.Errorf("stored transactions = %d, want 1", got)
+	}
+}
+
+func TestWallet_ConcurrentWithdrawAndRollback(t *testing.T) {
+	s := newConcurrencySuite(t)
+	playerName := s.newPlayer(t)
+	ref := playerName + ":bet"
+
+	errs := parallel(duplicates, func(i int) error {
+		if i%2 == 0 {
+			return s.wallet.RollbackTransaction(context.Background(), &domain.Transaction{
+				PlayerName: playerName,
+				ExternalID: ref,
+				RolledBack: true,
+			})
+		}
+		return s.wallet.WithdrawAndDeposit(context.Background(), withdrawTx(playerName, ref, 10, 0))
+	})
+
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, domain.ErrTransactionIsRolledBack) {
+			t.Fatalf("#%d error = %v", i, err)
+		}
+	}
+
+	// whichever came first, the bet ends up rolled back
+	if got := s.balance(t, playerName); got != openingBalance {
+		t.Errorf("balance = %d, want %d", got, openingBalance)
+	}
+	if got := s.transactions(t, ref); got != 1 {
+		t.Errorf("stored transactions = %d, want 1", got)
+	}
+}
+
+func TestWallet_WithdrawAndDeposit_ConcurrentDistinctRefs(t *testing.T) {
+	s := newConcurrencySuite(t)
+	playerName := s.newPlayer(t)
+
+	errs := parallel(duplicates, func(i int) error {
+		ref := playerName + ":bet:" + strconv.Itoa(i%(duplicates/3))
+		return s.wallet.WithdrawAndDeposit(context.Background(), withdrawTx(playerName, ref, 10, 0))
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("WithdrawAndDeposit() #%d error = %v", i, err)
+		}
+	}
+
+	if got, want := s.balance(t, playerName), int64(openingBalance-10*(duplicates/3)); got != want {
+		t.Errorf("balance = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"mascot/internal/domain"
+	"mascot/internal/memory"
+)
+
+// newMemoryStore returns a memory storage with one USD wallet holding balance.
+func newMemoryStore(t *testing.T, playerName string, balance int64) *memory.Store {
+	t.Helper()
+	store := memory.NewStore()
+	err := memory.NewWallet(store).InsertWallet(context.Background(), &domain.Wallet{UserName: playerName, Currency: "USD", Balance: balance})
+	if err != nil {
+		t.Fatalf("InsertWallet() error = %v", err)
+	}
+
+	return store
+}
+
+// newStoreWallet returns a wallet engine on the memory storage.
+func newStoreWallet(store *memory.Store, options ...WalletOption) *Wallet {
+	return NewWallet(
+		store,
+		memory.NewWallet(store),
+		memory.NewRound(store),
+		memory.NewFreeRounds(store),
+		memory.NewJackpot(store),
+		memory.NewLedger(store),
+		memory.NewReservation(store),
+		memory.NewOutbox(store),
+		options...,
+	)
+}
+
+// newMemoryWallet returns a wallet engine on the memory storage with one USD wallet holding balance.
+func newMemoryWallet(t *testing.T, playerName string, balance int64) *Wallet {
+	t.Helper()
+	return newStoreWallet(newMemoryStore(t, playerName, balance))
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestWallet_WithdrawAndDeposit(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	w := newMemoryWallet(t, "player", 100)
+
+	balance := func() int64 {
+		t.Helper()
+		b, err := w.GetBalance(ctx, "player", "USD")
+		if err != nil {
+			t.Fatalf("GetBalance() error = %v", err)
+		}
+		return b.Real
+	}
+
+	bet := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet-1", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(30), Deposit: int64Ptr(50),
+	}
+	if err := w.WithdrawAndDeposit(ctx, bet); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+	if got := balance(); got != 120 {
+		t.Errorf("balance = %d, want 120", got)
+	}
+
+	replay := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet-1", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(30), Deposit: int64Ptr(50),
+	}
+	if err := w.WithdrawAndDeposit(ctx, replay); err != nil {
+		t.Fatalf("replayed WithdrawAndDeposit() error = %v", err)
+	}
+	if replay.ID != bet.ID || balance() != 120 {
+		t.Errorf("replay = %s with balance %d, want %s with balance 120", replay.ID, balance(), bet.ID)
+	}
+
+	tooBig := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet-2", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(1000), Deposit: int64Ptr(0),
+	}
+	if err := w.WithdrawAndDeposit(ctx, tooBig); !errors.Is(err, domain.ErrNotEnoughMoney) {
+		t.Errorf("WithdrawAndDeposit() error = %v, want %v", err, domain.ErrNotEnoughMoney)
+	}
+
+	rollback := &domain.Transaction{PlayerName: "player", ExternalID: "bet-1", RolledBack: true}
+	if err := w.RollbackTransaction(ctx, rollback); err != nil {
+		t.Fatalf("RollbackTransaction() error = %v", err)
+	}
+	if got := balance(); got != 100 {
+		t.Errorf("balance after rollback = %d, want 100", got)
+	}
+
+	history, _, err := w.GetTransactionHistory(ctx, domain.TransactionFilter{PlayerName: "player", Limit: 10})
+	if err != nil {
+		t.Fatalf("GetTransactionHistory() error = %v", err)
+	}
+	if len(history) != 1 || history[0].ID != bet.ID || !history[0].RolledBack {
+		t.Errorf("history = %+v, want the rolled back bet", history)
+	}
+}
+
+func TestWallet_WithdrawAndDeposit_LegacyReplay(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := newMemoryStore(t, "player", 100)
+	w := newStoreWallet(store)
+
+	legacy := &domain.Transaction{
+		ID: "legacy", Kind: domain.TransactionKindWithdrawAndDeposit, PlayerName: "player", Currency: "USD",
+		ExternalID: "bet-1", GameID: "g", RoundRef: "r", Withdraw: int64Ptr(30), Deposit: int64Ptr(50),
+	}
+	if err := memory.NewWallet(store).InsertTransaction(ctx, legacy); err != nil {
+		t.Fatalf("InsertTransaction() error = %v", err)
+	}
+
+	replay := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet-1", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(30), Deposit: int64Ptr(50),
+	}
+	if err := w.WithdrawAndDeposit(ctx, replay); !errors.Is(err, domain.ErrReplayUnavailable) {
+		t.Errorf("replayed WithdrawAndDeposit() error = %v, want %v", err, domain.ErrReplayUnavailable)
+	}
+}
+
+func TestWallet_WithdrawAndDeposit_Concurrent(t *testing.T) {
+	t.Parallel()
+	const bets = 200
+	ctx := context.Background()
+	w := newMemoryWallet(t, "player", bets/2)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, bets)
+	for i := 0; i < bets; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- w.WithdrawAndDeposit(ctx, &domain.Transaction{
+				PlayerName: "player", Currency: "USD", ExternalID: "bet-" + strconv.Itoa(i),
+				Withdraw: int64Ptr(1), Deposit: int64Ptr(0),
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	var rejected int
+	for err := range errs {
+		switch {
+		case errors.Is(err, domain.ErrNotEnoughMoney):
+			rejected++
+		case err != nil:
+			t.Fatalf("WithdrawAndDeposit() error = %v", err)
+		}
+	}
+
+	balance, err := w.GetBalance(ctx, "player", "USD")
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	if balance.Real != 0 || rejected != bets/2 {
+		t.Errorf("balance = %d with %d bets rejected, want 0 with %d", balance.Real, rejected, bets/2)
+	}
+}
+
+func TestWallet_CreateWallet(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	w := newMemoryWallet(t, "player", 100)
+
+	created, err := w.CreateWallet(ctx, "newcomer", "eur", "casino", 250)
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	if created.Currency != "EUR" || created.Status != domain.WalletStatusActive {
+		t.Errorf("created wallet = %s %s, want EUR active", created.Currency, created.Status)
+	}
+
+	got, err := w.GetWallet(ctx, "newcomer")
+	if err != nil {
+		t.Fatalf("GetWallet() error = %v", err)
+	}
+	if got.ID != created.ID || got.Balance != 250 || got.Operator != "casino" {
+		t.Errorf("wallet = %d of %q with balance %d, want %d of casino with balance 250",
+			got.ID, got.Operator, got.Balance, created.ID)
+	}
+
+	if _, err := w.CreateWallet(ctx, "player", "USD", "casino", 0); !errors.Is(err, domain.ErrWalletExists) {
+		t.Errorf("CreateWallet() of an existing wallet error = %v, want %v", err, domain.ErrWalletExists)
+	}
+	if _, err := w.CreateWallet(ctx, "broke", "USD", "casino", -1); !errors.Is(err, domain.ErrInvalidWallet) {
+		t.Errorf("CreateWallet() with a negative balance error = %v, want %v", err, domain.ErrInvalidWallet)
+	}
+	if _, err := w.CreateWallet(ctx, "stray", "USD", "", 0); !errors.Is(err, domain.ErrInvalidWallet) {
+		t.Errorf("CreateWallet() without an operator error = %v, want %v", err, domain.ErrInvalidWallet)
+	}
+
+	wallets, err := w.ListWallets(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("ListWallets() error = %v", err)
+	}
+	if len(wallets) != 2 || wallets[0].UserName != "newcomer" || wallets[1].UserName != "player" {
+		t.Errorf("ListWallets() returned %d wallets, want newcomer and player", len(wallets))
+	}
+}
+
+func TestWallet_GetTransaction(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	w := newMemoryWallet(t, "player", 100)
+
+	if _, err := w.GetTransaction(ctx, "missing"); !errors.Is(err, domain.ErrTransactionNotFound) {
+		t.Errorf("GetTransaction() error = %v, want %v", err, domain.ErrTransactionNotFound)
+	}
+
+	bet := &domain.Transaction{
+		PlayerName: "player", Currency: "USD", ExternalID: "bet-1", GameID: "g", RoundRef: "r",
+		Withdraw: int64Ptr(30), Deposit: int64Ptr(0),
+	}
+	if err := w.WithdrawAndDeposit(ctx, bet); err != nil {
+		t.Fatalf("WithdrawAndDeposit() error = %v", err)
+	}
+
+	got, err := w.GetTransaction(ctx, "bet-1")
+	if err != nil {
+		t.Fatalf("GetTransaction() error = %v", err)
+	}
+	if got.ID != bet.ID {
+		t.Errorf("GetTransaction() = %s, want %s", got.ID, bet.ID)
+	}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+const (
+	defaultWebhookBatchSize   = 100
+	defaultWebhookConcurrency = 10
+	deadLettersLimit          = 1000
+	// webhookLeaseMargin is added to the client timeout to lease the claimed deliveries.
+	webhookLeaseMargin = time.Minute
+)
+
+// Webhook request headers. The signature is the hex HMAC-SHA256 of the timestamp, a dot and the body,
+// keyed with the subscription secret.
+const (
+	WebhookEventHeader     = "X-Mascot-Event"
+	WebhookDeliveryHeader  = "X-Mascot-Delivery"
+	WebhookTimestampHeader = "X-Mascot-Timestamp"
+	WebhookSignatureHeader = "X-Mascot-Signature"
+)
+
+// SignWebhook returns the signature of a webhook request as sent in WebhookSignatureHeader.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhooks fans the outbox events out to the webhook subscriptions and delivers them.
+type Webhooks struct {
+	transactor  *db.Transactor
+	webhookRepo *repositories.Webhook
+	client      *http.Client
+	retry       domain.WebhookRetry
+	batchSize   int
+	concurrency int
+	lease       time.Duration
+	now         func() time.Time
+}
+
+func NewWebhooks(transactor *db.Transactor, webhookRepo *repositories.Webhook, client *http.Client, retry domain.WebhookRetry) *Webhooks {
+	return &Webhooks{
+		transactor:  transactor,
+		webhookRepo: webhookRepo,
+		client:      client,
+		retry:       retry,
+		batchSize:   defaultWebhookBatchSize,
+		concurrency: defaultWebhookConcurrency,
+		lease:       client.Timeout + webhookLeaseMargin,
+		now:         time.Now,
+	}
+}
+
+func (w *Webhooks) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+
+	subscription.Active = true
+	return w.webhookRepo.InsertSubscription(ctx, subscription)
+}
+
+func (w *Webhooks) GetSubscriptions(ctx context.Context, operator string) ([]*domain.WebhookSubscription, error) {
+	return w.webhookRepo.GetSubscriptions(ctx, operator)
+}
+
+func (w *Webhooks) SetSubscriptionActive(ctx context.Context, id int64, active bool) error {
+	return w.webhookRepo.SetSubscriptionActive(ctx, id, active)
+}
+
+// Publish queues a delivery of the event for every active subscription to its type of the operator owning
+// the wallet of the event. It is an EventSink,
+// the outbox calls it in its transaction so that the event is queued exactly when it is dispatched.
+func (w *Webhooks) Publish(ctx context.Context, event *domain.Event) error {
+	subscriptions, err := w.webhookRepo.GetPlayerSubscriptions(ctx, event.PlayerName)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(newEventMessage(event)); err != nil {
+				return err
+			}
+		}
+
+		err := w.webhookRepo.InsertDelivery(ctx, &domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         domain.WebhookDeliveryStatusPending,
+			NextAttemptAt:  w.now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deliver sends up to the batch size of due deliveries, concurrently. A failed delivery is retried with
+// exponential backoff and moved to the dead letters once it runs out of attempts. It returns the number
+// of sent deliveries.
+func (w *Webhooks) Deliver(ctx context.Context) (int, error) {
+	var sent int
+	for sent < w.batchSize {
+		limit := w.concurrency
+		if left := w.batchSize - sent; left < limit {
+			limit = left
+		}
+
+		claims, subscriptions, err := w.claim(ctx, limit)
+		if err != nil || len(claims) == 0 {
+			return sent, err
+		}
+
+		errs := make([]error, len(claims))
+		var wg sync.WaitGroup
+		for i, claim := range claims {
+			wg.Add(1)
+			go func(i int, claim *domain.WebhookDelivery) {
+				defer wg.Done()
+				errs[i] = w.send(ctx, subscriptions[claim.SubscriptionID], claim)
+			}(i, claim)
+		}
+		wg.Wait()
+
+		for i, claim := range claims {
+			if err := w.record(ctx, claim, errs[i]); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// claim leases up to limit due deliveries to this sender so that the others skip them while they are
+// sent outside of a transaction. It returns them with their subscriptions by ID.
+func (w *Webhooks) claim(
+	ctx context.Context,
+	limit int,
+) ([]*domain.WebhookDelivery, map[int64]*domain.WebhookSubscription, error) {
+	var claims []*domain.WebhookDelivery
+	subscriptions := make(map[int64]*domain.WebhookSubscription)
+	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		deliveries, err := w.webhookRepo.GetDueDeliveries(tCtx, w.now(), limit)
+		if err != nil {
+			return err
+		}
+
+		claims = claims[:0]
+		for _, delivery := range deliveries {
+			if _, ok := subscriptions[delivery.SubscriptionID]; !ok {
+				subscription, err := w.webhookRepo.GetSubscription(tCtx, delivery.SubscriptionID)
+				if err != nil {
+					return err
+				}
+				subscriptions[delivery.SubscriptionID] = subscription
+			}
+
+			delivery.Claim(w.now(), w.lease)
+			if err := w.webhookRepo.UpdateDelivery(tCtx, delivery); err != nil {
+				return err
+			}
+			claims = append(claims, delivery)
+		}
+
+		return nil
+	})
+
+	return claims, subscriptions, err
+}
+
+// record stores the outcome of sending the claimed delivery, unless another sender took the delivery
+// over when the lease ran out.
+func (w *Webhooks) record(ctx context.Context, claim *domain.WebhookDelivery, sendErr error) error {
+	return w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		delivery, err := w.webhookRepo.GetDelivery(tCtx, claim.ID)
+		if err != nil || !delivery.ClaimedAs(claim) {
+			return err
+		}
+
+		if sendErr == nil {
+			delivery.Delivered(w.now())
+			return w.webhookRepo.UpdateDelivery(tCtx, delivery)
+		}
+
+		if !delivery.Failed(sendErr, w.now(), w.retry) {
+			return w.webhookRepo.UpdateDelivery(tCtx, delivery)
+		}
+
+		if err := w.webhookRepo.UpdateDelivery(tCtx, delivery); err != nil {
+			return err
+		}
+		return w.webhookRepo.InsertDeadLetter(tCtx, delivery)
+	})
+}
+
+func (w *Webhooks) GetDeliveries(
+	ctx context.Context,
+	subscriptionID int64,
+	status domain.WebhookDeliveryStatus,
+	beforeID int64,
+	limit int,
+) ([]*domain.WebhookDelivery, error) {
+	if status != "" && !status.Valid() {
+		return nil, domain.ErrInvalidFilter
+	}
+
+	return w.webhookRepo.GetDeliveries(db.PreferReplica(ctx), subscriptionID, status, beforeID, limit)
+}
+
+func (w *Webhooks) GetDeadLetters(ctx context.Context) ([]*domain.WebhookDeadLetter, error) {
+	return w.webhookRepo.GetDeadLetters(ctx, deadLettersLimit)
+}
+
+// ReplayDelivery queues the event of the delivery once more, whatever became of the delivery.
+func (w *Webhooks) ReplayDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	var replay *domain.WebhookDelivery
+	err := w.transactor.WithTx(ctx, func(tCtx context.Context) error {
+		delivery, err := w.webhookRepo.GetDelivery(tCtx, id)
+		if err != nil {
+			return err
+		}
+
+		replay = delivery.Replay(w.now())
+		if err := w.webhookRepo.InsertDelivery(tCtx, replay); err != nil {
+			return err
+		}
+
+		return w.webhookRepo.MarkDeadLetterReplayed(tCtx, delivery.ID, w.now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+func (w *Webhooks) send(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) error {
+	if !subscription.Active {
+		return fmt.Errorf("subscription %d is inactive", subscription.ID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
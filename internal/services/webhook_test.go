@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"mascot/internal/db"
+	"mascot/internal/domain"
+	"mascot/internal/repositories"
+)
+
+const testWebhookSecret = "s3cret"
+
+// webhookReceiver is an operator backend which checks signatures and fails while it is down.
+type webhookReceiver struct {
+	*httptest.Server
+	down atomic.Bool
+
+	mu       sync.Mutex
+	received []string
+	invalid  int
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	t.Helper()
+	r := &webhookReceiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if SignWebhook(testWebhookSecret, req.Header.Get(WebhookTimestampHeader), body) != req.Header.Get(WebhookSignatureHeader) {
+			r.invalid++
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		r.received = append(r.received, req.Header.Get(WebhookEventHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestWebhooks_send(t *testing.T) {
+	t.Parallel()
+	receiver := newWebhookReceiver(t)
+	webhooks := NewWebhooks(nil, nil, receiver.Client(), domain.WebhookRetry{})
+
+	subscription := &domain.WebhookSubscription{ID: 1, URL: receiver.URL, Secret: testWebhookSecret, Active: true}
+	delivery := &domain.WebhookDelivery{ID: 7, EventType: domain.EventTypeBalanceChanged, Payload: []byte(`{"id":1}`)}
+	if err := webhooks.send(context.Background(), subscription, delivery); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	receiver.down.Store(true)
+	if err := webhooks.send(context.Background(), subscription, delivery); err == nil {
+		t.Errorf("send() to a receiver which is down succeeded")
+	}
+
+	receiver.down.Store(false)
+	subscription.Secret = "wrong"
+	if err := webhooks.send(context.Background(), subscription, delivery); err == nil {
+		t.Errorf("send() with a wrong signature succeeded")
+	}
+
+	if len(receiver.received) != 1 || receiver.received[0] != string(domain.EventTypeBalanceChanged) || receiver.invalid != 1 {
+		t.Errorf("received = %v, invalid = %d", receiver.received, receiver.invalid)
+	}
+}
+
+func TestWebhooks_Deliver(t *testing.T) {
+	s := newConcurrencySuite(t)
+	ctx := context.Background()
+	receiver := newWebhookReceiver(t)
+	receiver.down.Store(true)
+
+	transactor := db.NewTransactor(s.conn, zap.NewNop())
+	webhookRepo := repositories.NewWebhook(transactor)
+	webhooks := NewWebhooks(transactor, webhookRepo, receiver.Client(), domain.WebhookRetry{MaxAttempts: 3})
+
+	operator := "operator-" + s.newPlayer(t)
+	player, stranger := operator+"-player", operator+"-stranger"
+	for name, owner := range map[string]string{player: operator, stranger: operator + "-other"} {
+		if _, err := s.wallet.CreateWallet(ctx, name, "USD", owner, 0); err != nil {
+			t.Fatalf("CreateWallet() error = %v", err)
+		}
+	}
+
+	subscription := &domain.WebhookSubscription{
+		Operator:   operator,
+		URL:        receiver.URL,
+		EventTypes: []domain.EventType{domain.EventTypeTransactionRolledBack},
+		Secret:     testWebhookSecret,
+	}
+	if err := webhooks.CreateSubscription(ctx, subscription); err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+
+	events := []*domain.Event{
+		{ID: 1, Type: domain.EventTypeBalanceChanged, PlayerName: player, Payload: []byte(`{}`), CreatedAt: time.Now()},
+		{ID: 2, Type: domain.EventTypeTransactionRolledBack, PlayerName: player, Payload: []byte(`{}`), CreatedAt: time.Now()},
+		{ID: 3, Type: domain.EventTypeTransactionRolledBack, PlayerName: stranger, Payload: []byte(`{}`), CreatedAt: time.Now()},
+	}
+	// the rollback is published again as a dispatch retried after a later sink failed
+	for _, event := range append(events, events[1]) {
+		if err := webhooks.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// without backoff the delivery runs out of attempts at once
+	if _, err := webhooks.Deliver(ctx); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	dead, err := webhooks.GetDeliveries(ctx, subscription.ID, domain.WebhookDeliveryStatusDead, 0, 10)
+	if err != nil {
+		t.Fatalf("GetDeliveries() error = %v", err)
+	}
+	// the rollback of the wallet of another operator isn't queued for the subscription
+	if len(dead) != 1 || dead[0].EventID != 2 || dead[0].Attempts != 3 {
+		t.Fatalf("dead deliveries = %+v, want the rollback after 3 attempts", dead)
+	}
+
+	receiver.down.Store(false)
+	if _, err := webhooks.ReplayDelivery(ctx, dead[0].ID); err != nil {
+		t.Fatalf("ReplayDelivery() error = %v", err)
+	}
+	if _, err := webhooks.Deliver(ctx); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	delivered, err := webhooks.GetDeliveries(ctx, subscription.ID, domain.WebhookDeliveryStatusDelivered, 0, 10)
+	if err != nil {
+		t.Fatalf("GetDeliveries() error = %v", err)
+	}
+	if len(delivered) != 1 || delivered[0].EventID != 2 {
+		t.Errorf("delivered = %+v, want the replayed rollback", delivered)
+	}
+}
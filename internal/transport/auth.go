@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"mascot/internal/handlers"
+)
+
+// ActorAuth lets through the requests sending Authorization: Bearer <token> with the token of an actor,
+// whom the handlers find in the request context. actors are the actor names by token.
+func ActorAuth(actors map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		actor, ok := authenticate(actors, req.Header.Get("Authorization"))
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(&ServerResponse{
+				Jsonrpc: version,
+				Error:   handlers.NewError(handlers.ErrUnauthorized, "unauthorized"),
+			})
+			return
+		}
+
+		next.ServeHTTP(w, req.WithContext(handlers.WithActor(req.Context(), actor)))
+	})
+}
+
+// authenticate compares the bearer token with every token in constant time.
+func authenticate(actors map[string]string, authorization string) (string, bool) {
+	const prefix = "Bearer "
+	if !strings.HasPrefix(authorization, prefix) {
+		return "", false
+	}
+
+	token := []byte(strings.TrimSpace(authorization[len(prefix):]))
+	var found string
+	for candidate, actor := range actors {
+		if subtle.ConstantTimeCompare(token, []byte(candidate)) == 1 {
+			found = actor
+		}
+	}
+
+	return found, found != ""
+}
@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestActorAuth(t *testing.T) {
+	t.Parallel()
+	actors := map[string]string{"s3cret": "alice", "t0ken": "bob"}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantActor     string
+		wantStatus    int
+	}{
+		{name: "known token", authorization: "Bearer t0ken", wantActor: "bob", wantStatus: http.StatusOK},
+		{name: "unknown token", authorization: "Bearer nope", wantStatus: http.StatusUnauthorized},
+		{name: "another scheme", authorization: "Basic s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "empty token", authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+		{name: "no header", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if actor, _ := authenticate(actors, tt.authorization); actor != tt.wantActor {
+				t.Errorf("authenticate() = %q, want %q", actor, tt.wantActor)
+			}
+
+			called := false
+			handler := ActorAuth(actors, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				called = true
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus || called != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("got status %d with handler called %v, want %d", rec.Code, called, tt.wantStatus)
+			}
+		})
+	}
+}
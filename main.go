@@ -2,20 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"go.uber.org/zap"
 
 	"mascot/internal/app"
+	"mascot/internal/cli"
 	"mascot/internal/config"
 )
 
 const serviceName = "mascot"
 
 func main() {
+	configFile := flag.String("config", os.Getenv("MASCOT_CONFIG"), "YAML config file, MASCOT_CONFIG by default")
+	storage := flag.String("storage", "", "wallet storage, postgres or memory; shorthand of --set storage=")
+	var overrides config.Overrides
+	flag.Var(&overrides, "set", "override a setting of the config file and the environment, as path=value; repeatable")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), cli.Usage)
+	}
+	flag.Parse()
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -26,19 +39,29 @@ func main() {
 
 	logger = logger.With(zap.String("service", serviceName))
 
-	cfg, err := config.Init(serviceName)
-	if err != nil {
-		logger.Fatal("init config", zap.Error(err))
+	if *storage != "" {
+		overrides = append(overrides, "storage="+*storage)
 	}
 
-	service := app.NewService(logger)
-	go service.Start(ctx, cfg)
-
-	<-ctx.Done()
+	cfg, err := config.Load(config.Sources{
+		File:      *configFile,
+		EnvPrefix: serviceName,
+		OtherEnv:  []string{"MASCOT_CONFIG", cli.AdminTokenEnv, "MASCOT_TEST_POSTGRES_DSN"},
+		Overrides: overrides,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := service.Shutdown(ctx); err != nil {
-		logger.Fatal("shutdown service", zap.Error(err))
+	err = cli.New(app.NewService(logger), cfg, os.Stdout).Run(ctx, flag.Args())
+	switch {
+	case errors.Is(err, cli.ErrUsage):
+		fmt.Fprintf(os.Stderr, "%v\n\n%s", err, cli.Usage)
+		os.Exit(2)
+	case errors.Is(err, cli.ErrDiscrepancies):
+		os.Exit(1)
+	case err != nil:
+		logger.Fatal("run command", zap.Strings("args", flag.Args()), zap.Error(err))
 	}
 }
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets ADD COLUMN status VARCHAR NOT NULL DEFAULT 'active';
+
+CREATE TABLE wallet_status_changes (
+    id BIGSERIAL NOT NULL CONSTRAINT wallet_status_changes_pk PRIMARY KEY,
+    wallet_id BIGINT NOT NULL REFERENCES wallets (id),
+    from_status VARCHAR NOT NULL,
+    to_status VARCHAR NOT NULL,
+    actor VARCHAR NOT NULL,
+    reason VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_status_changes_wallet_id_idx ON wallet_status_changes (wallet_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE wallet_status_changes;
+ALTER TABLE wallets DROP COLUMN status;
+-- +goose StatementEnd
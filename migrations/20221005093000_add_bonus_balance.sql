@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN bonus_balance BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN bonus_wagering_requirement BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN bonus_wagered BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN bonus_expires_at TIMESTAMPTZ;
+
+ALTER TABLE transactions
+    ADD COLUMN kind VARCHAR NOT NULL DEFAULT 'withdraw_and_deposit',
+    ADD COLUMN withdraw_bonus BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN deposit_bonus BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX wallets_bonus_expires_at_idx ON wallets (bonus_expires_at) WHERE bonus_expires_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX wallets_bonus_expires_at_idx;
+
+ALTER TABLE transactions
+    DROP COLUMN kind,
+    DROP COLUMN withdraw_bonus,
+    DROP COLUMN deposit_bonus;
+
+ALTER TABLE wallets
+    DROP COLUMN bonus_balance,
+    DROP COLUMN bonus_wagering_requirement,
+    DROP COLUMN bonus_wagered,
+    DROP COLUMN bonus_expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions
+    ADD COLUMN game_id VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN round_ref VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN finished BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX transactions_round_idx ON transactions (player_name, game_id, round_ref) WHERE round_ref <> '';
+
+CREATE TABLE rounds (
+    id BIGSERIAL NOT NULL CONSTRAINT rounds_pk PRIMARY KEY,
+    player_name VARCHAR NOT NULL,
+    game_id VARCHAR NOT NULL,
+    round_ref VARCHAR NOT NULL,
+    currency VARCHAR NOT NULL,
+    total_bet BIGINT NOT NULL DEFAULT 0,
+    total_win BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    CONSTRAINT rounds_player_game_round_key UNIQUE (player_name, game_id, round_ref)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE rounds;
+DROP INDEX transactions_round_idx;
+
+ALTER TABLE transactions
+    DROP COLUMN game_id,
+    DROP COLUMN round_ref,
+    DROP COLUMN finished;
+-- +goose StatementEnd
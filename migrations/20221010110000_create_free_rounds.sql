@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE free_rounds (
+    id BIGSERIAL NOT NULL CONSTRAINT free_rounds_pk PRIMARY KEY,
+    ref VARCHAR NOT NULL UNIQUE,
+    player_name VARCHAR NOT NULL,
+    game_id VARCHAR NOT NULL,
+    campaign VARCHAR NOT NULL DEFAULT '',
+    currency VARCHAR NOT NULL,
+    count BIGINT NOT NULL,
+    remaining BIGINT NOT NULL,
+    bet_value BIGINT NOT NULL,
+    wagering_multiplier BIGINT NOT NULL DEFAULT 0,
+    total_win BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX free_rounds_player_name_idx ON free_rounds (player_name);
+CREATE INDEX free_rounds_campaign_idx ON free_rounds (campaign);
+
+ALTER TABLE transactions
+    ADD COLUMN free_rounds_ref VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN free_round_spent BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions
+    DROP COLUMN free_rounds_ref,
+    DROP COLUMN free_round_spent;
+
+DROP TABLE free_rounds;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE jackpot_pools (
+    id BIGSERIAL NOT NULL CONSTRAINT jackpot_pools_pk PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    currency VARCHAR NOT NULL,
+    balance BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE jackpot_contributions (
+    id BIGSERIAL NOT NULL CONSTRAINT jackpot_contributions_pk PRIMARY KEY,
+    pool_id BIGINT NOT NULL REFERENCES jackpot_pools (id),
+    game_id VARCHAR NOT NULL DEFAULT '',
+    currency VARCHAR NOT NULL,
+    rate_basis_points BIGINT NOT NULL,
+    CONSTRAINT jackpot_contributions_pool_game_key UNIQUE (pool_id, game_id)
+);
+
+CREATE INDEX jackpot_contributions_currency_idx ON jackpot_contributions (currency, game_id);
+
+CREATE TABLE jackpot_ledger (
+    id BIGSERIAL NOT NULL CONSTRAINT jackpot_ledger_pk PRIMARY KEY,
+    pool_id BIGINT NOT NULL REFERENCES jackpot_pools (id),
+    transaction_id VARCHAR NOT NULL DEFAULT '',
+    kind VARCHAR NOT NULL,
+    amount BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX jackpot_ledger_pool_id_idx ON jackpot_ledger (pool_id, id);
+CREATE INDEX jackpot_ledger_transaction_id_idx ON jackpot_ledger (transaction_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE jackpot_ledger;
+DROP TABLE jackpot_contributions;
+DROP TABLE jackpot_pools;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX transactions_player_name_created_at_idx ON transactions (player_name, created_at DESC, id DESC);
+CREATE INDEX transactions_created_at_idx ON transactions (created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX transactions_created_at_idx;
+DROP INDEX transactions_player_name_created_at_idx;
+
+ALTER TABLE transactions
+    DROP COLUMN created_at,
+    DROP COLUMN updated_at;
+-- +goose StatementEnd
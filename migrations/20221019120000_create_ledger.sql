@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE ledger_entries (
+    id BIGSERIAL NOT NULL CONSTRAINT ledger_entries_pk PRIMARY KEY,
+    set_id VARCHAR NOT NULL,
+    transaction_id VARCHAR NOT NULL DEFAULT '',
+    kind VARCHAR NOT NULL,
+    account VARCHAR NOT NULL,
+    currency VARCHAR NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ledger_entries_set_id_idx ON ledger_entries (set_id);
+CREATE INDEX ledger_entries_account_idx ON ledger_entries (account, id);
+CREATE INDEX ledger_entries_transaction_id_idx ON ledger_entries (transaction_id);
+
+CREATE FUNCTION ledger_entries_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger entries are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_no_update BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE PROCEDURE ledger_entries_immutable();
+
+CREATE TRIGGER ledger_entries_no_truncate BEFORE TRUNCATE ON ledger_entries
+    FOR EACH STATEMENT EXECUTE PROCEDURE ledger_entries_immutable();
+
+-- balances existing before the ledger are booked as opening entry sets against the house
+INSERT INTO ledger_entries (set_id, kind, account, currency, amount)
+SELECT 'opening:wallet:' || id, 'opening', 'player:' || player_name || ':real', upper(currency), balance
+FROM wallets WHERE balance <> 0
+UNION ALL
+SELECT 'opening:wallet:' || id, 'opening', 'player:' || player_name || ':bonus', upper(currency), bonus_balance
+FROM wallets WHERE bonus_balance <> 0
+UNION ALL
+SELECT 'opening:wallet:' || id, 'opening', 'house:' || upper(currency), upper(currency), -(balance + bonus_balance)
+FROM wallets WHERE balance + bonus_balance <> 0
+UNION ALL
+SELECT 'opening:jackpot:' || id, 'opening', 'jackpot:' || name, upper(currency), balance
+FROM jackpot_pools WHERE balance <> 0
+UNION ALL
+SELECT 'opening:jackpot:' || id, 'opening', 'house:' || upper(currency), upper(currency), -balance
+FROM jackpot_pools WHERE balance <> 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ledger_entries;
+DROP FUNCTION ledger_entries_immutable();
+-- +goose StatementEnd
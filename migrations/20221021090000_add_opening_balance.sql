@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN opening_balance BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN opening_bonus_balance BIGINT NOT NULL DEFAULT 0;
+
+-- existing wallets open with whatever their history doesn't explain, so reconciliation starts clean
+UPDATE wallets w SET
+    opening_balance = w.balance - coalesce(t.real_movement, 0),
+    opening_bonus_balance = w.bonus_balance - coalesce(t.bonus_movement, 0)
+FROM wallets w2 LEFT JOIN (
+    SELECT player_name,
+           sum((coalesce(deposit, 0) - deposit_bonus) - (coalesce(withdraw, 0) - withdraw_bonus)) AS real_movement,
+           sum(deposit_bonus - withdraw_bonus) AS bonus_movement
+    FROM transactions WHERE NOT rolled_back GROUP BY player_name
+) t ON t.player_name = w2.player_name
+WHERE w.id = w2.id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallets DROP COLUMN opening_balance, DROP COLUMN opening_bonus_balance;
+-- +goose StatementEnd
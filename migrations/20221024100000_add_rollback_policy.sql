@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN debt BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN rollback_policy VARCHAR NOT NULL DEFAULT '';
+
+ALTER TABLE transactions ADD COLUMN rollback_shortfall BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP COLUMN rollback_shortfall;
+ALTER TABLE wallets DROP COLUMN debt, DROP COLUMN rollback_policy;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets ADD COLUMN held_balance BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE reservations (
+    id BIGSERIAL NOT NULL CONSTRAINT reservations_pk PRIMARY KEY,
+    ref VARCHAR NOT NULL CONSTRAINT reservations_ref_key UNIQUE,
+    player_name VARCHAR NOT NULL,
+    currency VARCHAR NOT NULL,
+    amount BIGINT NOT NULL,
+    committed_amount BIGINT NOT NULL DEFAULT 0,
+    game_id VARCHAR NOT NULL DEFAULT '',
+    round_ref VARCHAR NOT NULL DEFAULT '',
+    status VARCHAR NOT NULL,
+    transaction_id VARCHAR NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX reservations_held_expires_at_idx ON reservations (expires_at) WHERE status = 'held';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reservations;
+ALTER TABLE wallets DROP COLUMN held_balance;
+-- +goose StatementEnd
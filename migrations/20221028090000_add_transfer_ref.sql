@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions ADD COLUMN transfer_ref VARCHAR NOT NULL DEFAULT '';
+
+CREATE INDEX transactions_transfer_ref_idx ON transactions (transfer_ref) WHERE transfer_ref <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP COLUMN transfer_ref;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE adjustments (
+    id BIGSERIAL NOT NULL CONSTRAINT adjustments_pk PRIMARY KEY,
+    player_name VARCHAR NOT NULL,
+    currency VARCHAR NOT NULL,
+    amount BIGINT NOT NULL,
+    reason VARCHAR NOT NULL,
+    comment VARCHAR NOT NULL,
+    created_by VARCHAR NOT NULL,
+    reviewed_by VARCHAR NOT NULL DEFAULT '',
+    review_comment VARCHAR NOT NULL DEFAULT '',
+    status VARCHAR NOT NULL,
+    transaction_id VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX adjustments_pending_idx ON adjustments (created_at) WHERE status = 'pending';
+CREATE INDEX adjustments_player_name_idx ON adjustments (player_name, created_at);
+
+CREATE TABLE adjustment_events (
+    id BIGSERIAL NOT NULL CONSTRAINT adjustment_events_pk PRIMARY KEY,
+    adjustment_id BIGINT NOT NULL REFERENCES adjustments (id),
+    status VARCHAR NOT NULL,
+    actor VARCHAR NOT NULL,
+    comment VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX adjustment_events_adjustment_id_idx ON adjustment_events (adjustment_id, id);
+CREATE INDEX adjustment_events_actor_idx ON adjustment_events (actor, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE adjustment_events;
+DROP TABLE adjustments;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webhook_subscriptions (
+    id BIGSERIAL NOT NULL CONSTRAINT webhook_subscriptions_pk PRIMARY KEY,
+    operator VARCHAR NOT NULL,
+    url VARCHAR NOT NULL,
+    event_types VARCHAR[] NOT NULL,
+    secret VARCHAR NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL NOT NULL CONSTRAINT webhook_deliveries_pk PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions (id),
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMPTZ,
+    replay_of BIGINT REFERENCES webhook_deliveries (id)
+);
+
+-- an event is published to a subscription once, only replays deliver it again
+CREATE UNIQUE INDEX webhook_deliveries_event_key ON webhook_deliveries (subscription_id, event_id) WHERE replay_of IS NULL;
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_subscription_id_idx ON webhook_deliveries (subscription_id, id);
+
+CREATE TABLE webhook_dead_letters (
+    id BIGSERIAL NOT NULL CONSTRAINT webhook_dead_letters_pk PRIMARY KEY,
+    delivery_id BIGINT NOT NULL CONSTRAINT webhook_dead_letters_delivery_id_key UNIQUE REFERENCES webhook_deliveries (id),
+    subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions (id),
+    event_type VARCHAR NOT NULL,
+    attempts INT NOT NULL,
+    last_error VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    replayed_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_dead_letters_pending_idx ON webhook_dead_letters (id) WHERE replayed_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_dead_letters;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
+-- +goose StatementEnd
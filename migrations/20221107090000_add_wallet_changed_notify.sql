@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION notify_wallet_changed() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('wallet_changed', OLD.player_name);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallet_changed
+    AFTER UPDATE OR DELETE ON wallets
+    FOR EACH ROW EXECUTE PROCEDURE notify_wallet_changed();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER wallet_changed ON wallets;
+DROP FUNCTION notify_wallet_changed();
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions
+    ADD COLUMN lost_bonus_requirement BIGINT,
+    ADD COLUMN lost_bonus_wagered BIGINT,
+    ADD COLUMN lost_bonus_expires_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions
+    DROP COLUMN lost_bonus_requirement,
+    DROP COLUMN lost_bonus_wagered,
+    DROP COLUMN lost_bonus_expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox_events ADD COLUMN next_attempt_at TIMESTAMPTZ;
+
+CREATE INDEX outbox_events_backoff_idx ON outbox_events (player_name)
+    WHERE dispatched_at IS NULL AND next_attempt_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX outbox_events_backoff_idx;
+ALTER TABLE outbox_events DROP COLUMN next_attempt_at;
+-- +goose StatementEnd
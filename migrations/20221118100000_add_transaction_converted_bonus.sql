@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions
+    ADD COLUMN converted_bonus_balance BIGINT,
+    ADD COLUMN converted_bonus_requirement BIGINT,
+    ADD COLUMN converted_bonus_wagered BIGINT,
+    ADD COLUMN converted_bonus_expires_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions
+    DROP COLUMN converted_bonus_balance,
+    DROP COLUMN converted_bonus_requirement,
+    DROP COLUMN converted_bonus_wagered,
+    DROP COLUMN converted_bonus_expires_at;
+-- +goose StatementEnd